@@ -0,0 +1,35 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/2pizzzza/TestTask/internal/enrichstub"
+)
+
+func main() {
+	port := flag.Int("port", 8000, "port to listen on")
+	fixturesPath := flag.String("fixtures", "cmd/enrichstub/fixtures.json", "JSON fixture file")
+	latency := flag.Duration("latency", 0, "delay added to every response")
+	errorRate := flag.Float64("error-rate", 0, "fraction of requests answered with HTTP 500")
+	malformedRate := flag.Float64("malformed-rate", 0, "fraction of requests answered with a truncated JSON body")
+	seed := flag.Int64("seed", 0, "random seed for fault injection, 0 for time-based")
+	flag.Parse()
+
+	fixtures, err := enrichstub.LoadFixtures(*fixturesPath)
+	if err != nil {
+		log.Fatalf("failed to load fixtures: %s", err)
+	}
+
+	stub := enrichstub.New(fixtures, enrichstub.Options{
+		Latency:       *latency,
+		ErrorRate:     *errorRate,
+		MalformedRate: *malformedRate,
+		Seed:          *seed,
+	})
+
+	log.Printf("Enrichment stub is live. port: %d, fixtures: %d", *port, len(fixtures))
+	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", *port), stub))
+}
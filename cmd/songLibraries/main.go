@@ -1,19 +1,37 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	_ "github.com/2pizzzza/TestTask/cmd/songLibraries/docs"
 	"github.com/2pizzzza/TestTask/internal/config"
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/enrichment"
+	"github.com/2pizzzza/TestTask/internal/events"
+	"github.com/2pizzzza/TestTask/internal/health"
 	"github.com/2pizzzza/TestTask/internal/http-server/handlers"
+	"github.com/2pizzzza/TestTask/internal/http-server/middleware/auth"
 	"github.com/2pizzzza/TestTask/internal/http-server/middleware/logger"
+	"github.com/2pizzzza/TestTask/internal/http-server/middleware/requestid"
 	"github.com/2pizzzza/TestTask/internal/lib/logger/sl"
+	"github.com/2pizzzza/TestTask/internal/linkcheck"
+	"github.com/2pizzzza/TestTask/internal/metrics"
 	"github.com/2pizzzza/TestTask/internal/service"
+	"github.com/2pizzzza/TestTask/internal/storage/memory"
 	"github.com/2pizzzza/TestTask/internal/storage/postgres"
+	"github.com/2pizzzza/TestTask/internal/storage/sqlite"
+	"github.com/2pizzzza/TestTask/internal/tracing"
+	"github.com/2pizzzza/TestTask/internal/webhook"
 	httpSwagger "github.com/swaggo/http-swagger"
-	"log"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
 )
 
 const (
@@ -26,6 +44,7 @@ const (
 // @version 1.0
 // @termsOfService http://swagger.io/terms/
 
+// @contact.name API Support
 // @contact.url http://www.swagger.io/support
 // @contact.email support@swagger.io
 
@@ -34,38 +53,386 @@ const (
 
 // @host 127.0.0.1:8080
 // @BasePath /
+
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description API key created with songlib keys create, or an access token from /auth/login, sent as "Bearer <token>".
 func main() {
-	env, err := config.NewConfig()
+	env, err := config.Load(os.Args[1:])
 
 	if err != nil {
 		slog.Error("Failed load env", sl.Err(err))
+		os.Exit(1)
 	}
 
 	logs := setupLogger(env.Env)
+	// Libraries and stray slog calls log through the configured handler too.
+	slog.SetDefault(logs)
 
-	db, err := postgres.New(env)
+	shutdownTracing, err := tracing.Setup(context.Background(), env.Tracing)
+	if err != nil {
+		logs.Error("Failed to set up tracing", sl.Err(err))
+		os.Exit(1)
+	}
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	db, err := openStorage(ctx, env, logs)
 	if err != nil {
-		logs.Error("Failed connect db err: %s", sl.Err(err))
+		logs.Error("Failed to open storage", slog.String("storage", env.Storage), sl.Err(err))
+		os.Exit(1)
 	}
 
-	songService := service.New(*logs, db)
-	songHandler := handlers.New(songService)
+	enrichClient := enrichment.NewClient(env.Enrichment.ApiUrl)
 
 	mux := http.NewServeMux()
+
+	appMetrics := metrics.New(mux)
+	if pg, ok := db.(*postgres.Storage); ok {
+		appMetrics.RegisterDB(pg.Db)
+	}
+	appMetrics.RegisterCatalog(logs, db, env.HttpConn.HealthCheckTimeout)
+
+	checker := health.New(env.HttpConn.HealthCheckTimeout)
+	checker.Add("database", true, db.Ping)
+	checker.Add("migrations", true, db.CheckSchema)
+	checker.Add("enrichment", false, enrichClient.Ping)
+	healthHandler := handlers.NewHealth(checker)
+
+	var cacheStore enrichment.Store
+	if pg, ok := db.(*postgres.Storage); ok && env.Enrichment.PersistentCache {
+		cacheStore = pg
+	}
+	enricher := enrichment.NewCachedProvider(
+		logs,
+		appMetrics.InstrumentProvider(enrichClient),
+		cacheStore,
+		env.Enrichment.CacheSize,
+		env.Enrichment.CacheTTL,
+		env.Enrichment.NegativeTTL,
+	)
+
+	songService := service.New(*logs, db, db, db, db, enricher, env.DBConn.RequestTimeout)
+	songHandler := handlers.New(songService, songService, env.DBConn.RequestTimeout)
+	adminHandler := handlers.NewAdmin(enricher, songService)
+
+	mux.HandleFunc("GET /healthz", healthHandler.LivenessHandler)
+	mux.HandleFunc("GET /readyz", healthHandler.ReadinessHandler)
+	mux.HandleFunc("GET /startupz", healthHandler.StartupHandler)
+	mux.Handle("GET /metrics", appMetrics.Handler())
 	mux.HandleFunc("/songs/create", songHandler.CreateSongHandler)
 	mux.HandleFunc("/songs/update", songHandler.UpdateSongHandler)
 	mux.HandleFunc("/songs/info", songHandler.GetSongByIDHandler)
 	mux.HandleFunc("/songs/delete", songHandler.DeleteSongHandler)
 	mux.HandleFunc("/songs", songHandler.GetAllSongsHandler)
 	mux.HandleFunc("/songs/{id}/lyrics", songHandler.GetSongLyricsHandler)
+	mux.HandleFunc("PUT /songs/{id}/lyrics", songHandler.UpdateSongLyricsHandler)
+	mux.HandleFunc("GET /songs/{id}/lyrics/revisions", songHandler.GetLyricsRevisionsHandler)
+	mux.HandleFunc("POST /songs/{id}/lyrics/rollback", songHandler.RollbackLyricsHandler)
+	mux.HandleFunc("GET /songs/{id}/links", songHandler.GetSongLinksHandler)
+	mux.HandleFunc("POST /songs/{id}/links", songHandler.CreateSongLinkHandler)
+	mux.HandleFunc("PUT /songs/{id}/links/{linkId}", songHandler.UpdateSongLinkHandler)
+	mux.HandleFunc("DELETE /songs/{id}/links/{linkId}", songHandler.DeleteSongLinkHandler)
+	mux.HandleFunc("GET /links/broken", songHandler.GetBrokenLinksHandler)
+	mux.HandleFunc("/admin/enrichment/cache", adminHandler.InvalidateEnrichmentCacheHandler)
+	mux.HandleFunc("/admin/enrich", adminHandler.ReEnrichHandler)
 	mux.HandleFunc("/swagger/", httpSwagger.WrapHandler)
 
-	loggedMux := logger.LoggingMiddleware(mux)
-	_ = songService
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+
+	if env.LinkCheck.Enabled {
+		checker := linkcheck.New(logs, db, env.LinkCheck)
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			checker.Run(workersCtx)
+		}()
+	}
+
+	dispatcher := webhook.New(logs, db, env.Webhooks)
+	webhookHandler := handlers.NewWebhooks(service.NewWebhooks(logs, db, dispatcher), env.DBConn.RequestTimeout)
+
+	mux.HandleFunc("GET /webhooks", webhookHandler.ListWebhooksHandler)
+	mux.HandleFunc("POST /webhooks", webhookHandler.CreateWebhookHandler)
+	mux.HandleFunc("GET /webhooks/{id}", webhookHandler.GetWebhookHandler)
+	mux.HandleFunc("PUT /webhooks/{id}", webhookHandler.UpdateWebhookHandler)
+	mux.HandleFunc("DELETE /webhooks/{id}", webhookHandler.DeleteWebhookHandler)
+	mux.HandleFunc("GET /webhooks/{id}/deliveries", webhookHandler.ListDeliveriesHandler)
+	mux.HandleFunc("POST /webhooks/{id}/test", webhookHandler.SendTestEventHandler)
+
+	feed := events.NewMemoryPublisher(env.Events.FeedBuffer)
+	eventHandler := handlers.NewEvents(logs, feed, db, env.Events.Heartbeat)
+	mux.HandleFunc("GET /songs/events", eventHandler.SongEventsHandler)
+
+	dispatcher.Start(workersCtx)
+
+	// Webhook jobs are written in the relay transaction, so they go first:
+	// a failure there rolls the batch back before the file and the feed,
+	// which cannot be undone, have seen it.
+	var filePublisher *events.FilePublisher
+	publishers := events.MultiPublisher{dispatcher}
+	if publisher := setupPublisher(env.Events, logs); publisher != nil {
+		filePublisher = publisher
+		publishers = append(publishers, publisher)
+	}
+	publishers = append(publishers, feed)
+	relay := events.NewRelay(logs, db, publishers, env.Events.RelayInterval, env.Events.BatchSize)
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		relay.Run(workersCtx)
+	}()
+
+	var api http.Handler = mux
+	if env.Auth.Enabled {
+		keys, ok := db.(service.APIKeyRepository)
+		if !ok {
+			logs.Error("Storage does not keep API keys", slog.String("storage", env.Storage))
+			os.Exit(1)
+		}
+		authn := auth.Chain{service.NewAPIKeys(logs, keys)}
+
+		if env.Auth.JWTSecret != "" {
+			users, ok := db.(service.UserRepository)
+			if !ok {
+				logs.Error("Storage does not keep users", slog.String("storage", env.Storage))
+				os.Exit(1)
+			}
+			userService := service.NewUsers(logs, users, db, env.Auth)
+			authHandler := handlers.NewAuth(userService, env.DBConn.RequestTimeout)
+			mux.HandleFunc("POST /auth/register", authHandler.RegisterHandler)
+			mux.HandleFunc("POST /auth/login", authHandler.LoginHandler)
+			mux.HandleFunc("POST /auth/refresh", authHandler.RefreshHandler)
+			mux.HandleFunc("POST /auth/logout", authHandler.LogoutHandler)
+			authn = append(authn, userService)
+		} else {
+			logs.Info("AUTH_JWT_SECRET is not set, user accounts are disabled")
+		}
+
+		api = auth.Middleware(logs, authn, scopePolicy(mux), mux)
+	} else {
+		logs.Warn("API key checks are disabled, every route is public")
+	}
+
+	handler := otelhttp.NewHandler(
+		requestid.Middleware(logs, logger.LoggingMiddleware(api, appMetrics)),
+		"http.server",
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return routeName(mux, r)
+		}),
+		// Probes and scrapes are noise; event streams would be one span per
+		// connection and need the unwrapped writer to lift the write deadline.
+		otelhttp.WithFilter(func(r *http.Request) bool {
+			switch r.URL.Path {
+			case "/healthz", "/readyz", "/startupz", "/metrics", "/songs/events":
+				return false
+			}
+			return true
+		}),
+	)
+
+	srv := &http.Server{
+		Addr:              fmt.Sprintf(":%d", env.HttpConn.HttpPort),
+		Handler:           handler,
+		ReadTimeout:       env.HttpConn.ReadTimeout,
+		ReadHeaderTimeout: env.HttpConn.ReadHeaderTimeout,
+		WriteTimeout:      env.HttpConn.WriteTimeout,
+		IdleTimeout:       env.HttpConn.IdleTimeout,
+	}
+	srv.RegisterOnShutdown(eventHandler.Shutdown)
+
+	serverErr := make(chan error, 1)
+	go func() {
+		logs.Info("Server is live", slog.Int("port", env.HttpConn.HttpPort))
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+		close(serverErr)
+	}()
+	checker.MarkStarted()
+
+	select {
+	case err := <-serverErr:
+		if err != nil {
+			logs.Error("Server failed", sl.Err(err))
+		}
+	case <-ctx.Done():
+		logs.Info("Shutting down", slog.Duration("timeout", env.HttpConn.ShutdownTimeout))
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), env.HttpConn.ShutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		logs.Error("Failed to drain connections", sl.Err(err))
+	}
+
+	adminHandler.Shutdown()
+	stopWorkers()
+	workersDone := make(chan struct{})
+	go func() {
+		workers.Wait()
+		dispatcher.Wait()
+		close(workersDone)
+	}()
+	select {
+	case <-workersDone:
+	case <-shutdownCtx.Done():
+		logs.Error("Background workers did not stop in time")
+	}
+
+	if filePublisher != nil {
+		if err := filePublisher.Close(); err != nil {
+			logs.Error("Failed to close events file", sl.Err(err))
+		}
+	}
+
+	if err := db.Close(); err != nil {
+		logs.Error("Failed to close storage", sl.Err(err))
+	}
+
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		logs.Error("Failed to flush traces", sl.Err(err))
+	}
+
+	logs.Info("Server stopped")
+}
+
+// routeName names server spans after the matched route, e.g. "GET /songs/{id}/links".
+func routeName(mux *http.ServeMux, r *http.Request) string {
+	_, pattern := mux.Handler(r)
+	switch {
+	case pattern == "":
+		return r.Method
+	case strings.HasPrefix(pattern, "/"):
+		return r.Method + " " + pattern
+	default:
+		return pattern
+	}
+}
+
+// routeScopes maps route patterns to the scope they need; "" marks a public
+// route. Routes missing here need the admin scope. Users get the scopes of
+// their role, see models.RoleScopes.
+var routeScopes = map[string]string{
+	"GET /healthz":                      "",
+	"GET /readyz":                       "",
+	"GET /startupz":                     "",
+	"GET /metrics":                      "",
+	"/swagger/":                         "",
+	"POST /auth/register":               "",
+	"POST /auth/login":                  "",
+	"POST /auth/refresh":                "",
+	"POST /auth/logout":                 "",
+	"/songs":                            models.ScopeSongsRead,
+	"/songs/info":                       models.ScopeSongsRead,
+	"/songs/{id}/lyrics":                models.ScopeSongsRead,
+	"GET /songs/{id}/lyrics/revisions":  models.ScopeSongsRead,
+	"GET /songs/{id}/links":             models.ScopeSongsRead,
+	"GET /links/broken":                 models.ScopeSongsRead,
+	"GET /songs/events":                 models.ScopeSongsRead,
+	"/songs/create":                     models.ScopeSongsWrite,
+	"/songs/update":                     models.ScopeSongsWrite,
+	"/songs/delete":                     models.ScopeSongsModerate,
+	"POST /songs/{id}/lyrics/rollback":  models.ScopeSongsModerate,
+	"PUT /songs/{id}/lyrics":            models.ScopeSongsWrite,
+	"POST /songs/{id}/links":            models.ScopeSongsWrite,
+	"PUT /songs/{id}/links/{linkId}":    models.ScopeSongsWrite,
+	"DELETE /songs/{id}/links/{linkId}": models.ScopeSongsWrite,
+}
+
+// scopePolicy looks the scope up by the route the mux picks for a request.
+// Requests no route matches are let through to get their 404 or 405.
+func scopePolicy(mux *http.ServeMux) auth.Policy {
+	return func(r *http.Request) string {
+		_, pattern := mux.Handler(r)
+		if pattern == "" {
+			return ""
+		}
+		if scope, ok := routeScopes[pattern]; ok {
+			return scope
+		}
+		return models.ScopeAdmin
+	}
+}
+
+// store is everything the server needs from a storage backend.
+type store interface {
+	service.SongRepository
+	service.LinkRepository
+	service.GroupRepository
+	service.TxManager
+	service.WebhookRepository
+	webhook.Store
+	linkcheck.Repository
+	events.OutboxStore
+	handlers.EventHistory
+	metrics.CatalogSource
+	Ping(ctx context.Context) error
+	CheckSchema(ctx context.Context) error
+	Close() error
+}
+
+func openStorage(ctx context.Context, env *config.Config, logs *slog.Logger) (store, error) {
+	switch env.Storage {
+	case "memory":
+		logs.Warn("Using in-memory storage, data is lost on restart")
+		return memory.New(), nil
+	case "sqlite":
+		return openSQLite(ctx, env, logs)
+	}
+
+	db, err := postgres.New(sl.WithLogger(ctx, logs), env)
+	if err != nil {
+		return nil, err
+	}
+
+	if env.DBConn.AutoMigrate {
+		if err := migrateUp(ctx, env.DBConn, logs); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	if err := db.CheckSchema(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("database schema is not up to date, run `songlib migrate up` or set DB_AUTO_MIGRATE=true: %w", err)
+	}
 
-	log.Printf("Server is live. port: %d", env.HttpConn.HttpPort)
-	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", env.HttpConn.HttpPort), loggedMux))
+	return db, nil
+}
+
+func openSQLite(ctx context.Context, env *config.Config, logs *slog.Logger) (store, error) {
+	db, err := sqlite.New(sl.WithLogger(ctx, logs), env.SQLite)
+	if err != nil {
+		return nil, err
+	}
+
+	// Nothing but this process can reach an in-memory database to migrate it.
+	if env.DBConn.AutoMigrate || env.SQLite.Path == ":memory:" {
+		if err := db.MigrateUp(ctx, logs); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	if err := db.CheckSchema(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("database schema is not up to date, run `songlib migrate up` or set DB_AUTO_MIGRATE=true: %w", err)
+	}
+
+	return db, nil
+}
+
+func migrateUp(ctx context.Context, cfg config.DatabaseConfig, logs *slog.Logger) error {
+	migrator, err := postgres.NewMigrator(cfg, logs)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	return migrator.Up(ctx)
 }
 
 func setupLogger(env string) *slog.Logger {
@@ -88,3 +455,17 @@ func setupLogger(env string) *slog.Logger {
 
 	return logs
 }
+
+func setupPublisher(cfg config.EventsConfig, logs *slog.Logger) *events.FilePublisher {
+	switch cfg.Publisher {
+	case "file":
+		publisher, err := events.NewFilePublisher(cfg.FilePath)
+		if err != nil {
+			logs.Error("Failed open events file", sl.Err(err))
+			return nil
+		}
+		return publisher
+	default:
+		return nil
+	}
+}
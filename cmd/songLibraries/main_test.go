@@ -0,0 +1,60 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+)
+
+func TestScopePolicy(t *testing.T) {
+	mux := http.NewServeMux()
+	noop := func(http.ResponseWriter, *http.Request) {}
+	// Registering every listed pattern also checks that it is valid and that
+	// none of them conflict.
+	for pattern := range routeScopes {
+		mux.HandleFunc(pattern, noop)
+	}
+	// Routes left out of routeScopes, like the admin and webhook ones.
+	mux.HandleFunc("/admin/enrich", noop)
+	mux.HandleFunc("GET /webhooks", noop)
+	mux.HandleFunc("POST /webhooks/{id}/test", noop)
+
+	policy := scopePolicy(mux)
+
+	tests := []struct {
+		method, path string
+		want         string
+	}{
+		{http.MethodGet, "/healthz", ""},
+		{http.MethodGet, "/metrics", ""},
+		{http.MethodGet, "/swagger/index.html", ""},
+		{http.MethodPost, "/auth/login", ""},
+		{http.MethodGet, "/songs", models.ScopeSongsRead},
+		{http.MethodGet, "/songs/events", models.ScopeSongsRead},
+		{http.MethodGet, "/songs/7/lyrics", models.ScopeSongsRead},
+		{http.MethodGet, "/songs/7/lyrics/revisions", models.ScopeSongsRead},
+		{http.MethodGet, "/songs/7/links", models.ScopeSongsRead},
+		{http.MethodPost, "/songs/create", models.ScopeSongsWrite},
+		{http.MethodPost, "/songs/7/links", models.ScopeSongsWrite},
+		{http.MethodDelete, "/songs/7/links/3", models.ScopeSongsWrite},
+		{http.MethodPut, "/songs/7/lyrics", models.ScopeSongsWrite},
+		{http.MethodDelete, "/songs/delete", models.ScopeSongsModerate},
+		{http.MethodPost, "/songs/7/lyrics/rollback", models.ScopeSongsModerate},
+		// Unlisted routes default to admin.
+		{http.MethodPost, "/admin/enrich", models.ScopeAdmin},
+		{http.MethodGet, "/webhooks", models.ScopeAdmin},
+		{http.MethodPost, "/webhooks/1/test", models.ScopeAdmin},
+		// Requests no route matches pass through to get their 404 or 405.
+		{http.MethodGet, "/nowhere", ""},
+		{http.MethodGet, "/auth/login", ""},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		if got := policy(req); got != tt.want {
+			t.Errorf("%s %s: scope = %q, want %q", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
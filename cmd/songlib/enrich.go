@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/lib/checkpoint"
+	"github.com/2pizzzza/TestTask/internal/service"
+)
+
+func runEnrich(args []string) error {
+	fs := flag.NewFlagSet("enrich", flag.ExitOnError)
+	concurrency := fs.Int("concurrency", 4, "parallel provider calls")
+	rate := fs.Float64("rate", 2, "provider calls per second, 0 for unlimited")
+	batch := fs.Int("batch", 100, "songs loaded per batch")
+	dryRun := fs.Bool("dry-run", false, "report what would be filled without writing")
+	checkpointPath := fs.String("checkpoint", ".songlib-enrich.checkpoint", "checkpoint file, empty to disable")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	a, err := newApp(ctx)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	var cp service.Checkpoint
+	if *checkpointPath != "" {
+		cp = checkpoint.New(*checkpointPath)
+	}
+
+	summary, err := a.service.ReEnrich(ctx, models.EnrichOptions{
+		Concurrency: *concurrency,
+		Rate:        *rate,
+		BatchSize:   *batch,
+		DryRun:      *dryRun,
+	}, cp)
+
+	printEnrichSummary(summary)
+
+	return err
+}
+
+func printEnrichSummary(summary models.EnrichSummary) {
+	if summary.DryRun {
+		fmt.Println("dry run: nothing was written")
+	}
+	fmt.Printf("scanned:   %d\n", summary.Scanned)
+	fmt.Printf("filled:    %d\n", summary.Filled)
+	fmt.Printf("not found: %d\n", summary.NotFound)
+	fmt.Printf("failed:    %d\n", summary.Failed)
+	for _, f := range summary.Failures {
+		fmt.Printf("  song %d: %s\n", f.SongID, f.Error)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/storage"
+)
+
+const groupsUsage = `Usage: songlib groups <command> [flags]
+
+Commands:
+  merge -into G FROM...      move the songs of the FROM groups into G and delete
+                             the emptied groups; takes -dry-run and -o table|json|csv
+`
+
+func runGroups(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, groupsUsage)
+		return errors.New("missing groups command")
+	}
+
+	command, rest := args[0], args[1:]
+	switch command {
+	case "merge":
+		return groupsMerge(rest)
+	case "help", "-h", "--help":
+		fmt.Print(groupsUsage)
+		return nil
+	default:
+		fmt.Fprint(os.Stderr, groupsUsage)
+		return fmt.Errorf("unknown groups command %q", command)
+	}
+}
+
+func groupsMerge(args []string) error {
+	c := newCommand("groups merge", true)
+	into := c.fs.String("into", "", "target group (required), created if missing")
+	from, err := c.parse(args)
+	if err != nil {
+		return err
+	}
+	if *into == "" || len(from) == 0 {
+		return errors.New("usage: songlib groups merge -into G FROM...")
+	}
+
+	ctx, a, p, done, err := c.start()
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	result, err := a.service.MergeGroups(ctx, from, *into, c.isDryRun())
+	if err != nil {
+		if errors.Is(err, storage.ErrGroupNotFound) {
+			return fmt.Errorf("group not found: %w", err)
+		}
+		return err
+	}
+
+	if result.DryRun {
+		dryRunNotice()
+	}
+	return printMerge(p, result)
+}
+
+func printMerge(p printer, result models.GroupMergeResult) error {
+	switch p.format {
+	case "json":
+		return p.json(result)
+	case "csv":
+		w := csv.NewWriter(p.out)
+		if err := w.Write([]string{"song_id", "result"}); err != nil {
+			return err
+		}
+		for _, id := range result.Moved {
+			if err := w.Write([]string{strconv.FormatInt(id, 10), "moved"}); err != nil {
+				return err
+			}
+		}
+		for _, id := range result.Conflicts {
+			if err := w.Write([]string{strconv.FormatInt(id, 10), "conflict"}); err != nil {
+				return err
+			}
+		}
+		w.Flush()
+		return w.Error()
+	default:
+		fmt.Fprintf(p.out, "into:      %s\n", result.Into)
+		fmt.Fprintf(p.out, "moved:     %d %s\n", len(result.Moved), idList(result.Moved))
+		fmt.Fprintf(p.out, "conflicts: %d %s\n", len(result.Conflicts), idList(result.Conflicts))
+		fmt.Fprintf(p.out, "removed:   %s\n", strings.Join(result.RemovedGroups, ", "))
+		if len(result.Conflicts) > 0 {
+			fmt.Fprintln(p.out, "conflicting songs already exist in the target group and were left in place")
+		}
+		return nil
+	}
+}
+
+func idList(ids []int64) string {
+	if len(ids) == 0 {
+		return ""
+	}
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.FormatInt(id, 10)
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/storage"
+)
+
+const keysUsage = `Usage: songlib keys <command> [flags]
+
+Commands:
+  create -name N -scopes S   create an API key; S is a comma separated list of
+                             songs:read, songs:write, songs:moderate and admin
+  list                       list API keys
+  revoke ID                  revoke an API key
+
+Every command takes -o table|json|csv.
+`
+
+func runKeys(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, keysUsage)
+		return errors.New("missing keys command")
+	}
+
+	command, rest := args[0], args[1:]
+	switch command {
+	case "create":
+		return keysCreate(rest)
+	case "list":
+		return keysList(rest)
+	case "revoke":
+		return keysRevoke(rest)
+	case "help", "-h", "--help":
+		fmt.Print(keysUsage)
+		return nil
+	default:
+		fmt.Fprint(os.Stderr, keysUsage)
+		return fmt.Errorf("unknown keys command %q", command)
+	}
+}
+
+func keysCreate(args []string) error {
+	c := newCommand("keys create", false)
+	name := c.fs.String("name", "", "what the key is for (required)")
+	scopes := c.fs.String("scopes", models.ScopeSongsRead, "comma separated scopes")
+	if _, err := c.parse(args); err != nil {
+		return err
+	}
+	if *name == "" {
+		return errors.New("-name is required")
+	}
+
+	ctx, a, p, done, err := c.start()
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	var list []string
+	for _, s := range strings.Split(*scopes, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			list = append(list, s)
+		}
+	}
+
+	key, secret, err := a.keys.CreateAPIKey(ctx, *name, list)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(os.Stderr, "store the key now, it cannot be shown again")
+	return printKeys(p, []models.APIKey{key}, secret)
+}
+
+func keysList(args []string) error {
+	c := newCommand("keys list", false)
+	if _, err := c.parse(args); err != nil {
+		return err
+	}
+
+	ctx, a, p, done, err := c.start()
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	keys, err := a.keys.ListAPIKeys(ctx)
+	if err != nil {
+		return err
+	}
+	return printKeys(p, keys, "")
+}
+
+func keysRevoke(args []string) error {
+	c := newCommand("keys revoke", false)
+	positional, err := c.parse(args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return errors.New("expected exactly one key id")
+	}
+	id, err := strconv.ParseInt(positional[0], 10, 64)
+	if err != nil || id < 1 {
+		return fmt.Errorf("invalid key id %q", positional[0])
+	}
+
+	ctx, a, p, done, err := c.start()
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	key, err := a.keys.RevokeAPIKey(ctx, id)
+	if err != nil {
+		if errors.Is(err, storage.ErrAPIKeyNotFound) {
+			return fmt.Errorf("key %d not found", id)
+		}
+		return err
+	}
+	return printKeys(p, []models.APIKey{key}, "")
+}
+
+// keyRow is the shape keys are printed in; Key is only set right after
+// creation.
+type keyRow struct {
+	models.APIKey
+	Key string `json:"key,omitempty"`
+}
+
+var keyColumns = []string{"id", "name", "prefix", "scopes", "created_at", "last_used_at", "revoked_at"}
+
+func keyFields(key models.APIKey) []string {
+	return []string{
+		strconv.FormatInt(key.Id, 10),
+		key.Name,
+		key.Prefix,
+		strings.Join(key.Scopes, ","),
+		key.CreatedAt.Format(time.RFC3339),
+		formatTime(key.LastUsedAt),
+		formatTime(key.RevokedAt),
+	}
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func printKeys(p printer, keys []models.APIKey, secret string) error {
+	switch p.format {
+	case "json":
+		rows := make([]keyRow, 0, len(keys))
+		for _, k := range keys {
+			rows = append(rows, keyRow{APIKey: k, Key: secret})
+		}
+		if secret != "" {
+			return p.json(rows[0])
+		}
+		return p.json(rows)
+	case "csv":
+		w := csv.NewWriter(p.out)
+		columns := keyColumns
+		if secret != "" {
+			columns = append(columns[:len(columns):len(columns)], "key")
+		}
+		if err := w.Write(columns); err != nil {
+			return err
+		}
+		for _, k := range keys {
+			fields := keyFields(k)
+			if secret != "" {
+				fields = append(fields, secret)
+			}
+			if err := w.Write(fields); err != nil {
+				return err
+			}
+		}
+		w.Flush()
+		return w.Error()
+	default:
+		w := tabwriter.NewWriter(p.out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tPREFIX\tSCOPES\tCREATED\tLAST USED\tREVOKED")
+		for _, k := range keys {
+			fmt.Fprintln(w, strings.Join(keyFields(k), "\t"))
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		if secret != "" {
+			fmt.Fprintf(p.out, "\nkey: %s\n", secret)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const lyricsUsage = `Usage: songlib lyrics <command> [flags]
+
+Commands:
+  set ID -file PATH          replace the lyrics of a song, "-" reads stdin;
+                             takes -dry-run and -o table|json|csv
+`
+
+func runLyrics(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, lyricsUsage)
+		return errors.New("missing lyrics command")
+	}
+
+	command, rest := args[0], args[1:]
+	switch command {
+	case "set":
+		return lyricsSet(rest)
+	case "help", "-h", "--help":
+		fmt.Print(lyricsUsage)
+		return nil
+	default:
+		fmt.Fprint(os.Stderr, lyricsUsage)
+		return fmt.Errorf("unknown lyrics command %q", command)
+	}
+}
+
+func lyricsSet(args []string) error {
+	c := newCommand("lyrics set", true)
+	file := c.fs.String("file", "", `file with the lyrics, "-" for stdin (required)`)
+	positional, err := c.parse(args)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(positional)
+	if err != nil {
+		return err
+	}
+	if *file == "" {
+		return errors.New("-file is required")
+	}
+
+	lyrics, err := readLyrics(*file)
+	if err != nil {
+		return err
+	}
+
+	ctx, a, p, done, err := c.start()
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	if c.isDryRun() {
+		current, err := a.service.GetSongByID(ctx, id)
+		if err != nil {
+			return songError(id, err)
+		}
+		dryRunNotice()
+		row := toRow(current)
+		row.Lyrics = lyrics
+		return p.song(row)
+	}
+
+	song, err := a.service.SetLyrics(ctx, id, lyrics)
+	if err != nil {
+		return songError(id, err)
+	}
+
+	row := toRow(song)
+	row.Lyrics = song.Lyrics
+	return p.song(row)
+}
+
+// readLyrics normalises line endings so couplets split on blank lines.
+func readLyrics(path string) (string, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("read lyrics: %w", err)
+	}
+
+	lyrics := strings.ReplaceAll(string(data), "\r\n", "\n")
+	return strings.TrimSpace(lyrics), nil
+}
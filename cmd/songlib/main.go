@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/2pizzzza/TestTask/internal/config"
+	"github.com/2pizzzza/TestTask/internal/enrichment"
+	"github.com/2pizzzza/TestTask/internal/lib/logger/sl"
+	"github.com/2pizzzza/TestTask/internal/service"
+	"github.com/2pizzzza/TestTask/internal/storage/postgres"
+	"github.com/2pizzzza/TestTask/internal/storage/sqlite"
+)
+
+const usage = `Usage: songlib <command> [flags]
+
+Commands:
+  songs     list, show, create, update and delete songs
+  groups    merge groups
+  lyrics    replace the lyrics of a song
+  enrich    fill missing release dates, links and lyrics from the enrichment provider
+  keys      create, list and revoke API keys
+  users     create and list users, change their roles
+  migrate   apply, roll back or inspect database migrations
+
+Run songlib <command> -h for the flags of a command.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "songs":
+		err = runSongs(os.Args[2:])
+	case "groups":
+		err = runGroups(os.Args[2:])
+	case "lyrics":
+		err = runLyrics(os.Args[2:])
+	case "enrich":
+		err = runEnrich(os.Args[2:])
+	case "keys":
+		err = runKeys(os.Args[2:])
+	case "users":
+		err = runUsers(os.Args[2:])
+	case "migrate":
+		err = runMigrate(os.Args[2:])
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+// catalog is the storage the commands work on.
+type catalog interface {
+	service.SongRepository
+	service.LinkRepository
+	service.GroupRepository
+	service.TxManager
+	service.APIKeyRepository
+	service.UserRepository
+	Close() error
+}
+
+type app struct {
+	cfg     *config.Config
+	log     *slog.Logger
+	db      catalog
+	service *service.SongRep
+	keys    *service.APIKeyRep
+	users   *service.UserRep
+}
+
+func newApp(ctx context.Context) (*app, error) {
+	cfg, err := config.NewConfig()
+	if err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+	var (
+		db         catalog
+		cacheStore enrichment.Store
+	)
+	switch cfg.Storage {
+	case "postgres":
+		pg, err := postgres.New(sl.WithLogger(ctx, log), cfg)
+		if err != nil {
+			return nil, fmt.Errorf("connect db: %w", err)
+		}
+		if cfg.Enrichment.PersistentCache {
+			cacheStore = pg
+		}
+		db = pg
+	case "sqlite":
+		lite, err := sqlite.New(sl.WithLogger(ctx, log), cfg.SQLite)
+		if err != nil {
+			return nil, fmt.Errorf("open db: %w", err)
+		}
+		db = lite
+	default:
+		return nil, fmt.Errorf("songlib needs STORAGE=postgres or sqlite, got %q", cfg.Storage)
+	}
+	enricher := enrichment.NewCachedProvider(
+		log,
+		enrichment.NewClient(cfg.Enrichment.ApiUrl),
+		cacheStore,
+		cfg.Enrichment.CacheSize,
+		cfg.Enrichment.CacheTTL,
+		cfg.Enrichment.NegativeTTL,
+	)
+
+	return &app{
+		cfg:     cfg,
+		log:     log,
+		db:      db,
+		service: service.New(*log, db, db, db, db, enricher, cfg.DBConn.RequestTimeout),
+		keys:    service.NewAPIKeys(log, db),
+		users:   service.NewUsers(log, db, db, cfg.Auth),
+	}, nil
+}
+
+func (a *app) Close() {
+	if err := a.db.Close(); err != nil {
+		a.log.Error("failed to close db", slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+
+	"github.com/2pizzzza/TestTask/internal/config"
+	"github.com/2pizzzza/TestTask/internal/lib/logger/sl"
+	"github.com/2pizzzza/TestTask/internal/storage/migration"
+	"github.com/2pizzzza/TestTask/internal/storage/postgres"
+	"github.com/2pizzzza/TestTask/internal/storage/sqlite"
+)
+
+const migrateUsage = `Usage: songlib migrate <command>
+
+Commands:
+  up [N]          apply all pending migrations, or only the next N
+  down [N]        roll back the last N migrations (default 1)
+  down -all       roll back every migration
+  goto V          migrate up or down to version V
+  version         print the applied version
+  force V         mark version V as applied and clear the dirty flag, -1 for none
+  status          list applied and pending migrations
+`
+
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return errors.New("missing migrate command")
+	}
+
+	cfg, err := config.NewConfig()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	migrator, err := newMigrator(ctx, cfg, log)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	command, rest := args[0], args[1:]
+	switch command {
+	case "up":
+		n, err := optionalCount(rest)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			err = migrator.Up(ctx)
+		} else {
+			err = migrator.Steps(ctx, n)
+		}
+		if err != nil {
+			return err
+		}
+	case "down":
+		fs := flag.NewFlagSet("migrate down", flag.ExitOnError)
+		all := fs.Bool("all", false, "roll back every migration")
+		if err := fs.Parse(rest); err != nil {
+			return err
+		}
+		if *all {
+			if fs.NArg() > 0 {
+				return errors.New("down -all takes no count")
+			}
+			if err := migrator.Down(ctx); err != nil {
+				return err
+			}
+			break
+		}
+		n, err := optionalCount(fs.Args())
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			n = 1
+		}
+		if err := migrator.Steps(ctx, -n); err != nil {
+			return err
+		}
+	case "goto":
+		if len(rest) != 1 {
+			return errors.New("goto needs exactly one version")
+		}
+		version, err := strconv.ParseUint(rest[0], 10, 0)
+		if err != nil {
+			return fmt.Errorf("invalid version %q", rest[0])
+		}
+		if err := migrator.Goto(ctx, uint(version)); err != nil {
+			return err
+		}
+	case "force":
+		if len(rest) != 1 {
+			return errors.New("force needs exactly one version")
+		}
+		version, err := strconv.Atoi(rest[0])
+		if err != nil || version < -1 {
+			return fmt.Errorf("invalid version %q", rest[0])
+		}
+		if err := migrator.Force(version); err != nil {
+			return err
+		}
+	case "version":
+	case "status":
+		status, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		printMigrationStatus(status)
+		return nil
+	case "help", "-h", "--help":
+		fmt.Print(migrateUsage)
+		return nil
+	default:
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return fmt.Errorf("unknown migrate command %q", command)
+	}
+
+	return printVersion(migrator)
+}
+
+// newMigrator opens the configured database for migrating.
+func newMigrator(ctx context.Context, cfg *config.Config, log *slog.Logger) (*migration.Migrator, error) {
+	switch cfg.Storage {
+	case "postgres":
+		return postgres.NewMigrator(cfg.DBConn, log)
+	case "sqlite":
+		if cfg.SQLite.Path == ":memory:" {
+			return nil, errors.New("an in-memory sqlite database is migrated by the server that holds it")
+		}
+		return sqlite.NewMigrator(sl.WithLogger(ctx, log), cfg.SQLite, log)
+	}
+
+	return nil, fmt.Errorf("migrate needs STORAGE=postgres or sqlite, got %q", cfg.Storage)
+}
+
+// optionalCount parses an optional positive count; zero means none was given.
+func optionalCount(args []string) (int, error) {
+	switch len(args) {
+	case 0:
+		return 0, nil
+	case 1:
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 {
+			return 0, fmt.Errorf("invalid count %q", args[0])
+		}
+		return n, nil
+	default:
+		return 0, errors.New("too many arguments")
+	}
+}
+
+func printVersion(migrator *migration.Migrator) error {
+	version, dirty, err := migrator.Version()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case version == 0:
+		fmt.Println("version: none")
+	case dirty:
+		fmt.Printf("version: %d (dirty)\n", version)
+	default:
+		fmt.Printf("version: %d\n", version)
+	}
+
+	return nil
+}
+
+func printMigrationStatus(status migration.Status) {
+	if status.Dirty {
+		fmt.Printf("version: %d (dirty, fix the schema and run `songlib migrate force`)\n", status.Version)
+	} else {
+		fmt.Printf("version: %d\n", status.Version)
+	}
+	fmt.Printf("latest:  %d\n", status.Latest)
+	if status.Version > status.Latest {
+		fmt.Println("the database is newer than this binary")
+	}
+	for _, m := range status.Applied {
+		fmt.Printf("  [x] %d %s\n", m.Version, m.Name)
+	}
+	for _, m := range status.Pending {
+		fmt.Printf("  [ ] %d %s\n", m.Version, m.Name)
+	}
+}
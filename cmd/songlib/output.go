@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+)
+
+// songRow is the shape songs are printed in, whatever the format.
+type songRow struct {
+	Id           int64  `json:"id"`
+	Group        string `json:"group"`
+	Song         string `json:"song"`
+	ReleaseDate  string `json:"release_date"`
+	Link         string `json:"link"`
+	LyricsSource string `json:"lyrics_source"`
+	Lyrics       string `json:"lyrics,omitempty"`
+}
+
+var songColumns = []string{"id", "group", "song", "release_date", "link", "lyrics_source"}
+
+func toRow(song models.Song) songRow {
+	return songRow{
+		Id:           song.Id,
+		Group:        song.GroupName.GroupName,
+		Song:         song.SongName,
+		ReleaseDate:  song.ReleaseDate,
+		Link:         song.Link,
+		LyricsSource: song.LyricsSource,
+	}
+}
+
+func (r songRow) fields() []string {
+	return []string{strconv.FormatInt(r.Id, 10), r.Group, r.Song, r.ReleaseDate, r.Link, r.LyricsSource}
+}
+
+type printer struct {
+	format string
+	out    io.Writer
+}
+
+func newPrinter(format string) (printer, error) {
+	switch format {
+	case "table", "json", "csv":
+		return printer{format: format, out: os.Stdout}, nil
+	default:
+		return printer{}, fmt.Errorf("unknown output format %q, use table, json or csv", format)
+	}
+}
+
+func (p printer) songs(rows []songRow) error {
+	switch p.format {
+	case "json":
+		if rows == nil {
+			rows = []songRow{}
+		}
+		return p.json(rows)
+	case "csv":
+		w := csv.NewWriter(p.out)
+		if err := w.Write(songColumns); err != nil {
+			return err
+		}
+		for _, r := range rows {
+			if err := w.Write(r.fields()); err != nil {
+				return err
+			}
+		}
+		w.Flush()
+		return w.Error()
+	default:
+		w := tabwriter.NewWriter(p.out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tGROUP\tSONG\tRELEASE DATE\tLINK\tLYRICS SOURCE")
+		for _, r := range rows {
+			f := r.fields()
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", f[0], f[1], f[2], f[3], f[4], f[5])
+		}
+		return w.Flush()
+	}
+}
+
+// song prints a single song; the table format includes the lyrics.
+func (p printer) song(row songRow) error {
+	switch p.format {
+	case "json":
+		return p.json(row)
+	case "csv":
+		return p.songs([]songRow{row})
+	default:
+		w := tabwriter.NewWriter(p.out, 0, 0, 2, ' ', 0)
+		f := row.fields()
+		for i, column := range songColumns {
+			fmt.Fprintf(w, "%s:\t%s\n", column, f[i])
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		if row.Lyrics != "" {
+			fmt.Fprintf(p.out, "\n%s\n", row.Lyrics)
+		}
+		return nil
+	}
+}
+
+func (p printer) json(v any) error {
+	enc := json.NewEncoder(p.out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/storage"
+)
+
+const songsUsage = `Usage: songlib songs <command> [flags]
+
+Commands:
+  list                       list songs, filtered by -group, -song and -release-date
+  get ID                     show a song with its lyrics
+  create -group G -song S    add a song and enrich it
+  update ID [-group G] [-song S]
+                             rename a song or move it to another group
+  delete ID                  delete a song
+
+Every command takes -o table|json|csv; create, update and delete take -dry-run.
+`
+
+func runSongs(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, songsUsage)
+		return errors.New("missing songs command")
+	}
+
+	command, rest := args[0], args[1:]
+	switch command {
+	case "list":
+		return songsList(rest)
+	case "get":
+		return songsGet(rest)
+	case "create":
+		return songsCreate(rest)
+	case "update":
+		return songsUpdate(rest)
+	case "delete":
+		return songsDelete(rest)
+	case "help", "-h", "--help":
+		fmt.Print(songsUsage)
+		return nil
+	default:
+		fmt.Fprint(os.Stderr, songsUsage)
+		return fmt.Errorf("unknown songs command %q", command)
+	}
+}
+
+// command bundles the flags shared by the catalog commands.
+type command struct {
+	fs     *flag.FlagSet
+	output *string
+	dryRun *bool
+}
+
+func newCommand(name string, withDryRun bool) *command {
+	c := &command{fs: flag.NewFlagSet(name, flag.ExitOnError)}
+	c.output = c.fs.String("o", "table", "output format: table, json or csv")
+	if withDryRun {
+		c.dryRun = c.fs.Bool("dry-run", false, "show what would change without writing")
+	}
+	return c
+}
+
+// parse accepts flags before and after positional arguments and returns
+// the positional ones.
+func (c *command) parse(args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := c.fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = c.fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func (c *command) isDryRun() bool {
+	return c.dryRun != nil && *c.dryRun
+}
+
+// start connects to the database once the arguments are known to be valid.
+func (c *command) start() (context.Context, *app, printer, func(), error) {
+	p, err := newPrinter(*c.output)
+	if err != nil {
+		return nil, nil, printer{}, nil, err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	a, err := newApp(ctx)
+	if err != nil {
+		stop()
+		return nil, nil, printer{}, nil, err
+	}
+
+	return ctx, a, p, func() {
+		a.Close()
+		stop()
+	}, nil
+}
+
+func dryRunNotice() {
+	fmt.Fprintln(os.Stderr, "dry run: nothing was written")
+}
+
+func parseID(args []string) (int64, error) {
+	if len(args) != 1 {
+		return 0, errors.New("expected exactly one song id")
+	}
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("invalid song id %q", args[0])
+	}
+	return id, nil
+}
+
+func songError(id int64, err error) error {
+	if errors.Is(err, storage.ErrSongNotFound) {
+		return fmt.Errorf("song %d not found", id)
+	}
+	return err
+}
+
+func songsList(args []string) error {
+	c := newCommand("songs list", false)
+	group := c.fs.String("group", "", "group name contains")
+	song := c.fs.String("song", "", "song title contains")
+	releaseDate := c.fs.String("release-date", "", "exact release date")
+	limit := c.fs.Int("limit", 50, "maximum number of songs")
+	offset := c.fs.Int("offset", 0, "songs to skip")
+	if _, err := c.parse(args); err != nil {
+		return err
+	}
+	if *limit < 1 || *offset < 0 {
+		return errors.New("limit must be positive and offset not negative")
+	}
+
+	ctx, a, p, done, err := c.start()
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	songs, err := a.service.GetAllSong(ctx, models.SongFilter{
+		GroupName:   *group,
+		SongName:    *song,
+		ReleaseDate: *releaseDate,
+	}, *limit, *offset)
+	if err != nil {
+		return err
+	}
+
+	rows := make([]songRow, 0, len(songs))
+	for _, s := range songs {
+		rows = append(rows, toRow(*s))
+	}
+	return p.songs(rows)
+}
+
+func songsGet(args []string) error {
+	c := newCommand("songs get", false)
+	positional, err := c.parse(args)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(positional)
+	if err != nil {
+		return err
+	}
+
+	ctx, a, p, done, err := c.start()
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	song, err := a.service.GetSongByID(ctx, id)
+	if err != nil {
+		return songError(id, err)
+	}
+
+	row := toRow(song)
+	row.Lyrics = song.Lyrics
+	return p.song(row)
+}
+
+func songsCreate(args []string) error {
+	c := newCommand("songs create", true)
+	group := c.fs.String("group", "", "group name (required)")
+	song := c.fs.String("song", "", "song title (required)")
+	if _, err := c.parse(args); err != nil {
+		return err
+	}
+	if *group == "" || *song == "" {
+		return errors.New("-group and -song are required")
+	}
+
+	ctx, a, p, done, err := c.start()
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	existing, err := findSong(ctx, a, *group, *song)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return fmt.Errorf("song already exists with id %d", existing.Id)
+	}
+
+	if c.isDryRun() {
+		dryRunNotice()
+		return p.song(songRow{Group: *group, Song: *song})
+	}
+
+	if _, err := a.service.CreateSong(ctx, models.SongCreateReq{GroupName: *group, SongName: *song}); err != nil {
+		return err
+	}
+
+	created, err := findSong(ctx, a, *group, *song)
+	if err != nil {
+		return err
+	}
+	if created == nil {
+		return errors.New("song was created but could not be read back")
+	}
+	return p.song(toRow(*created))
+}
+
+// findSong looks a song up by its exact group and title.
+func findSong(ctx context.Context, a *app, group, title string) (*models.Song, error) {
+	const pageSize = 100
+
+	filter := models.SongFilter{GroupName: group, SongName: title}
+	for offset := 0; ; offset += pageSize {
+		songs, err := a.service.GetAllSong(ctx, filter, pageSize, offset)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range songs {
+			if s.GroupName.GroupName == group && s.SongName == title {
+				return s, nil
+			}
+		}
+		if len(songs) < pageSize {
+			return nil, nil
+		}
+	}
+}
+
+func songsUpdate(args []string) error {
+	c := newCommand("songs update", true)
+	group := c.fs.String("group", "", "new group name, unchanged if empty")
+	song := c.fs.String("song", "", "new song title, unchanged if empty")
+	positional, err := c.parse(args)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(positional)
+	if err != nil {
+		return err
+	}
+	if *group == "" && *song == "" {
+		return errors.New("nothing to update, pass -group and/or -song")
+	}
+
+	ctx, a, p, done, err := c.start()
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	if c.isDryRun() {
+		current, err := a.service.GetSongByID(ctx, id)
+		if err != nil {
+			return songError(id, err)
+		}
+
+		dryRunNotice()
+		row := toRow(current)
+		if *group != "" {
+			row.Group = *group
+		}
+		if *song != "" {
+			row.Song = *song
+		}
+		return p.song(row)
+	}
+
+	// Empty fields keep their current value.
+	updated, err := a.service.UpdateSong(ctx, models.SongUpdateReq{
+		Id:           id,
+		NewGroupName: *group,
+		NewSongName:  *song,
+	})
+	if err != nil {
+		return songError(id, err)
+	}
+	return p.song(toRow(updated))
+}
+
+func songsDelete(args []string) error {
+	c := newCommand("songs delete", true)
+	positional, err := c.parse(args)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(positional)
+	if err != nil {
+		return err
+	}
+
+	ctx, a, p, done, err := c.start()
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	song, err := a.service.GetSongByID(ctx, id)
+	if err != nil {
+		return songError(id, err)
+	}
+
+	if c.isDryRun() {
+		dryRunNotice()
+		return p.song(toRow(song))
+	}
+
+	if _, err := a.service.DeleteSong(ctx, id); err != nil {
+		return songError(id, err)
+	}
+	return p.song(toRow(song))
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/storage"
+)
+
+const usersUsage = `Usage: songlib users <command> [flags]
+
+Commands:
+  create -username U -role R   create a user, the password is read from the
+                               first line of stdin; R is viewer, editor,
+                               moderator or admin
+  list                         list users
+  role ID ROLE                 change the role of a user
+
+Every command takes -o table|json|csv.
+`
+
+func runUsers(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usersUsage)
+		return errors.New("missing users command")
+	}
+
+	command, rest := args[0], args[1:]
+	switch command {
+	case "create":
+		return usersCreate(rest)
+	case "list":
+		return usersList(rest)
+	case "role":
+		return usersRole(rest)
+	case "help", "-h", "--help":
+		fmt.Print(usersUsage)
+		return nil
+	default:
+		fmt.Fprint(os.Stderr, usersUsage)
+		return fmt.Errorf("unknown users command %q", command)
+	}
+}
+
+func usersCreate(args []string) error {
+	c := newCommand("users create", false)
+	username := c.fs.String("username", "", "login name (required)")
+	role := c.fs.String("role", models.RoleViewer, "viewer, editor, moderator or admin")
+	if _, err := c.parse(args); err != nil {
+		return err
+	}
+	if *username == "" {
+		return errors.New("-username is required")
+	}
+
+	password, err := readPassword()
+	if err != nil {
+		return err
+	}
+
+	ctx, a, p, done, err := c.start()
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	user, err := a.users.CreateUser(ctx, *username, password, *role)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserExists) {
+			return fmt.Errorf("user %q already exists", *username)
+		}
+		return err
+	}
+	return printUsers(p, []models.User{user})
+}
+
+// readPassword takes the first line of stdin, so a password never shows up
+// in the process list or the shell history.
+func readPassword() (string, error) {
+	if fi, err := os.Stdin.Stat(); err == nil && fi.Mode()&os.ModeCharDevice != 0 {
+		fmt.Fprint(os.Stderr, "password: ")
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("read password: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func usersList(args []string) error {
+	c := newCommand("users list", false)
+	if _, err := c.parse(args); err != nil {
+		return err
+	}
+
+	ctx, a, p, done, err := c.start()
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	users, err := a.users.ListUsers(ctx)
+	if err != nil {
+		return err
+	}
+	return printUsers(p, users)
+}
+
+func usersRole(args []string) error {
+	c := newCommand("users role", false)
+	positional, err := c.parse(args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 {
+		return errors.New("expected a user id and a role")
+	}
+	id, err := strconv.ParseInt(positional[0], 10, 64)
+	if err != nil || id < 1 {
+		return fmt.Errorf("invalid user id %q", positional[0])
+	}
+
+	ctx, a, p, done, err := c.start()
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	user, err := a.users.SetUserRole(ctx, id, positional[1])
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return fmt.Errorf("user %d not found", id)
+		}
+		return err
+	}
+	return printUsers(p, []models.User{user})
+}
+
+var userColumns = []string{"id", "username", "role", "created_at"}
+
+func userFields(user models.User) []string {
+	return []string{
+		strconv.FormatInt(user.Id, 10),
+		user.Username,
+		user.Role,
+		user.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func printUsers(p printer, users []models.User) error {
+	switch p.format {
+	case "json":
+		return p.json(users)
+	case "csv":
+		w := csv.NewWriter(p.out)
+		if err := w.Write(userColumns); err != nil {
+			return err
+		}
+		for _, u := range users {
+			if err := w.Write(userFields(u)); err != nil {
+				return err
+			}
+		}
+		w.Flush()
+		return w.Error()
+	default:
+		w := tabwriter.NewWriter(p.out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tUSERNAME\tROLE\tCREATED")
+		for _, u := range users {
+			fmt.Fprintln(w, strings.Join(userFields(u), "\t"))
+		}
+		return w.Flush()
+	}
+}
@@ -0,0 +1,14 @@
+// Package db ships the SQL migrations inside the binaries.
+package db
+
+import "embed"
+
+// Migrations holds the golang-migrate files for postgres under migrations/.
+//
+//go:embed migrations/*.sql
+var Migrations embed.FS
+
+// SQLiteMigrations holds the golang-migrate files for SQLite under sqlite/.
+//
+//go:embed sqlite/*.sql
+var SQLiteMigrations embed.FS
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS enrichment_cache
+(
+    cache_key  VARCHAR(512) PRIMARY KEY,
+    payload    JSONB,
+    not_found  BOOLEAN     NOT NULL DEFAULT FALSE,
+    expires_at TIMESTAMPTZ NOT NULL
+);
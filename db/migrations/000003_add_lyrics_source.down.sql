@@ -0,0 +1,2 @@
+ALTER TABLE songs
+    DROP COLUMN IF EXISTS lyrics_source;
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE songs
+    ADD COLUMN IF NOT EXISTS lyrics_source VARCHAR(255) DEFAULT '';
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS song_links
+(
+    id         SERIAL PRIMARY KEY,
+    song_id    INT          NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    platform   VARCHAR(32)  NOT NULL CHECK (platform IN ('spotify', 'youtube', 'apple_music', 'bandcamp', 'other')),
+    url        VARCHAR(2048) NOT NULL,
+    source     VARCHAR(255) NOT NULL DEFAULT 'manual',
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS song_links_song_id_idx ON song_links (song_id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS song_links_provider_platform_idx
+    ON song_links (song_id, platform) WHERE source <> 'manual';
+
+INSERT INTO song_links (song_id, platform, url, source)
+SELECT id, 'spotify', link, 'enrichment'
+FROM songs
+WHERE COALESCE(link, '') <> '';
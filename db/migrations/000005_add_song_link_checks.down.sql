@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS song_links_last_checked_at_idx;
+
+ALTER TABLE song_links
+    DROP COLUMN IF EXISTS status_code,
+    DROP COLUMN IF EXISTS check_error,
+    DROP COLUMN IF EXISTS redirect_url,
+    DROP COLUMN IF EXISTS broken,
+    DROP COLUMN IF EXISTS last_checked_at;
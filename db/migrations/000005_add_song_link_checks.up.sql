@@ -0,0 +1,8 @@
+ALTER TABLE song_links
+    ADD COLUMN IF NOT EXISTS status_code     INT,
+    ADD COLUMN IF NOT EXISTS check_error     TEXT         NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS redirect_url    VARCHAR(2048) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS broken          BOOLEAN      NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS last_checked_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS song_links_last_checked_at_idx ON song_links (last_checked_at NULLS FIRST);
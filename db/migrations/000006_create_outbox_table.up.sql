@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS outbox
+(
+    id           BIGSERIAL PRIMARY KEY,
+    event_type   VARCHAR(64) NOT NULL,
+    song_id      INT         NOT NULL,
+    payload      JSONB       NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    published_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS outbox_unpublished_idx ON outbox (id) WHERE published_at IS NULL;
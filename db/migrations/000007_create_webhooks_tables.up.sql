@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS webhooks
+(
+    id            SERIAL PRIMARY KEY,
+    url           VARCHAR(2048) NOT NULL,
+    events        TEXT[]        NOT NULL DEFAULT '{}',
+    secret        VARCHAR(255)  NOT NULL,
+    active        BOOLEAN       NOT NULL DEFAULT TRUE,
+    failure_count INT           NOT NULL DEFAULT 0,
+    disabled_at   TIMESTAMPTZ,
+    created_at    TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries
+(
+    id            BIGSERIAL PRIMARY KEY,
+    webhook_id    INT         NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    event_id      BIGINT      NOT NULL,
+    event_type    VARCHAR(64) NOT NULL,
+    attempt       INT         NOT NULL,
+    status_code   INT,
+    response_body TEXT        NOT NULL DEFAULT '',
+    error         TEXT        NOT NULL DEFAULT '',
+    duration_ms   INT         NOT NULL DEFAULT 0,
+    success       BOOLEAN     NOT NULL DEFAULT FALSE,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook_id_idx ON webhook_deliveries (webhook_id, id DESC);
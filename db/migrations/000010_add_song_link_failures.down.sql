@@ -0,0 +1,2 @@
+ALTER TABLE song_links
+    DROP COLUMN IF EXISTS failures;
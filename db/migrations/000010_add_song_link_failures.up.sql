@@ -0,0 +1,2 @@
+ALTER TABLE song_links
+    ADD COLUMN IF NOT EXISTS failures INT NOT NULL DEFAULT 0;
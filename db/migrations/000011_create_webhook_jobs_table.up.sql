@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS webhook_jobs
+(
+    id              BIGSERIAL PRIMARY KEY,
+    webhook_id      INT         NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    event_id        BIGINT      NOT NULL,
+    event_type      VARCHAR(64) NOT NULL,
+    body            BYTEA       NOT NULL,
+    attempts        INT         NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS webhook_jobs_next_attempt_at_idx ON webhook_jobs (next_attempt_at, id);
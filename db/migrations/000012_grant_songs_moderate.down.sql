@@ -0,0 +1,3 @@
+UPDATE api_keys
+SET scopes = array_remove(scopes, 'songs:moderate')
+WHERE 'songs:write' = ANY (scopes);
@@ -0,0 +1,6 @@
+-- Deleting songs moved from songs:write to songs:moderate; keys that could
+-- delete songs before keep that right.
+UPDATE api_keys
+SET scopes = array_append(scopes, 'songs:moderate')
+WHERE 'songs:write' = ANY (scopes)
+  AND NOT 'songs:moderate' = ANY (scopes);
@@ -0,0 +1,11 @@
+-- Lyrics as they were before each change, so a moderator can roll back.
+CREATE TABLE IF NOT EXISTS lyrics_revisions
+(
+    id            BIGSERIAL PRIMARY KEY,
+    song_id       INT          NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    lyrics        TEXT         NOT NULL,
+    lyrics_source VARCHAR(255) NOT NULL DEFAULT '',
+    created_at    TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS lyrics_revisions_song_id_idx ON lyrics_revisions (song_id, id);
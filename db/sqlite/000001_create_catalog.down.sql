@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhooks;
+DROP TABLE IF EXISTS outbox;
+DROP TABLE IF EXISTS song_links;
+DROP TABLE IF EXISTS songs;
+DROP TABLE IF EXISTS groups;
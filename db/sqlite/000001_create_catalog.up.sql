@@ -0,0 +1,81 @@
+CREATE TABLE IF NOT EXISTS groups
+(
+    id         INTEGER PRIMARY KEY,
+    group_name TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS songs
+(
+    id            INTEGER PRIMARY KEY,
+    group_id      INTEGER NOT NULL REFERENCES groups (id),
+    song_title    TEXT    NOT NULL,
+    release_date  TEXT    NOT NULL DEFAULT '',
+    lyrics        TEXT    NOT NULL DEFAULT '',
+    lyrics_source TEXT    NOT NULL DEFAULT '',
+    link          TEXT    NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS songs_group_id_idx ON songs (group_id);
+
+CREATE TABLE IF NOT EXISTS song_links
+(
+    id              INTEGER PRIMARY KEY,
+    song_id         INTEGER   NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    platform        TEXT      NOT NULL CHECK (platform IN ('spotify', 'youtube', 'apple_music', 'bandcamp', 'other')),
+    url             TEXT      NOT NULL,
+    source          TEXT      NOT NULL DEFAULT 'manual',
+    created_at      TIMESTAMP NOT NULL,
+    status_code     INTEGER,
+    check_error     TEXT      NOT NULL DEFAULT '',
+    redirect_url    TEXT      NOT NULL DEFAULT '',
+    broken          BOOLEAN   NOT NULL DEFAULT FALSE,
+    last_checked_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS song_links_song_id_idx ON song_links (song_id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS song_links_provider_platform_idx
+    ON song_links (song_id, platform) WHERE source <> 'manual';
+
+CREATE INDEX IF NOT EXISTS song_links_last_checked_at_idx ON song_links (last_checked_at);
+
+CREATE TABLE IF NOT EXISTS outbox
+(
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_type   TEXT      NOT NULL,
+    song_id      INTEGER   NOT NULL,
+    payload      TEXT      NOT NULL,
+    created_at   TIMESTAMP NOT NULL,
+    published_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS outbox_unpublished_idx ON outbox (id) WHERE published_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS webhooks
+(
+    id            INTEGER PRIMARY KEY,
+    url           TEXT      NOT NULL,
+    events        TEXT      NOT NULL DEFAULT '[]',
+    secret        TEXT      NOT NULL,
+    active        BOOLEAN   NOT NULL DEFAULT TRUE,
+    failure_count INTEGER   NOT NULL DEFAULT 0,
+    disabled_at   TIMESTAMP,
+    created_at    TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries
+(
+    id            INTEGER PRIMARY KEY,
+    webhook_id    INTEGER   NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    event_id      INTEGER   NOT NULL,
+    event_type    TEXT      NOT NULL,
+    attempt       INTEGER   NOT NULL,
+    status_code   INTEGER,
+    response_body TEXT      NOT NULL DEFAULT '',
+    error         TEXT      NOT NULL DEFAULT '',
+    duration_ms   INTEGER   NOT NULL DEFAULT 0,
+    success       BOOLEAN   NOT NULL DEFAULT FALSE,
+    created_at    TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook_id_idx ON webhook_deliveries (webhook_id, id DESC);
@@ -0,0 +1,45 @@
+-- Trigram index over group names and titles; it narrows substring searches
+-- down before the exact ILIKE check runs.
+CREATE VIRTUAL TABLE IF NOT EXISTS songs_fts USING fts5
+(
+    group_name,
+    song_title,
+    tokenize = 'trigram'
+);
+
+INSERT INTO songs_fts (rowid, group_name, song_title)
+SELECT s.id, g.group_name, s.song_title
+FROM songs s
+         JOIN groups g ON g.id = s.group_id;
+
+CREATE TRIGGER IF NOT EXISTS songs_fts_insert
+    AFTER INSERT
+    ON songs
+BEGIN
+    INSERT INTO songs_fts (rowid, group_name, song_title)
+    SELECT NEW.id, group_name, NEW.song_title FROM groups WHERE id = NEW.group_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS songs_fts_update
+    AFTER UPDATE OF group_id, song_title
+    ON songs
+BEGIN
+    DELETE FROM songs_fts WHERE rowid = OLD.id;
+    INSERT INTO songs_fts (rowid, group_name, song_title)
+    SELECT NEW.id, group_name, NEW.song_title FROM groups WHERE id = NEW.group_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS songs_fts_delete
+    AFTER DELETE
+    ON songs
+BEGIN
+    DELETE FROM songs_fts WHERE rowid = OLD.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS groups_fts_rename
+    AFTER UPDATE OF group_name
+    ON groups
+BEGIN
+    UPDATE songs_fts SET group_name = NEW.group_name
+    WHERE rowid IN (SELECT id FROM songs WHERE group_id = NEW.id);
+END;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS api_keys
+(
+    id           INTEGER PRIMARY KEY,
+    name         TEXT      NOT NULL,
+    prefix       TEXT      NOT NULL,
+    key_hash     TEXT      NOT NULL UNIQUE,
+    scopes       TEXT      NOT NULL DEFAULT '[]',
+    created_at   TIMESTAMP NOT NULL,
+    last_used_at TIMESTAMP,
+    revoked_at   TIMESTAMP
+);
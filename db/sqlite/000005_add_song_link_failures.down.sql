@@ -0,0 +1 @@
+ALTER TABLE song_links DROP COLUMN failures;
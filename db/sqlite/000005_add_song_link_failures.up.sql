@@ -0,0 +1 @@
+ALTER TABLE song_links ADD COLUMN failures INTEGER NOT NULL DEFAULT 0;
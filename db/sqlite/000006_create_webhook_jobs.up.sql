@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS webhook_jobs
+(
+    id              INTEGER PRIMARY KEY,
+    webhook_id      INTEGER   NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    event_id        INTEGER   NOT NULL,
+    event_type      TEXT      NOT NULL,
+    body            BLOB      NOT NULL,
+    attempts        INTEGER   NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL,
+    created_at      TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS webhook_jobs_next_attempt_at_idx ON webhook_jobs (next_attempt_at, id);
@@ -0,0 +1,3 @@
+UPDATE api_keys
+SET scopes = (SELECT json_group_array(value) FROM json_each(api_keys.scopes) WHERE value <> 'songs:moderate')
+WHERE EXISTS (SELECT 1 FROM json_each(api_keys.scopes) WHERE value = 'songs:write');
@@ -0,0 +1,6 @@
+-- Deleting songs moved from songs:write to songs:moderate; keys that could
+-- delete songs before keep that right.
+UPDATE api_keys
+SET scopes = json_insert(scopes, '$[#]', 'songs:moderate')
+WHERE EXISTS (SELECT 1 FROM json_each(api_keys.scopes) WHERE value = 'songs:write')
+  AND NOT EXISTS (SELECT 1 FROM json_each(api_keys.scopes) WHERE value = 'songs:moderate');
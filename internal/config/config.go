@@ -1,62 +1,279 @@
 package config
 
 import (
-	"fmt"
-	"os"
+	"net"
+	"net/url"
 	"strconv"
-
-	"github.com/joho/godotenv"
+	"time"
 )
 
+// Config is assembled from, in increasing priority: defaults, an optional
+// YAML or TOML file, environment variables (and the .env file) and command
+// line flags. Every setting has an env name; the flag name is the same in
+// lower case with dashes, e.g. DB_HOST and -db-host. Any variable can also
+// be read from a file by setting NAME_FILE, which is meant for secrets.
+//
+// Storage selects the backend, "postgres", "sqlite" or "memory"; the sqlite
+// backend keeps everything in one local file, the memory backend needs no
+// database and loses everything on restart.
 type Config struct {
-	Env      string
-	DBConn   DatabaseConfig
-	HttpConn HttpConfig
+	Env        string           `env:"ENV" yaml:"env" toml:"env"`
+	Storage    string           `env:"STORAGE" yaml:"storage" toml:"storage"`
+	DBConn     DatabaseConfig   `yaml:"database" toml:"database"`
+	SQLite     SQLiteConfig     `yaml:"sqlite" toml:"sqlite"`
+	HttpConn   HttpConfig       `yaml:"http" toml:"http"`
+	Enrichment EnrichmentConfig `yaml:"enrichment" toml:"enrichment"`
+	LinkCheck  LinkCheckConfig  `yaml:"linkcheck" toml:"linkcheck"`
+	Events     EventsConfig     `yaml:"events" toml:"events"`
+	Webhooks   WebhookConfig    `yaml:"webhooks" toml:"webhooks"`
+	Auth       AuthConfig       `yaml:"auth" toml:"auth"`
+	Tracing    TracingConfig    `yaml:"tracing" toml:"tracing"`
 }
 
 type DatabaseConfig struct {
-	Host     string
-	Port     int
-	Database string
-	Username string
-	Password string
-	DbUrl    string
+	Host     string `env:"DB_HOST" yaml:"host" toml:"host"`
+	Port     int    `env:"DB_PORT" yaml:"port" toml:"port"`
+	Database string `env:"DB_NAME" yaml:"name" toml:"name"`
+	Username string `env:"DB_USER" yaml:"user" toml:"user"`
+	Password string `env:"DB_PASSWORD" yaml:"password" toml:"password"`
+	// DbUrl is a full postgres:// connection URL. When set it replaces the
+	// host, port, name, user and password settings.
+	DbUrl           string `env:"DB_URL" yaml:"url" toml:"url"`
+	SSLMode         string `env:"DB_SSLMODE" yaml:"sslmode" toml:"sslmode"`
+	SSLRootCert     string `env:"DB_SSLROOTCERT" yaml:"sslrootcert" toml:"sslrootcert"`
+	ApplicationName string `env:"DB_APPLICATION_NAME" yaml:"application_name" toml:"application_name"`
+
+	MaxOpenConns    int           `env:"DB_MAX_OPEN_CONNS" yaml:"max_open_conns" toml:"max_open_conns"`
+	MaxIdleConns    int           `env:"DB_MAX_IDLE_CONNS" yaml:"max_idle_conns" toml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `env:"DB_CONN_MAX_LIFETIME" yaml:"conn_max_lifetime" toml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `env:"DB_CONN_MAX_IDLE_TIME" yaml:"conn_max_idle_time" toml:"conn_max_idle_time"`
+
+	// StatementTimeout is set as the server-side statement_timeout of every
+	// connection; zero leaves the server default.
+	StatementTimeout time.Duration `env:"DB_STATEMENT_TIMEOUT" yaml:"statement_timeout" toml:"statement_timeout"`
+
+	// On startup the database is pinged until it answers, waiting
+	// RetryBackoffBase, doubled up to RetryBackoffMax, between attempts and
+	// giving up after ConnectWait.
+	ConnectTimeout   time.Duration `env:"DB_CONNECT_TIMEOUT" yaml:"connect_timeout" toml:"connect_timeout"`
+	ConnectWait      time.Duration `env:"DB_CONNECT_WAIT" yaml:"connect_wait" toml:"connect_wait"`
+	RetryBackoffBase time.Duration `env:"DB_RETRY_BACKOFF_BASE" yaml:"retry_backoff_base" toml:"retry_backoff_base"`
+	RetryBackoffMax  time.Duration `env:"DB_RETRY_BACKOFF_MAX" yaml:"retry_backoff_max" toml:"retry_backoff_max"`
+
+	// AutoMigrate applies pending migrations when the server starts, for the
+	// sqlite storage too. Without it the server refuses to start until
+	// `songlib migrate up` has been run.
+	AutoMigrate bool `env:"DB_AUTO_MIGRATE" yaml:"auto_migrate" toml:"auto_migrate"`
+
+	// RequestTimeout bounds the database work done for a single HTTP request.
+	RequestTimeout time.Duration `env:"DB_REQUEST_TIMEOUT" yaml:"request_timeout" toml:"request_timeout"`
+}
+
+// DSN returns the connection URL; both lib/pq and the migrations accept it.
+// Parameters already present in DB_URL take precedence over the separate
+// settings.
+func (c DatabaseConfig) DSN() string {
+	u := &url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(c.Username, c.Password),
+		Host:   net.JoinHostPort(c.Host, strconv.Itoa(c.Port)),
+		Path:   "/" + c.Database,
+	}
+	if c.DbUrl != "" {
+		if parsed, err := url.Parse(c.DbUrl); err == nil {
+			u = parsed
+		}
+	}
+
+	query := u.Query()
+	setDefault := func(key, value string) {
+		if value != "" && !query.Has(key) {
+			query.Set(key, value)
+		}
+	}
+	setDefault("sslmode", c.SSLMode)
+	setDefault("sslrootcert", c.SSLRootCert)
+	setDefault("application_name", c.ApplicationName)
+	if c.ConnectTimeout > 0 {
+		setDefault("connect_timeout", strconv.Itoa(int(c.ConnectTimeout.Round(time.Second)/time.Second)))
+	}
+	if c.StatementTimeout > 0 {
+		// lib/pq sends unknown parameters to the server as session settings.
+		setDefault("statement_timeout", strconv.FormatInt(c.StatementTimeout.Milliseconds(), 10))
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
+
+// SQLiteConfig configures the sqlite backend. Path ":memory:" keeps the
+// database in memory for the lifetime of the process.
+type SQLiteConfig struct {
+	Path        string        `env:"SQLITE_PATH" yaml:"path" toml:"path"`
+	BusyTimeout time.Duration `env:"SQLITE_BUSY_TIMEOUT" yaml:"busy_timeout" toml:"busy_timeout"`
 }
 
 type HttpConfig struct {
-	HttpPort int
+	HttpPort          int           `env:"HTTP_PORT" yaml:"port" toml:"port"`
+	ReadTimeout       time.Duration `env:"HTTP_READ_TIMEOUT" yaml:"read_timeout" toml:"read_timeout"`
+	ReadHeaderTimeout time.Duration `env:"HTTP_READ_HEADER_TIMEOUT" yaml:"read_header_timeout" toml:"read_header_timeout"`
+	WriteTimeout      time.Duration `env:"HTTP_WRITE_TIMEOUT" yaml:"write_timeout" toml:"write_timeout"`
+	IdleTimeout       time.Duration `env:"HTTP_IDLE_TIMEOUT" yaml:"idle_timeout" toml:"idle_timeout"`
+	ShutdownTimeout   time.Duration `env:"HTTP_SHUTDOWN_TIMEOUT" yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+	// HealthCheckTimeout bounds each readiness check.
+	HealthCheckTimeout time.Duration `env:"HEALTH_CHECK_TIMEOUT" yaml:"health_check_timeout" toml:"health_check_timeout"`
 }
 
-func NewConfig() (db *Config, err error) {
-	err = godotenv.Load()
+type EnrichmentConfig struct {
+	ApiUrl          string        `env:"API" yaml:"api_url" toml:"api_url"`
+	CacheSize       int           `env:"ENRICH_CACHE_SIZE" yaml:"cache_size" toml:"cache_size"`
+	CacheTTL        time.Duration `env:"ENRICH_CACHE_TTL" yaml:"cache_ttl" toml:"cache_ttl"`
+	NegativeTTL     time.Duration `env:"ENRICH_NEGATIVE_TTL" yaml:"negative_ttl" toml:"negative_ttl"`
+	PersistentCache bool          `env:"ENRICH_CACHE_PERSISTENT" yaml:"persistent_cache" toml:"persistent_cache"`
+}
 
-	if err != nil {
-		fmt.Println("Error is occurred  on .env file please check", err)
-		return nil, err
-	}
+type LinkCheckConfig struct {
+	Enabled             bool          `env:"LINKCHECK_ENABLED" yaml:"enabled" toml:"enabled"`
+	Interval            time.Duration `env:"LINKCHECK_INTERVAL" yaml:"interval" toml:"interval"`
+	RecheckAfter        time.Duration `env:"LINKCHECK_RECHECK_AFTER" yaml:"recheck_after" toml:"recheck_after"`
+	BatchSize           int           `env:"LINKCHECK_BATCH_SIZE" yaml:"batch_size" toml:"batch_size"`
+	Concurrency         int           `env:"LINKCHECK_CONCURRENCY" yaml:"concurrency" toml:"concurrency"`
+	PerHost             int           `env:"LINKCHECK_PER_HOST" yaml:"per_host" toml:"per_host"`
+	HostDelay           time.Duration `env:"LINKCHECK_HOST_DELAY" yaml:"host_delay" toml:"host_delay"`
+	Timeout             time.Duration `env:"LINKCHECK_TIMEOUT" yaml:"timeout" toml:"timeout"`
+	FailureThreshold    int           `env:"LINKCHECK_FAILURE_THRESHOLD" yaml:"failure_threshold" toml:"failure_threshold"`
+	AutoUpdateRedirects bool          `env:"LINKCHECK_AUTO_UPDATE_REDIRECTS" yaml:"auto_update_redirects" toml:"auto_update_redirects"`
+}
 
-	host := os.Getenv("DB_HOST")
-	port, _ := strconv.Atoi(os.Getenv("DB_PORT"))
-	dbname := os.Getenv("DB_NAME")
-	pass := os.Getenv("DB_PASSWORD")
-	dburl := os.Getenv("DB_URL")
+type EventsConfig struct {
+	Publisher     string        `env:"EVENTS_PUBLISHER" yaml:"publisher" toml:"publisher"`
+	FilePath      string        `env:"EVENTS_FILE" yaml:"file" toml:"file"`
+	RelayInterval time.Duration `env:"EVENTS_RELAY_INTERVAL" yaml:"relay_interval" toml:"relay_interval"`
+	BatchSize     int           `env:"EVENTS_BATCH_SIZE" yaml:"batch_size" toml:"batch_size"`
+	FeedBuffer    int           `env:"EVENTS_FEED_BUFFER" yaml:"feed_buffer" toml:"feed_buffer"`
+	Heartbeat     time.Duration `env:"EVENTS_HEARTBEAT" yaml:"heartbeat" toml:"heartbeat"`
+}
 
-	env := os.Getenv("ENV")
+type TracingConfig struct {
+	// Exporter is "none", "stdout", "file" or "otlp". The OTLP exporter reads
+	// the standard OTEL_EXPORTER_OTLP_* variables.
+	Exporter    string  `env:"TRACING_EXPORTER" yaml:"exporter" toml:"exporter"`
+	FilePath    string  `env:"TRACING_FILE" yaml:"file" toml:"file"`
+	ServiceName string  `env:"OTEL_SERVICE_NAME" yaml:"service_name" toml:"service_name"`
+	SampleRatio float64 `env:"TRACING_SAMPLE_RATIO" yaml:"sample_ratio" toml:"sample_ratio"`
+}
 
-	httpPort, _ := strconv.Atoi(os.Getenv("HTTP_PORT"))
+type WebhookConfig struct {
+	Workers      int           `env:"WEBHOOK_WORKERS" yaml:"workers" toml:"workers"`
+	PollInterval time.Duration `env:"WEBHOOK_POLL_INTERVAL" yaml:"poll_interval" toml:"poll_interval"`
+	MaxAttempts  int           `env:"WEBHOOK_MAX_ATTEMPTS" yaml:"max_attempts" toml:"max_attempts"`
+	BackoffBase  time.Duration `env:"WEBHOOK_BACKOFF_BASE" yaml:"backoff_base" toml:"backoff_base"`
+	BackoffMax   time.Duration `env:"WEBHOOK_BACKOFF_MAX" yaml:"backoff_max" toml:"backoff_max"`
+	MaxFailures  int           `env:"WEBHOOK_MAX_FAILURES" yaml:"max_failures" toml:"max_failures"`
+	Timeout      time.Duration `env:"WEBHOOK_TIMEOUT" yaml:"timeout" toml:"timeout"`
+	// AllowPrivate lets webhooks reach loopback, private and link-local
+	// addresses. It is meant for local development; otherwise anyone who
+	// can register a webhook could probe the internal network.
+	AllowPrivate bool `env:"WEBHOOK_ALLOW_PRIVATE" yaml:"allow_private" toml:"allow_private"`
+}
 
-	return &Config{
-		Env: env,
+// AuthConfig controls authentication on the HTTP API. Keys and users live
+// in the database and are managed with `songlib keys` and `songlib users`,
+// so the memory storage can only run with Enabled off. User accounts and
+// the /auth endpoints are only served when JWTSecret is set.
+type AuthConfig struct {
+	Enabled      bool          `env:"AUTH_ENABLED" yaml:"enabled" toml:"enabled"`
+	JWTSecret    string        `env:"AUTH_JWT_SECRET" yaml:"jwt_secret" toml:"jwt_secret"`
+	AccessTTL    time.Duration `env:"AUTH_ACCESS_TTL" yaml:"access_ttl" toml:"access_ttl"`
+	RefreshTTL   time.Duration `env:"AUTH_REFRESH_TTL" yaml:"refresh_ttl" toml:"refresh_ttl"`
+	Registration bool          `env:"AUTH_REGISTRATION" yaml:"registration" toml:"registration"`
+}
+
+// Default returns the settings used when nothing overrides them.
+func Default() Config {
+	return Config{
+		Env:     "local",
+		Storage: "postgres",
 		DBConn: DatabaseConfig{
-			Host:     host,
-			Port:     port,
-			Database: dbname,
-			Username: pass,
-			Password: pass,
-			DbUrl:    dburl,
+			Host:             "localhost",
+			Port:             5432,
+			Database:         "testtask",
+			Username:         "postgres",
+			SSLMode:          "disable",
+			ApplicationName:  "songLibraries",
+			MaxOpenConns:     25,
+			MaxIdleConns:     5,
+			ConnMaxLifetime:  30 * time.Minute,
+			ConnMaxIdleTime:  5 * time.Minute,
+			StatementTimeout: 30 * time.Second,
+			ConnectTimeout:   5 * time.Second,
+			ConnectWait:      time.Minute,
+			RetryBackoffBase: 500 * time.Millisecond,
+			RetryBackoffMax:  10 * time.Second,
+			RequestTimeout:   5 * time.Second,
+		},
+		SQLite: SQLiteConfig{
+			Path:        "songlib.db",
+			BusyTimeout: 5 * time.Second,
 		},
 		HttpConn: HttpConfig{
-			HttpPort: httpPort,
+			HttpPort:           8080,
+			ReadTimeout:        10 * time.Second,
+			ReadHeaderTimeout:  5 * time.Second,
+			WriteTimeout:       30 * time.Second,
+			IdleTimeout:        2 * time.Minute,
+			ShutdownTimeout:    15 * time.Second,
+			HealthCheckTimeout: 2 * time.Second,
+		},
+		Enrichment: EnrichmentConfig{
+			ApiUrl:      "http://127.0.0.1:8000/search",
+			CacheSize:   1000,
+			CacheTTL:    24 * time.Hour,
+			NegativeTTL: time.Hour,
 		},
-	}, nil
+		LinkCheck: LinkCheckConfig{
+			Interval:         10 * time.Minute,
+			RecheckAfter:     24 * time.Hour,
+			BatchSize:        100,
+			Concurrency:      8,
+			PerHost:          2,
+			HostDelay:        time.Second,
+			Timeout:          10 * time.Second,
+			FailureThreshold: 3,
+		},
+		Events: EventsConfig{
+			Publisher:     "memory",
+			FilePath:      "events.ndjson",
+			RelayInterval: time.Second,
+			BatchSize:     100,
+			FeedBuffer:    1000,
+			Heartbeat:     15 * time.Second,
+		},
+		Webhooks: WebhookConfig{
+			Workers:      4,
+			PollInterval: time.Second,
+			MaxAttempts:  5,
+			BackoffBase:  time.Second,
+			BackoffMax:   time.Minute,
+			MaxFailures:  10,
+			Timeout:      10 * time.Second,
+		},
+		Auth: AuthConfig{
+			Enabled:      true,
+			AccessTTL:    15 * time.Minute,
+			RefreshTTL:   30 * 24 * time.Hour,
+			Registration: true,
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			FilePath:    "traces.ndjson",
+			ServiceName: "songLibraries",
+			SampleRatio: 1,
+		},
+	}
+}
+
+// NewConfig loads the configuration without command line flags.
+func NewConfig() (db *Config, err error) {
+	return Load(nil)
 }
@@ -0,0 +1,228 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvConfigFile names the config file when -config is not given.
+const EnvConfigFile = "CONFIG_FILE"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// setting is one leaf of Config together with its env name.
+type setting struct {
+	key   string
+	value reflect.Value
+}
+
+func (s setting) flagName() string {
+	return strings.ReplaceAll(strings.ToLower(s.key), "_", "-")
+}
+
+func settings(v reflect.Value) []setting {
+	var result []setting
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if key := field.Tag.Get("env"); key != "" {
+			result = append(result, setting{key: key, value: v.Field(i)})
+			continue
+		}
+		if field.Type.Kind() == reflect.Struct {
+			result = append(result, settings(v.Field(i))...)
+		}
+	}
+	return result
+}
+
+func (s setting) set(raw string) error {
+	if s.value.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("%s: invalid duration %q", s.key, raw)
+		}
+		s.value.SetInt(int64(d))
+		return nil
+	}
+
+	switch s.value.Kind() {
+	case reflect.String:
+		s.value.SetString(raw)
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("%s: invalid integer %q", s.key, raw)
+		}
+		s.value.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%s: invalid boolean %q", s.key, raw)
+		}
+		s.value.SetBool(b)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("%s: invalid number %q", s.key, raw)
+		}
+		s.value.SetFloat(f)
+	default:
+		return fmt.Errorf("%s: unsupported type %s", s.key, s.value.Type())
+	}
+	return nil
+}
+
+// flagValue records the raw flag so it is applied after the file and the
+// environment, and parsed together with them.
+type flagValue struct {
+	setting setting
+	raw     string
+}
+
+func (f *flagValue) String() string {
+	return f.raw
+}
+
+func (f *flagValue) Set(raw string) error {
+	f.raw = raw
+	return nil
+}
+
+func (f *flagValue) IsBoolFlag() bool {
+	return f.setting.value.Kind() == reflect.Bool
+}
+
+// Load builds the configuration from defaults, the config file, the
+// environment and args, then validates it. All problems are reported at once.
+func Load(args []string) (*Config, error) {
+	const op = "config.Load"
+
+	cfg := Default()
+	all := settings(reflect.ValueOf(&cfg).Elem())
+
+	flags := flag.NewFlagSet("config", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	configFile := flags.String("config", "", "path to a YAML or TOML config file")
+	values := make(map[string]*flagValue, len(all))
+	for _, s := range all {
+		v := &flagValue{setting: s}
+		values[s.flagName()] = v
+		flags.Var(v, s.flagName(), "overrides "+s.key)
+	}
+	if args != nil {
+		flags.SetOutput(os.Stderr)
+		if err := flags.Parse(args); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%s: .env: %w", op, err)
+	}
+
+	path := *configFile
+	if path == "" {
+		path = os.Getenv(EnvConfigFile)
+	}
+	if path != "" {
+		if err := decodeFile(path, &cfg); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	var errs []error
+	for _, s := range all {
+		raw, ok, err := lookupEnv(s.key)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if ok {
+			if err := s.set(raw); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	flags.Visit(func(f *flag.Flag) {
+		v, ok := values[f.Name]
+		if !ok {
+			return
+		}
+		if err := v.setting.set(v.raw); err != nil {
+			errs = append(errs, fmt.Errorf("-%s: %w", f.Name, err))
+		}
+	})
+
+	if len(errs) == 0 {
+		if err := cfg.Validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("%s: %w", op, errors.Join(errs...))
+	}
+
+	return &cfg, nil
+}
+
+// lookupEnv reads key, or the file named by key_FILE when key is unset.
+// Empty variables count as unset.
+func lookupEnv(key string) (string, bool, error) {
+	if v := os.Getenv(key); v != "" {
+		return v, true, nil
+	}
+
+	path := os.Getenv(key + "_FILE")
+	if path == "" {
+		return "", false, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("%s_FILE: %w", key, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), true, nil
+}
+
+func decodeFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	case ".toml":
+		meta, err := toml.Decode(string(data), cfg)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("%s: unknown keys %v", path, undecoded)
+		}
+	default:
+		return fmt.Errorf("%s: unsupported config format, use .yaml, .yml or .toml", path)
+	}
+
+	return nil
+}
@@ -0,0 +1,247 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/2pizzzza/TestTask/internal/config"
+)
+
+// writeFile stores content in a temp file called name and returns its path.
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return path
+}
+
+// clearEnv hides variables the tests set from the surrounding environment;
+// empty variables count as unset.
+func clearEnv(t *testing.T, keys ...string) {
+	t.Helper()
+	for _, key := range append(keys, config.EnvConfigFile) {
+		t.Setenv(key, "")
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	const file = "http:\n  port: 9001\n  shutdown_timeout: 1m\n"
+
+	tests := []struct {
+		name     string
+		file     string
+		env      string
+		args     []string
+		wantPort int
+	}{
+		{name: "defaults", wantPort: 8080},
+		{name: "file over defaults", file: file, wantPort: 9001},
+		{name: "env over file", file: file, env: "9002", wantPort: 9002},
+		{name: "flag over env", file: file, env: "9002", args: []string{"-http-port", "9003"}, wantPort: 9003},
+		{name: "flag over defaults", args: []string{"-http-port=9003"}, wantPort: 9003},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t, "HTTP_PORT", "HTTP_SHUTDOWN_TIMEOUT")
+			t.Setenv("HTTP_PORT", tt.env)
+			if tt.file != "" {
+				t.Setenv(config.EnvConfigFile, writeFile(t, "config.yaml", tt.file))
+			}
+
+			cfg, err := config.Load(append([]string{}, tt.args...))
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if cfg.HttpConn.HttpPort != tt.wantPort {
+				t.Errorf("HttpPort = %d, want %d", cfg.HttpConn.HttpPort, tt.wantPort)
+			}
+
+			// Settings the file does not mention keep their defaults.
+			wantShutdown := config.Default().HttpConn.ShutdownTimeout
+			if tt.file != "" {
+				wantShutdown = time.Minute
+			}
+			if cfg.HttpConn.ShutdownTimeout != wantShutdown {
+				t.Errorf("ShutdownTimeout = %v, want %v", cfg.HttpConn.ShutdownTimeout, wantShutdown)
+			}
+		})
+	}
+}
+
+func TestLoadFormats(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantErr string
+	}{
+		{name: "yaml", file: "config.yml", content: "database:\n  name: songs\n"},
+		{name: "toml", file: "config.toml", content: "[database]\nname = \"songs\"\n"},
+		{name: "unknown yaml key", file: "config.yaml", content: "database:\n  nmae: songs\n", wantErr: "nmae"},
+		{name: "unknown toml key", file: "config.toml", content: "[database]\nnmae = \"songs\"\n", wantErr: "unknown keys"},
+		{name: "unsupported format", file: "config.json", content: "{}", wantErr: "unsupported config format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t, "DB_NAME")
+
+			cfg, err := config.Load([]string{"-config", writeFile(t, tt.file, tt.content)})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load: err = %v, want one mentioning %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if cfg.DBConn.Database != "songs" {
+				t.Errorf("Database = %q, want %q", cfg.DBConn.Database, "songs")
+			}
+		})
+	}
+}
+
+func TestLoadFileVariables(t *testing.T) {
+	secret := writeFile(t, "db_password", "s3cret\n")
+
+	tests := []struct {
+		name     string
+		password string
+		file     string
+		want     string
+		wantErr  string
+	}{
+		{name: "read from file", file: secret, want: "s3cret"},
+		{name: "variable wins over file", password: "direct", file: secret, want: "direct"},
+		{name: "missing file", file: filepath.Join(t.TempDir(), "missing"), wantErr: "DB_PASSWORD_FILE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t, "DB_PASSWORD", "DB_PASSWORD_FILE")
+			t.Setenv("DB_PASSWORD", tt.password)
+			t.Setenv("DB_PASSWORD_FILE", tt.file)
+
+			cfg, err := config.Load(nil)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load: err = %v, want one mentioning %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if cfg.DBConn.Password != tt.want {
+				t.Errorf("Password = %q, want %q", cfg.DBConn.Password, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadReportsAllErrors(t *testing.T) {
+	clearEnv(t, "HTTP_PORT", "DB_REQUEST_TIMEOUT", "EVENTS_BATCH_SIZE")
+	t.Setenv("HTTP_PORT", "eighty")
+	t.Setenv("DB_REQUEST_TIMEOUT", "soon")
+
+	_, err := config.Load([]string{"-events-batch-size", "many"})
+	if err == nil {
+		t.Fatal("Load succeeded with invalid settings")
+	}
+	for _, want := range []string{"HTTP_PORT", "DB_REQUEST_TIMEOUT", "-events-batch-size"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %s", err, want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *config.Config)
+		want   []string
+	}{
+		{name: "defaults", modify: func(c *config.Config) {}},
+		{
+			name: "several problems at once",
+			modify: func(c *config.Config) {
+				c.Storage = "mongo"
+				c.HttpConn.HttpPort = 0
+				c.Webhooks.BackoffMax = c.Webhooks.BackoffBase / 2
+			},
+			want: []string{"STORAGE", "HTTP_PORT", "WEBHOOK_BACKOFF_MAX"},
+		},
+		{
+			name: "database url replaces the parts",
+			modify: func(c *config.Config) {
+				c.DBConn.Host = ""
+				c.DBConn.DbUrl = "postgres://user:pass@db:5432/songs"
+			},
+		},
+		{
+			name:   "invalid database url",
+			modify: func(c *config.Config) { c.DBConn.DbUrl = "mysql://db/songs" },
+			want:   []string{"DB_URL"},
+		},
+		{
+			name: "memory storage with auth",
+			modify: func(c *config.Config) {
+				c.Storage = "memory"
+				c.Auth.Enabled = true
+			},
+			want: []string{"AUTH_ENABLED"},
+		},
+		{
+			name:   "short jwt secret",
+			modify: func(c *config.Config) { c.Auth.JWTSecret = "short" },
+			want:   []string{"AUTH_JWT_SECRET"},
+		},
+		{
+			name: "link checker settings only when enabled",
+			modify: func(c *config.Config) {
+				c.LinkCheck.Enabled = false
+				c.LinkCheck.FailureThreshold = 0
+			},
+		},
+		{
+			name: "link checker enabled",
+			modify: func(c *config.Config) {
+				c.LinkCheck.Enabled = true
+				c.LinkCheck.FailureThreshold = 0
+			},
+			want: []string{"LINKCHECK_FAILURE_THRESHOLD"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Default()
+			tt.modify(&cfg)
+
+			err := cfg.Validate()
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("Validate: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Validate succeeded, want errors for %v", tt.want)
+			}
+			for _, key := range tt.want {
+				if !strings.Contains(err.Error(), key) {
+					t.Errorf("error %q does not mention %s", err, key)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+type validator struct {
+	errs []error
+}
+
+func (v *validator) check(ok bool, key, format string, args ...any) {
+	if !ok {
+		v.errs = append(v.errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+	}
+}
+
+func (v *validator) positive(d time.Duration, key string) {
+	v.check(d > 0, key, "must be positive, got %s", d)
+}
+
+func (v *validator) atLeast(n, min int, key string) {
+	v.check(n >= min, key, "must be at least %d, got %d", min, n)
+}
+
+func (v *validator) oneOf(value, key string, allowed ...string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	v.check(false, key, "must be one of %v, got %q", allowed, value)
+}
+
+func (v *validator) port(port int, key string) {
+	v.check(port > 0 && port < 65536, key, "must be between 1 and 65535, got %d", port)
+}
+
+func (v *validator) httpURL(raw, key string) {
+	u, err := url.Parse(raw)
+	v.check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "",
+		key, "must be an absolute http(s) url, got %q", raw)
+}
+
+func (v *validator) postgresURL(raw, key string) {
+	u, err := url.Parse(raw)
+	v.check(err == nil && (u.Scheme == "postgres" || u.Scheme == "postgresql") && u.Host != "",
+		key, "must be a postgres:// url")
+}
+
+// Validate reports every invalid setting, not only the first one.
+func (c *Config) Validate() error {
+	var v validator
+
+	v.oneOf(c.Env, "ENV", "local", "dev", "prod")
+	v.oneOf(c.Storage, "STORAGE", "postgres", "sqlite", "memory")
+	if c.Storage == "sqlite" {
+		v.check(c.SQLite.Path != "", "SQLITE_PATH", "is required for the sqlite storage")
+		v.check(c.SQLite.BusyTimeout >= 0, "SQLITE_BUSY_TIMEOUT", "must not be negative")
+	}
+
+	db := c.DBConn
+	if db.DbUrl != "" {
+		v.postgresURL(db.DbUrl, "DB_URL")
+	} else {
+		v.check(db.Host != "", "DB_HOST", "is required")
+		v.port(db.Port, "DB_PORT")
+		v.check(db.Database != "", "DB_NAME", "is required")
+		v.check(db.Username != "", "DB_USER", "is required")
+	}
+	v.oneOf(db.SSLMode, "DB_SSLMODE", "disable", "allow", "prefer", "require", "verify-ca", "verify-full")
+	if db.SSLRootCert != "" {
+		v.check(db.SSLMode == "verify-ca" || db.SSLMode == "verify-full",
+			"DB_SSLROOTCERT", "needs DB_SSLMODE verify-ca or verify-full, got %q", db.SSLMode)
+	}
+	v.atLeast(db.MaxOpenConns, 0, "DB_MAX_OPEN_CONNS")
+	v.atLeast(db.MaxIdleConns, 0, "DB_MAX_IDLE_CONNS")
+	if db.MaxOpenConns > 0 {
+		v.check(db.MaxIdleConns <= db.MaxOpenConns, "DB_MAX_IDLE_CONNS", "must not exceed DB_MAX_OPEN_CONNS")
+	}
+	v.check(db.ConnMaxLifetime >= 0, "DB_CONN_MAX_LIFETIME", "must not be negative")
+	v.check(db.ConnMaxIdleTime >= 0, "DB_CONN_MAX_IDLE_TIME", "must not be negative")
+	v.check(db.StatementTimeout >= 0, "DB_STATEMENT_TIMEOUT", "must not be negative")
+	v.check(db.ConnectTimeout >= 0, "DB_CONNECT_TIMEOUT", "must not be negative")
+	v.check(db.ConnectWait >= 0, "DB_CONNECT_WAIT", "must not be negative")
+	v.positive(db.RetryBackoffBase, "DB_RETRY_BACKOFF_BASE")
+	v.check(db.RetryBackoffMax >= db.RetryBackoffBase, "DB_RETRY_BACKOFF_MAX", "must not be less than DB_RETRY_BACKOFF_BASE")
+	v.positive(db.RequestTimeout, "DB_REQUEST_TIMEOUT")
+
+	h := c.HttpConn
+	v.port(h.HttpPort, "HTTP_PORT")
+	v.check(h.ReadTimeout >= 0, "HTTP_READ_TIMEOUT", "must not be negative")
+	v.check(h.ReadHeaderTimeout >= 0, "HTTP_READ_HEADER_TIMEOUT", "must not be negative")
+	v.check(h.WriteTimeout >= 0, "HTTP_WRITE_TIMEOUT", "must not be negative")
+	v.check(h.IdleTimeout >= 0, "HTTP_IDLE_TIMEOUT", "must not be negative")
+	v.positive(h.ShutdownTimeout, "HTTP_SHUTDOWN_TIMEOUT")
+	v.positive(h.HealthCheckTimeout, "HEALTH_CHECK_TIMEOUT")
+
+	e := c.Enrichment
+	v.httpURL(e.ApiUrl, "API")
+	v.atLeast(e.CacheSize, 1, "ENRICH_CACHE_SIZE")
+	v.positive(e.CacheTTL, "ENRICH_CACHE_TTL")
+	v.positive(e.NegativeTTL, "ENRICH_NEGATIVE_TTL")
+
+	if l := c.LinkCheck; l.Enabled {
+		v.positive(l.Interval, "LINKCHECK_INTERVAL")
+		v.positive(l.RecheckAfter, "LINKCHECK_RECHECK_AFTER")
+		v.atLeast(l.BatchSize, 1, "LINKCHECK_BATCH_SIZE")
+		v.atLeast(l.Concurrency, 1, "LINKCHECK_CONCURRENCY")
+		v.atLeast(l.PerHost, 1, "LINKCHECK_PER_HOST")
+		v.check(l.HostDelay >= 0, "LINKCHECK_HOST_DELAY", "must not be negative")
+		v.positive(l.Timeout, "LINKCHECK_TIMEOUT")
+		v.atLeast(l.FailureThreshold, 1, "LINKCHECK_FAILURE_THRESHOLD")
+	}
+
+	ev := c.Events
+	v.oneOf(ev.Publisher, "EVENTS_PUBLISHER", "memory", "file")
+	if ev.Publisher == "file" {
+		v.check(ev.FilePath != "", "EVENTS_FILE", "is required for the file publisher")
+	}
+	v.positive(ev.RelayInterval, "EVENTS_RELAY_INTERVAL")
+	v.atLeast(ev.BatchSize, 1, "EVENTS_BATCH_SIZE")
+	v.atLeast(ev.FeedBuffer, 1, "EVENTS_FEED_BUFFER")
+	v.positive(ev.Heartbeat, "EVENTS_HEARTBEAT")
+
+	w := c.Webhooks
+	v.atLeast(w.Workers, 1, "WEBHOOK_WORKERS")
+	v.positive(w.PollInterval, "WEBHOOK_POLL_INTERVAL")
+	v.atLeast(w.MaxAttempts, 1, "WEBHOOK_MAX_ATTEMPTS")
+	v.positive(w.BackoffBase, "WEBHOOK_BACKOFF_BASE")
+	v.check(w.BackoffMax >= w.BackoffBase, "WEBHOOK_BACKOFF_MAX", "must not be less than WEBHOOK_BACKOFF_BASE")
+	v.atLeast(w.MaxFailures, 1, "WEBHOOK_MAX_FAILURES")
+	v.positive(w.Timeout, "WEBHOOK_TIMEOUT")
+
+	if c.Auth.Enabled {
+		v.check(c.Storage != "memory", "AUTH_ENABLED", "needs API keys stored in postgres or sqlite; disable it for the memory storage")
+		if a := c.Auth; a.JWTSecret != "" {
+			v.check(len(a.JWTSecret) >= 32, "AUTH_JWT_SECRET", "must be at least 32 bytes long")
+			v.positive(a.AccessTTL, "AUTH_ACCESS_TTL")
+			v.check(a.RefreshTTL > a.AccessTTL, "AUTH_REFRESH_TTL", "must be longer than AUTH_ACCESS_TTL")
+		}
+	}
+
+	t := c.Tracing
+	v.oneOf(t.Exporter, "TRACING_EXPORTER", "none", "stdout", "file", "otlp")
+	if t.Exporter == "file" {
+		v.check(t.FilePath != "", "TRACING_FILE", "is required for the file exporter")
+	}
+	v.check(t.ServiceName != "", "OTEL_SERVICE_NAME", "is required")
+	v.check(t.SampleRatio >= 0 && t.SampleRatio <= 1, "TRACING_SAMPLE_RATIO", "must be between 0 and 1, got %g", t.SampleRatio)
+
+	return errors.Join(v.errs...)
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// Scopes of API keys and user roles. They are hierarchical: ScopeAdmin
+// grants every other scope, songs:moderate grants songs:write and
+// songs:write grants songs:read.
+const (
+	ScopeSongsRead     = "songs:read"
+	ScopeSongsWrite    = "songs:write"
+	ScopeSongsModerate = "songs:moderate"
+	ScopeAdmin         = "admin"
+)
+
+// APIKey describes a key without the key itself, which is shown once when
+// the key is created and stored only as a hash.
+type APIKey struct {
+	Id         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Allows reports whether the key grants the scope.
+func (k APIKey) Allows(scope string) bool {
+	return allows(k.Scopes, scope)
+}
+
+// scopeRank orders the hierarchical scopes; a scope grants those of lower
+// rank.
+var scopeRank = map[string]int{
+	ScopeSongsRead:     1,
+	ScopeSongsWrite:    2,
+	ScopeSongsModerate: 3,
+	ScopeAdmin:         4,
+}
+
+func allows(scopes []string, scope string) bool {
+	want, ranked := scopeRank[scope]
+	for _, s := range scopes {
+		if s == scope || s == ScopeAdmin || ranked && scopeRank[s] >= want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package models
+
+import "testing"
+
+func TestAPIKeyScopesAreHierarchical(t *testing.T) {
+	tests := []struct {
+		scopes  []string
+		allowed []string
+		denied  []string
+	}{
+		{
+			scopes:  []string{ScopeSongsRead},
+			allowed: []string{ScopeSongsRead},
+			denied:  []string{ScopeSongsWrite, ScopeSongsModerate, ScopeAdmin},
+		},
+		{
+			scopes:  []string{ScopeSongsWrite},
+			allowed: []string{ScopeSongsRead, ScopeSongsWrite},
+			denied:  []string{ScopeSongsModerate, ScopeAdmin},
+		},
+		{
+			scopes:  []string{ScopeSongsModerate},
+			allowed: []string{ScopeSongsRead, ScopeSongsWrite, ScopeSongsModerate},
+			denied:  []string{ScopeAdmin},
+		},
+		{
+			scopes:  []string{ScopeAdmin},
+			allowed: []string{ScopeSongsRead, ScopeSongsModerate, ScopeAdmin, "reports:export"},
+		},
+		{
+			scopes: []string{"reports:export"},
+			denied: []string{ScopeSongsRead},
+		},
+	}
+
+	for _, tt := range tests {
+		key := APIKey{Scopes: tt.scopes}
+		for _, scope := range tt.allowed {
+			if !key.Allows(scope) {
+				t.Errorf("key with %v does not allow %s", tt.scopes, scope)
+			}
+		}
+		for _, scope := range tt.denied {
+			if key.Allows(scope) {
+				t.Errorf("key with %v allows %s", tt.scopes, scope)
+			}
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package models
+
+// GroupMergeResult describes what merging groups into another one did, or
+// would do on a dry run.
+type GroupMergeResult struct {
+	Into string `json:"into"`
+	// Moved lists the songs now in the target group.
+	Moved []int64 `json:"moved"`
+	// Conflicts lists songs left behind because the target group already has
+	// a song with the same title.
+	Conflicts     []int64  `json:"conflicts"`
+	RemovedGroups []string `json:"removed_groups"`
+	DryRun        bool     `json:"dry_run"`
+}
@@ -0,0 +1,20 @@
+package models
+
+const (
+	HealthStatusOK          = "ok"
+	HealthStatusDegraded    = "degraded"
+	HealthStatusUnavailable = "unavailable"
+	HealthStatusStarting    = "starting"
+)
+
+type HealthCheck struct {
+	Status     string `json:"status" example:"ok"`
+	Required   bool   `json:"required"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+type HealthReport struct {
+	Status string                 `json:"status" example:"ok"`
+	Checks map[string]HealthCheck `json:"checks,omitempty"`
+}
@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+const (
+	PlatformSpotify    = "spotify"
+	PlatformYoutube    = "youtube"
+	PlatformAppleMusic = "apple_music"
+	PlatformBandcamp   = "bandcamp"
+	PlatformOther      = "other"
+)
+
+const (
+	LinkSourceManual     = "manual"
+	LinkSourceEnrichment = "enrichment"
+)
+
+func ValidPlatform(platform string) bool {
+	switch platform {
+	case PlatformSpotify, PlatformYoutube, PlatformAppleMusic, PlatformBandcamp, PlatformOther:
+		return true
+	}
+	return false
+}
+
+type SongLink struct {
+	Id            int64      `json:"id"`
+	SongId        int64      `json:"song_id"`
+	Platform      string     `json:"platform"`
+	URL           string     `json:"url"`
+	Source        string     `json:"source"`
+	CreatedAt     time.Time  `json:"created_at"`
+	StatusCode    int        `json:"status_code,omitempty"`
+	CheckError    string     `json:"check_error,omitempty"`
+	RedirectURL   string     `json:"redirect_url,omitempty"`
+	Broken        bool       `json:"broken"`
+	Failures      int        `json:"failures,omitempty"`
+	LastCheckedAt *time.Time `json:"last_checked_at,omitempty"`
+}
+
+// LinkCheck is the outcome of probing a stored link.
+type LinkCheck struct {
+	StatusCode  int
+	Error       string
+	RedirectURL string
+	Permanent   bool
+	Broken      bool
+	// Failures counts consecutive failed checks, this one included.
+	Failures  int
+	CheckedAt time.Time
+}
+
+type SongLinkReq struct {
+	Platform string `json:"platform"`
+	URL      string `json:"url"`
+}
@@ -2,13 +2,27 @@ package models
 
 import "time"
 
+// LyricsSourceManual marks lyrics entered by a human; enrichment never overwrites them.
+const LyricsSourceManual = "manual"
+
 type Song struct {
-	Id          int64
-	GroupName   Group
-	SongName    string
-	ReleaseDate time.Time
-	Lyrics      string
-	Link        string
+	Id           int64
+	GroupName    Group
+	SongName     string
+	ReleaseDate  string
+	Lyrics       string
+	LyricsSource string
+	Link         string
+}
+
+// SongDetails holds the enrichable fields of a song. Links maps a platform
+// to the URL the provider found there.
+type SongDetails struct {
+	ReleaseDate  string
+	Link         string
+	Lyrics       string
+	LyricsSource string
+	Links        map[string]string
 }
 
 type Group struct {
@@ -27,6 +41,23 @@ type SongUpdateReq struct {
 	NewSongName  string `json:"new_song_name"`
 }
 
+// LyricsRevision holds the lyrics of a song as they were before a change.
+type LyricsRevision struct {
+	Id           int64     `json:"id"`
+	SongId       int64     `json:"song_id"`
+	Lyrics       string    `json:"lyrics"`
+	LyricsSource string    `json:"lyrics_source,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type LyricsUpdateReq struct {
+	Lyrics string `json:"lyrics"`
+}
+
+type LyricsRollbackReq struct {
+	RevisionId int64 `json:"revision_id"`
+}
+
 type SongCreateResponse struct {
 	Message string `json:"message"`
 }
@@ -50,3 +81,38 @@ type LyricsResponse struct {
 	Total    int      `json:"total"`
 	Couplets []string `json:"couplets"`
 }
+
+type EnrichOptions struct {
+	Concurrency int     `json:"concurrency"`
+	Rate        float64 `json:"rate"`
+	BatchSize   int     `json:"batch_size"`
+	DryRun      bool    `json:"dry_run"`
+}
+
+type EnrichFailure struct {
+	SongID int64  `json:"song_id"`
+	Error  string `json:"error"`
+}
+
+type EnrichSummary struct {
+	Scanned  int             `json:"scanned"`
+	Filled   int             `json:"filled"`
+	NotFound int             `json:"not_found"`
+	Failed   int             `json:"failed"`
+	DryRun   bool            `json:"dry_run"`
+	Failures []EnrichFailure `json:"failures"`
+}
+
+type EnrichJobStatus struct {
+	Running    bool           `json:"running"`
+	StartedAt  time.Time      `json:"started_at"`
+	FinishedAt time.Time      `json:"finished_at"`
+	Summary    *EnrichSummary `json:"summary,omitempty"`
+	Error      string         `json:"error,omitempty"`
+}
+
+type CatalogStats struct {
+	Songs         int64 `json:"songs"`
+	Groups        int64 `json:"groups"`
+	MissingLyrics int64 `json:"missing_lyrics"`
+}
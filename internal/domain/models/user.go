@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+// User roles, from least to most privileged.
+const (
+	RoleViewer    = "viewer"
+	RoleEditor    = "editor"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// Roles lists the roles from least to most privileged.
+var Roles = []string{RoleViewer, RoleEditor, RoleModerator, RoleAdmin}
+
+// RoleScopes returns the scopes a role grants: viewers read songs, editors
+// also create and update them, moderators also delete them and admins may
+// do everything.
+func RoleScopes(role string) []string {
+	switch role {
+	case RoleViewer:
+		return []string{ScopeSongsRead}
+	case RoleEditor:
+		return []string{ScopeSongsRead, ScopeSongsWrite}
+	case RoleModerator:
+		return []string{ScopeSongsRead, ScopeSongsWrite, ScopeSongsModerate}
+	case RoleAdmin:
+		return []string{ScopeAdmin}
+	default:
+		return nil
+	}
+}
+
+type User struct {
+	Id        int64     `json:"id"`
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CredentialsReq struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type RefreshReq struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// TokenResponse follows the OAuth 2.0 token response; ExpiresIn is the
+// lifetime of the access token in seconds.
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Principal is the caller a request was authenticated as, either an API key
+// or a user.
+type Principal struct {
+	APIKeyId int64    `json:"api_key_id,omitempty"`
+	UserId   int64    `json:"user_id,omitempty"`
+	Name     string   `json:"name"`
+	Scopes   []string `json:"scopes"`
+}
+
+// Allows reports whether the principal holds the scope.
+func (p Principal) Allows(scope string) bool {
+	return allows(p.Scopes, scope)
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRoleScopes(t *testing.T) {
+	tests := []struct {
+		role    string
+		want    []string
+		allowed []string
+		denied  []string
+	}{
+		{
+			role:    RoleViewer,
+			want:    []string{ScopeSongsRead},
+			allowed: []string{ScopeSongsRead},
+			denied:  []string{ScopeSongsWrite, ScopeSongsModerate, ScopeAdmin},
+		},
+		{
+			role:    RoleEditor,
+			want:    []string{ScopeSongsRead, ScopeSongsWrite},
+			allowed: []string{ScopeSongsRead, ScopeSongsWrite},
+			denied:  []string{ScopeSongsModerate, ScopeAdmin},
+		},
+		{
+			role:    RoleModerator,
+			want:    []string{ScopeSongsRead, ScopeSongsWrite, ScopeSongsModerate},
+			allowed: []string{ScopeSongsRead, ScopeSongsWrite, ScopeSongsModerate},
+			denied:  []string{ScopeAdmin},
+		},
+		{
+			role:    RoleAdmin,
+			want:    []string{ScopeAdmin},
+			allowed: []string{ScopeSongsRead, ScopeSongsWrite, ScopeSongsModerate, ScopeAdmin},
+		},
+		{
+			role:   "root",
+			denied: []string{ScopeSongsRead, ScopeAdmin},
+		},
+	}
+
+	for _, tt := range tests {
+		scopes := RoleScopes(tt.role)
+		if !slices.Equal(scopes, tt.want) {
+			t.Errorf("RoleScopes(%q) = %v, want %v", tt.role, scopes, tt.want)
+		}
+
+		p := Principal{Scopes: scopes}
+		for _, scope := range tt.allowed {
+			if !p.Allows(scope) {
+				t.Errorf("role %q does not allow %s", tt.role, scope)
+			}
+		}
+		for _, scope := range tt.denied {
+			if p.Allows(scope) {
+				t.Errorf("role %q allows %s", tt.role, scope)
+			}
+		}
+	}
+}
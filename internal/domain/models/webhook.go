@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+type Webhook struct {
+	Id           int64      `json:"id"`
+	URL          string     `json:"url"`
+	Events       []string   `json:"events"`
+	Secret       string     `json:"secret,omitempty"`
+	Active       bool       `json:"active"`
+	FailureCount int        `json:"failure_count"`
+	DisabledAt   *time.Time `json:"disabled_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// Accepts reports whether the webhook subscribed to the event type; an
+// empty filter or "*" subscribes to everything.
+func (w Webhook) Accepts(eventType string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == "*" || e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+type WebhookReq struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret"`
+	Active *bool    `json:"active"`
+}
+
+type WebhookDelivery struct {
+	Id           int64     `json:"id"`
+	WebhookId    int64     `json:"webhook_id"`
+	EventId      int64     `json:"event_id"`
+	EventType    string    `json:"event_type"`
+	Attempt      int       `json:"attempt"`
+	StatusCode   int       `json:"status_code,omitempty"`
+	ResponseBody string    `json:"response_body,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	DurationMs   int64     `json:"duration_ms"`
+	Success      bool      `json:"success"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// WebhookJob is a pending delivery of one event to one webhook. Jobs are
+// stored in the transaction that marks the event published and removed once
+// the delivery succeeds or runs out of attempts.
+type WebhookJob struct {
+	Id            int64
+	WebhookId     int64
+	EventId       int64
+	EventType     string
+	Body          []byte
+	Attempts      int
+	NextAttemptAt time.Time
+	CreatedAt     time.Time
+}
@@ -0,0 +1,202 @@
+package enrichment
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/2pizzzza/TestTask/internal/lib/logger/sl"
+	"github.com/2pizzzza/TestTask/internal/storage"
+	"github.com/2pizzzza/TestTask/internal/utils"
+)
+
+// Entry is a cached provider answer. NotFound entries cache a negative lookup.
+type Entry struct {
+	Info      *utils.TrackInfo
+	NotFound  bool
+	ExpiresAt time.Time
+}
+
+// Store is an optional persistent second-level cache.
+type Store interface {
+	GetCacheEntry(ctx context.Context, key string) (Entry, error)
+	SaveCacheEntry(ctx context.Context, key string, entry Entry) error
+	DeleteCacheEntry(ctx context.Context, key string) error
+	PurgeCache(ctx context.Context) error
+}
+
+type cacheItem struct {
+	key   string
+	entry Entry
+}
+
+// CachedProvider wraps a Provider with an in-memory LRU cache with TTL and,
+// when a Store is given, a persistent cache behind it.
+type CachedProvider struct {
+	log         *slog.Logger
+	next        Provider
+	store       Store
+	size        int
+	ttl         time.Duration
+	negativeTTL time.Duration
+
+	mu    sync.Mutex
+	ll    *list.List
+	items map[string]*list.Element
+	now   func() time.Time
+}
+
+func NewCachedProvider(
+	log *slog.Logger, next Provider, store Store, size int, ttl, negativeTTL time.Duration) *CachedProvider {
+	if size <= 0 {
+		size = 1
+	}
+	return &CachedProvider{
+		log:         log,
+		next:        next,
+		store:       store,
+		size:        size,
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+		ll:          list.New(),
+		items:       make(map[string]*list.Element),
+		now:         time.Now,
+	}
+}
+
+func (c *CachedProvider) FetchTrackInfo(ctx context.Context, songName, groupName string) (*utils.TrackInfo, error) {
+	const op = "enrichment.cache.FetchTrackInfo"
+
+	log := c.log.With(
+		slog.String("op", op),
+	)
+
+	key := Key(groupName, songName)
+
+	if entry, ok := c.get(key); ok {
+		log.Debug("enrichment cache hit", slog.String("key", key))
+		return entryResult(entry)
+	}
+
+	if c.store != nil {
+		entry, err := c.store.GetCacheEntry(ctx, key)
+		switch {
+		case err == nil && c.now().Before(entry.ExpiresAt):
+			log.Debug("enrichment persistent cache hit", slog.String("key", key))
+			c.put(key, entry)
+			return entryResult(entry)
+		case err != nil && !errors.Is(err, storage.ErrCacheEntryNotFound):
+			log.Error("failed to read persistent cache", sl.Err(err))
+		}
+	}
+
+	info, err := c.next.FetchTrackInfo(ctx, songName, groupName)
+	if err != nil && !errors.Is(err, ErrTrackNotFound) {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	entry := Entry{Info: info, ExpiresAt: c.now().Add(c.ttl)}
+	if err != nil {
+		entry = Entry{NotFound: true, ExpiresAt: c.now().Add(c.negativeTTL)}
+	}
+
+	c.put(key, entry)
+	if c.store != nil {
+		if err := c.store.SaveCacheEntry(ctx, key, entry); err != nil {
+			log.Error("failed to write persistent cache", sl.Err(err))
+		}
+	}
+
+	return entryResult(entry)
+}
+
+// Invalidate drops the cached answer for a single (group, song) pair.
+func (c *CachedProvider) Invalidate(ctx context.Context, groupName, songName string) error {
+	const op = "enrichment.cache.Invalidate"
+
+	key := Key(groupName, songName)
+
+	c.mu.Lock()
+	if el, ok := c.items[key]; ok {
+		c.ll.Remove(el)
+		delete(c.items, key)
+	}
+	c.mu.Unlock()
+
+	if c.store != nil {
+		if err := c.store.DeleteCacheEntry(ctx, key); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
+
+// Purge drops every cached answer.
+func (c *CachedProvider) Purge(ctx context.Context) error {
+	const op = "enrichment.cache.Purge"
+
+	c.mu.Lock()
+	c.ll.Init()
+	c.items = make(map[string]*list.Element)
+	c.mu.Unlock()
+
+	if c.store != nil {
+		if err := c.store.PurgeCache(ctx); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
+
+func (c *CachedProvider) get(key string) (Entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return Entry{}, false
+	}
+
+	item := el.Value.(*cacheItem)
+	if !c.now().Before(item.entry.ExpiresAt) {
+		c.ll.Remove(el)
+		delete(c.items, key)
+		return Entry{}, false
+	}
+
+	c.ll.MoveToFront(el)
+	return item.entry, true
+}
+
+func (c *CachedProvider) put(key string, entry Entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		el.Value.(*cacheItem).entry = entry
+		c.ll.MoveToFront(el)
+		return
+	}
+
+	c.items[key] = c.ll.PushFront(&cacheItem{key: key, entry: entry})
+
+	for c.ll.Len() > c.size {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheItem).key)
+	}
+}
+
+func entryResult(entry Entry) (*utils.TrackInfo, error) {
+	if entry.NotFound || entry.Info == nil {
+		return nil, ErrTrackNotFound
+	}
+	info := *entry.Info
+	return &info, nil
+}
@@ -0,0 +1,102 @@
+package enrichment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/2pizzzza/TestTask/internal/utils"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+const SourceSpotifyWrapper = "spotify-wrapper"
+
+// Client talks to the Spotify wrapper search API.
+type Client struct {
+	apiUrl string
+	client *http.Client
+}
+
+func NewClient(apiUrl string) *Client {
+	return &Client{
+		apiUrl: apiUrl,
+		client: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: otelhttp.NewTransport(http.DefaultTransport),
+		},
+	}
+}
+
+func (c *Client) FetchTrackInfo(ctx context.Context, songName, groupName string) (*utils.TrackInfo, error) {
+	const op = "enrichment.client.FetchTrackInfo"
+
+	u, err := url.Parse(c.apiUrl)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	q := u.Query()
+	q.Set("song", songName)
+	q.Set("artist", groupName)
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: error making request: %w", op, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrTrackNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: received non-200 response: %s", op, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%s: error reading response body: %w", op, err)
+	}
+
+	var trackInfo utils.TrackInfo
+	if err := json.Unmarshal(body, &trackInfo); err != nil {
+		return nil, fmt.Errorf("%s: error unmarshalling JSON: %w", op, err)
+	}
+
+	if trackInfo.LyricsSource == "" && LyricsText(&trackInfo) != "" {
+		trackInfo.LyricsSource = SourceSpotifyWrapper
+	}
+
+	return &trackInfo, nil
+}
+
+// Ping checks that the wrapper answers HTTP requests. Any response below 500
+// counts: the search endpoint rejects requests without a query.
+func (c *Client) Ping(ctx context.Context) error {
+	const op = "enrichment.client.Ping"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.apiUrl, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("%s: received %s", op, resp.Status)
+	}
+
+	return nil
+}
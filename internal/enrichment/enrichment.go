@@ -0,0 +1,21 @@
+package enrichment
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/2pizzzza/TestTask/internal/utils"
+)
+
+var ErrTrackNotFound = errors.New("track not found")
+
+// Provider looks up additional information about a track in an external source.
+type Provider interface {
+	FetchTrackInfo(ctx context.Context, songName, groupName string) (*utils.TrackInfo, error)
+}
+
+// Key builds the normalized cache key for a (group, song) pair.
+func Key(groupName, songName string) string {
+	return strings.ToLower(strings.TrimSpace(groupName)) + "|" + strings.ToLower(strings.TrimSpace(songName))
+}
@@ -0,0 +1,26 @@
+package enrichment
+
+import (
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/utils"
+)
+
+// Links collects the per-platform links reported by a provider. The legacy
+// Spotify URL field is folded in as the spotify link.
+func Links(info *utils.TrackInfo) map[string]string {
+	links := make(map[string]string)
+	if info == nil {
+		return links
+	}
+
+	for platform, url := range info.Links {
+		if utils.IsHTTPURL(url) && models.ValidPlatform(platform) {
+			links[platform] = url
+		}
+	}
+	if utils.IsHTTPURL(info.SpotifyURL) {
+		links[models.PlatformSpotify] = info.SpotifyURL
+	}
+
+	return links
+}
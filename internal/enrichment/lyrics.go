@@ -0,0 +1,31 @@
+package enrichment
+
+import (
+	"strings"
+
+	"github.com/2pizzzza/TestTask/internal/utils"
+)
+
+// LyricsText flattens the lyrics returned by a provider into the stored
+// format, where couplets are separated by a blank line. Structured sections
+// win over the plain text, each section becoming one couplet.
+func LyricsText(info *utils.TrackInfo) string {
+	if info == nil {
+		return ""
+	}
+
+	if len(info.LyricsSections) == 0 {
+		return strings.TrimSpace(strings.ReplaceAll(info.Lyrics, "\r\n", "\n"))
+	}
+
+	couplets := make([]string, 0, len(info.LyricsSections))
+	for _, section := range info.LyricsSections {
+		text := strings.TrimSpace(strings.ReplaceAll(section.Text, "\r\n", "\n"))
+		if text == "" {
+			continue
+		}
+		couplets = append(couplets, text)
+	}
+
+	return strings.Join(couplets, "\n\n")
+}
@@ -0,0 +1,158 @@
+// Package enrichstub implements the Spotify wrapper search contract
+// (GET /search?song=&artist=) from fixtures, so enrichment can run offline.
+package enrichstub
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/2pizzzza/TestTask/internal/enrichment"
+	"github.com/2pizzzza/TestTask/internal/utils"
+)
+
+// Fixture is one canned answer. Status, Malformed and Latency override the
+// server-wide fault injection for this track.
+type Fixture struct {
+	Artist    string          `json:"artist"`
+	Song      string          `json:"song"`
+	Response  utils.TrackInfo `json:"response"`
+	Status    int             `json:"status,omitempty"`
+	Malformed bool            `json:"malformed,omitempty"`
+	Latency   string          `json:"latency,omitempty"`
+}
+
+// Options controls fault injection for every request.
+type Options struct {
+	Latency       time.Duration
+	ErrorRate     float64
+	MalformedRate float64
+	Seed          int64
+}
+
+type Stub struct {
+	opts     Options
+	fixtures map[string]Fixture
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+// LoadFixtures reads a JSON array of fixtures from path.
+func LoadFixtures(path string) ([]Fixture, error) {
+	const op = "enrichstub.LoadFixtures"
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var fixtures []Fixture
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return fixtures, nil
+}
+
+func New(fixtures []Fixture, opts Options) *Stub {
+	seed := opts.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	byKey := make(map[string]Fixture, len(fixtures))
+	for _, f := range fixtures {
+		byKey[enrichment.Key(f.Artist, f.Song)] = f
+	}
+
+	return &Stub{
+		opts:     opts,
+		fixtures: byKey,
+		rand:     rand.New(rand.NewSource(seed)),
+	}
+}
+
+// NewServer starts an httptest server serving the stub; its URL plus
+// "/search" is the enrichment API url.
+func NewServer(fixtures []Fixture, opts Options) *httptest.Server {
+	return httptest.NewServer(New(fixtures, opts))
+}
+
+func (s *Stub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/search" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	song := r.URL.Query().Get("song")
+	artist := r.URL.Query().Get("artist")
+	if song == "" || artist == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"detail": "song and artist are required"})
+		return
+	}
+
+	fixture, found := s.fixtures[enrichment.Key(artist, song)]
+
+	latency := s.opts.Latency
+	if found && fixture.Latency != "" {
+		if d, err := time.ParseDuration(fixture.Latency); err == nil {
+			latency = d
+		}
+	}
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if s.roll(s.opts.ErrorRate) {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"detail": "injected error"})
+		return
+	}
+
+	if !found {
+		writeJSON(w, http.StatusNotFound, map[string]string{"detail": "Track not found"})
+		return
+	}
+
+	if fixture.Status != 0 && fixture.Status != http.StatusOK {
+		writeJSON(w, fixture.Status, map[string]string{"detail": http.StatusText(fixture.Status)})
+		return
+	}
+
+	if fixture.Malformed || s.roll(s.opts.MalformedRate) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"Release Date": "2006-07-1`))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, fixture.Response)
+}
+
+func (s *Stub) roll(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rand.Float64() < rate
+}
+
+func writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(data)
+}
@@ -0,0 +1,81 @@
+package events
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+)
+
+const (
+	SongCreated   = "song.created"
+	SongUpdated   = "song.updated"
+	SongDeleted   = "song.deleted"
+	LyricsChanged = "lyrics.changed"
+)
+
+// Event is a domain event recorded in the outbox.
+type Event struct {
+	Id        int64           `json:"id"`
+	Type      string          `json:"type"`
+	SongId    int64           `json:"song_id"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// SongPayload is the snapshot of a song carried by song events. Only
+// lyrics.changed events carry the lyrics themselves, with their SHA-256 so
+// consumers can compare versions without keeping the text.
+type SongPayload struct {
+	SongId       int64  `json:"song_id"`
+	GroupName    string `json:"group_name"`
+	SongName     string `json:"song_name"`
+	ReleaseDate  string `json:"release_date,omitempty"`
+	Link         string `json:"link,omitempty"`
+	LyricsSource string `json:"lyrics_source,omitempty"`
+	Lyrics       string `json:"lyrics,omitempty"`
+	LyricsSHA256 string `json:"lyrics_sha256,omitempty"`
+}
+
+func NewSongPayload(song models.Song) SongPayload {
+	return SongPayload{
+		SongId:       song.Id,
+		GroupName:    song.GroupName.GroupName,
+		SongName:     song.SongName,
+		ReleaseDate:  song.ReleaseDate,
+		Link:         song.Link,
+		LyricsSource: song.LyricsSource,
+	}
+}
+
+// NewPayload returns the payload of an event of eventType about song.
+func NewPayload(eventType string, song models.Song) SongPayload {
+	payload := NewSongPayload(song)
+	if eventType == LyricsChanged {
+		sum := sha256.Sum256([]byte(song.Lyrics))
+		payload.Lyrics = song.Lyrics
+		payload.LyricsSHA256 = hex.EncodeToString(sum[:])
+	}
+	return payload
+}
+
+// Publisher delivers events to the outside world.
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// MultiPublisher publishes every event to all of its publishers and fails
+// if any of them does.
+type MultiPublisher []Publisher
+
+func (m MultiPublisher) Publish(ctx context.Context, event Event) error {
+	for _, p := range m {
+		if err := p.Publish(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package events_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/events"
+)
+
+func TestNewPayloadCarriesLyricsOnlyWhenTheyChange(t *testing.T) {
+	song := models.Song{Id: 1, SongName: "Uprising", Lyrics: "Paranoia is in bloom"}
+
+	changed := events.NewPayload(events.LyricsChanged, song)
+	if changed.Lyrics != song.Lyrics {
+		t.Errorf("Lyrics = %q, want %q", changed.Lyrics, song.Lyrics)
+	}
+	sum := sha256.Sum256([]byte(song.Lyrics))
+	if want := hex.EncodeToString(sum[:]); changed.LyricsSHA256 != want {
+		t.Errorf("LyricsSHA256 = %q, want %q", changed.LyricsSHA256, want)
+	}
+
+	updated := events.NewPayload(events.SongUpdated, song)
+	if updated.Lyrics != "" || updated.LyricsSHA256 != "" {
+		t.Errorf("song.updated payload = %+v, want no lyrics", updated)
+	}
+}
@@ -0,0 +1,58 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// recentIds is how many event ids the in-process sinks remember to skip
+// redeliveries. The relay re-publishes at most one batch when its
+// transaction is retried, so this only needs to exceed EVENTS_BATCH_SIZE.
+const recentIds = 4096
+
+// FilePublisher appends events to a file as newline-delimited JSON. It skips
+// events it wrote recently; after a restart an event may still be appended
+// twice.
+type FilePublisher struct {
+	mu     sync.Mutex
+	file   *os.File
+	enc    *json.Encoder
+	recent *Window
+}
+
+func NewFilePublisher(path string) (*FilePublisher, error) {
+	const op = "events.NewFilePublisher"
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &FilePublisher{file: f, enc: json.NewEncoder(f), recent: NewWindow(recentIds)}, nil
+}
+
+func (p *FilePublisher) Publish(ctx context.Context, event Event) error {
+	const op = "events.FilePublisher.Publish"
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.recent.Add(event.Id) {
+		return nil
+	}
+	if err := p.enc.Encode(event); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (p *FilePublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.file.Close()
+}
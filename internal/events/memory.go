@@ -0,0 +1,99 @@
+package events
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryPublisher keeps published events in memory and fans them out to
+// subscribers. A slow subscriber misses events rather than blocking the relay.
+// Events published again, as when the relay transaction is retried, are
+// dropped.
+type MemoryPublisher struct {
+	mu          sync.Mutex
+	events      []Event
+	limit       int
+	recent      *Window
+	subscribers map[chan Event]struct{}
+}
+
+// NewMemoryPublisher keeps at most limit events; limit <= 0 keeps all.
+func NewMemoryPublisher(limit int) *MemoryPublisher {
+	return &MemoryPublisher{
+		limit:       limit,
+		recent:      NewWindow(recentIds),
+		subscribers: make(map[chan Event]struct{}),
+	}
+}
+
+func (p *MemoryPublisher) Publish(ctx context.Context, event Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.recent.Add(event.Id) {
+		return nil
+	}
+	p.events = append(p.events, event)
+	if p.limit > 0 && len(p.events) > p.limit {
+		p.events = p.events[len(p.events)-p.limit:]
+	}
+
+	for ch := range p.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+
+	return nil
+}
+
+// Since returns the retained events with an id greater than lastId. The
+// second result is false unless the buffer holds the event right after
+// lastId: it may have been dropped, or published before this process
+// started, so the caller cannot resume from lastId using memory alone.
+func (p *MemoryPublisher) Since(lastId int64) ([]Event, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	complete := lastId == 0
+	var result []Event
+	for _, e := range p.events {
+		if e.Id == lastId+1 {
+			complete = true
+		}
+		if e.Id > lastId {
+			result = append(result, e)
+		}
+	}
+
+	return result, complete
+}
+
+// Events returns a copy of the retained events.
+func (p *MemoryPublisher) Events() []Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]Event(nil), p.events...)
+}
+
+// Subscribe returns a channel receiving every event published after the call
+// and a function that unsubscribes and closes the channel.
+func (p *MemoryPublisher) Subscribe(buffer int) (<-chan Event, func()) {
+	ch := make(chan Event, buffer)
+
+	p.mu.Lock()
+	p.subscribers[ch] = struct{}{}
+	p.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			p.mu.Lock()
+			delete(p.subscribers, ch)
+			p.mu.Unlock()
+			close(ch)
+		})
+	}
+}
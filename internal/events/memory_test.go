@@ -0,0 +1,45 @@
+package events_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/2pizzzza/TestTask/internal/events"
+)
+
+func TestMemoryPublisherSince(t *testing.T) {
+	ctx := context.Background()
+	p := events.NewMemoryPublisher(3)
+
+	if _, complete := p.Since(5); complete {
+		t.Error("Since(5) on an empty buffer is complete, want incomplete")
+	}
+	if _, complete := p.Since(0); !complete {
+		t.Error("Since(0) on an empty buffer is incomplete, want complete")
+	}
+
+	for id := int64(1); id <= 5; id++ {
+		if err := p.Publish(ctx, events.Event{Id: id}); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+
+	tests := []struct {
+		lastId   int64
+		want     int
+		complete bool
+	}{
+		{lastId: 0, want: 3, complete: true},
+		{lastId: 1, want: 3, complete: false}, // event 2 was dropped
+		{lastId: 2, want: 3, complete: true},
+		{lastId: 4, want: 1, complete: true},
+		{lastId: 5, want: 0, complete: false},
+		{lastId: 9, want: 0, complete: false},
+	}
+	for _, tt := range tests {
+		got, complete := p.Since(tt.lastId)
+		if len(got) != tt.want || complete != tt.complete {
+			t.Errorf("Since(%d) = %d events, complete %v; want %d, %v", tt.lastId, len(got), complete, tt.want, tt.complete)
+		}
+	}
+}
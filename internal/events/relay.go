@@ -0,0 +1,115 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/2pizzzza/TestTask/internal/lib/logger/sl"
+)
+
+// OutboxStore is the storage side of the transactional outbox.
+// FetchUnpublished locks the rows it returns until the surrounding
+// transaction ends and skips rows another transaction holds.
+type OutboxStore interface {
+	FetchUnpublished(ctx context.Context, limit int) ([]Event, error)
+	MarkPublished(ctx context.Context, ids []int64) error
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// Relay moves events from the outbox to a Publisher. Delivery is at least
+// once: an event is marked published only after Publish succeeded. Each
+// batch is fetched, published and marked in one transaction, so relays in
+// several replicas never pick up the same events. Publishers that write to
+// the same storage join that transaction; the others see the batch again
+// when the transaction is retried and must drop events they already have.
+type Relay struct {
+	log       *slog.Logger
+	store     OutboxStore
+	publisher Publisher
+	interval  time.Duration
+	batchSize int
+}
+
+func NewRelay(log *slog.Logger, store OutboxStore, publisher Publisher, interval time.Duration, batchSize int) *Relay {
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	return &Relay{
+		log:       log,
+		store:     store,
+		publisher: publisher,
+		interval:  interval,
+		batchSize: batchSize,
+	}
+}
+
+// Run polls the outbox every interval until ctx is cancelled.
+func (r *Relay) Run(ctx context.Context) {
+	const op = "events.Relay.Run"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := r.RelayOnce(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Error("failed to relay events", sl.Err(err))
+				}
+				break
+			}
+			if n < r.batchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayOnce publishes one batch of pending events and returns how many were published.
+func (r *Relay) RelayOnce(ctx context.Context) (int, error) {
+	const op = "events.Relay.RelayOnce"
+
+	var (
+		published  []int64
+		publishErr error
+	)
+	err := r.store.WithinTx(ctx, func(ctx context.Context) error {
+		pending, err := r.store.FetchUnpublished(ctx, r.batchSize)
+		if err != nil {
+			return err
+		}
+
+		// The transaction may be retried, so start over each time.
+		published, publishErr = make([]int64, 0, len(pending)), nil
+		for _, event := range pending {
+			if err := r.publisher.Publish(ctx, event); err != nil {
+				publishErr = fmt.Errorf("%s: event %d: %w", op, event.Id, err)
+				break
+			}
+			published = append(published, event.Id)
+		}
+
+		if len(published) == 0 {
+			return nil
+		}
+		return r.store.MarkPublished(ctx, published)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return len(published), publishErr
+}
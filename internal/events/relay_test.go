@@ -0,0 +1,152 @@
+package events_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/events"
+	"github.com/2pizzzza/TestTask/internal/storage/memory"
+)
+
+// failingPublisher records events and fails on the event with id failOn.
+type failingPublisher struct {
+	failOn int64
+	got    []int64
+}
+
+func (p *failingPublisher) Publish(_ context.Context, event events.Event) error {
+	if event.Id == p.failOn {
+		return errors.New("sink unavailable")
+	}
+	p.got = append(p.got, event.Id)
+	return nil
+}
+
+func seedSongs(t *testing.T, db *memory.Storage, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		if _, err := db.Save(context.Background(), "group", fmt.Sprintf("song %d", i), models.SongDetails{}); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+}
+
+func newRelay(db *memory.Storage, p events.Publisher) *events.Relay {
+	return events.NewRelay(slog.New(slog.NewTextHandler(io.Discard, nil)), db, p, time.Second, 10)
+}
+
+func TestRelayMarksPublishedEvents(t *testing.T) {
+	db := memory.New()
+	seedSongs(t, db, 3)
+	ctx := context.Background()
+
+	p := &failingPublisher{}
+	n, err := newRelay(db, p).RelayOnce(ctx)
+	if err != nil || n != 3 {
+		t.Fatalf("RelayOnce = %d, %v, want 3 events", n, err)
+	}
+
+	pending, err := db.FetchUnpublished(ctx, 10)
+	if err != nil {
+		t.Fatalf("FetchUnpublished: %v", err)
+	}
+	if len(pending) != 0 {
+		t.Errorf("%d events left unpublished, want none", len(pending))
+	}
+
+	if n, err := newRelay(db, p).RelayOnce(ctx); err != nil || n != 0 {
+		t.Errorf("second RelayOnce = %d, %v, want nothing to do", n, err)
+	}
+}
+
+func TestRelayKeepsEventsAfterFailure(t *testing.T) {
+	db := memory.New()
+	seedSongs(t, db, 3)
+	ctx := context.Background()
+
+	p := &failingPublisher{failOn: 2}
+	n, err := newRelay(db, p).RelayOnce(ctx)
+	if err == nil || n != 1 {
+		t.Fatalf("RelayOnce = %d, %v, want 1 event and an error", n, err)
+	}
+
+	// The event before the failure is done; the failed one and everything
+	// after it stay in the outbox for the next round.
+	pending, err := db.FetchUnpublished(ctx, 10)
+	if err != nil {
+		t.Fatalf("FetchUnpublished: %v", err)
+	}
+	if len(pending) != 2 || pending[0].Id != 2 || pending[1].Id != 3 {
+		t.Errorf("unpublished = %+v, want events 2 and 3", pending)
+	}
+
+	p.failOn = 0
+	if n, err := newRelay(db, p).RelayOnce(ctx); err != nil || n != 2 {
+		t.Errorf("RelayOnce after recovery = %d, %v, want 2 events", n, err)
+	}
+	if len(p.got) != 3 {
+		t.Errorf("published %v, want each event once", p.got)
+	}
+}
+
+// retryingStore runs every transaction twice, rolling the first attempt
+// back as a serialization failure would.
+type retryingStore struct {
+	*memory.Storage
+}
+
+func (s retryingStore) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	errRetry := errors.New("could not serialize access")
+	err := s.Storage.WithinTx(ctx, func(ctx context.Context) error {
+		if err := fn(ctx); err != nil {
+			return err
+		}
+		return errRetry
+	})
+	if !errors.Is(err, errRetry) {
+		return err
+	}
+	return s.Storage.WithinTx(ctx, fn)
+}
+
+func TestRelayRetryDoesNotDuplicateLocalSinks(t *testing.T) {
+	db := memory.New()
+	seedSongs(t, db, 3)
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+	file, err := events.NewFilePublisher(path)
+	if err != nil {
+		t.Fatalf("NewFilePublisher: %v", err)
+	}
+	feed := events.NewMemoryPublisher(10)
+
+	relay := events.NewRelay(slog.New(slog.NewTextHandler(io.Discard, nil)), retryingStore{db},
+		events.MultiPublisher{file, feed}, time.Second, 10)
+	if n, err := relay.RelayOnce(ctx); err != nil || n != 3 {
+		t.Fatalf("RelayOnce = %d, %v, want 3 events", n, err)
+	}
+	if err := file.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if got := len(feed.Events()); got != 3 {
+		t.Errorf("feed holds %d events, want 3", got)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 3 {
+		t.Errorf("file holds %d events, want 3", lines)
+	}
+}
@@ -0,0 +1,36 @@
+package events
+
+// Window remembers the last size event ids it was given, so a consumer can
+// drop redelivered events without assuming that ids arrive in order: outbox
+// ids are assigned on insert, and a transaction that commits later may carry
+// a lower id. It is not safe for concurrent use.
+type Window struct {
+	seen map[int64]struct{}
+	ring []int64
+	next int
+}
+
+func NewWindow(size int) *Window {
+	return &Window{
+		seen: make(map[int64]struct{}, size),
+		ring: make([]int64, 0, size),
+	}
+}
+
+// Add records id and reports whether it was not in the window yet.
+func (w *Window) Add(id int64) bool {
+	if _, ok := w.seen[id]; ok {
+		return false
+	}
+
+	if len(w.ring) < cap(w.ring) {
+		w.ring = append(w.ring, id)
+	} else {
+		delete(w.seen, w.ring[w.next])
+		w.ring[w.next] = id
+		w.next = (w.next + 1) % len(w.ring)
+	}
+	w.seen[id] = struct{}{}
+
+	return true
+}
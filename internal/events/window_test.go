@@ -0,0 +1,30 @@
+package events_test
+
+import (
+	"testing"
+
+	"github.com/2pizzzza/TestTask/internal/events"
+)
+
+func TestWindow(t *testing.T) {
+	w := events.NewWindow(3)
+
+	steps := []struct {
+		id   int64
+		want bool
+	}{
+		{id: 5, want: true},
+		{id: 3, want: true}, // lower ids arriving late are still new
+		{id: 5, want: false},
+		{id: 4, want: true},
+		{id: 3, want: false},
+		{id: 6, want: true}, // evicts 5, the oldest entry
+		{id: 5, want: true},
+		{id: 6, want: false},
+	}
+	for i, s := range steps {
+		if got := w.Add(s.id); got != s.want {
+			t.Errorf("step %d: Add(%d) = %v, want %v", i, s.id, got, s.want)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+// Package health runs dependency checks for the readiness and startup probes.
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+)
+
+// CheckFunc reports whether a dependency is usable.
+type CheckFunc func(ctx context.Context) error
+
+type check struct {
+	name     string
+	required bool
+	fn       CheckFunc
+}
+
+// Checker holds the registered checks. A failing required check makes the
+// service unavailable; a failing optional one only degrades it.
+type Checker struct {
+	timeout time.Duration
+	checks  []check
+	started atomic.Bool
+}
+
+func New(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Add registers a check. It is not safe to call once probes are served.
+func (c *Checker) Add(name string, required bool, fn CheckFunc) {
+	c.checks = append(c.checks, check{name: name, required: required, fn: fn})
+}
+
+// MarkStarted flips the startup probe once initialisation has finished.
+func (c *Checker) MarkStarted() {
+	c.started.Store(true)
+}
+
+func (c *Checker) Started() bool {
+	return c.started.Load()
+}
+
+// Check runs all checks concurrently, each bounded by the checker timeout.
+func (c *Checker) Check(ctx context.Context) models.HealthReport {
+	report := models.HealthReport{
+		Status: models.HealthStatusOK,
+		Checks: make(map[string]models.HealthCheck, len(c.checks)),
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, ch := range c.checks {
+		wg.Add(1)
+		go func(ch check) {
+			defer wg.Done()
+			result := c.run(ctx, ch)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[ch.name] = result
+		}(ch)
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status == models.HealthStatusOK {
+			continue
+		}
+		if result.Required {
+			report.Status = models.HealthStatusUnavailable
+			break
+		}
+		report.Status = models.HealthStatusDegraded
+	}
+
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, ch check) models.HealthCheck {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := ch.fn(ctx)
+
+	result := models.HealthCheck{
+		Status:     models.HealthStatusOK,
+		Required:   ch.required,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		result.Status = models.HealthStatusUnavailable
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/service"
+	"github.com/2pizzzza/TestTask/internal/utils"
+)
+
+type EnrichmentCache interface {
+	Invalidate(ctx context.Context, groupName, songName string) error
+	Purge(ctx context.Context) error
+}
+
+type Enricher interface {
+	ReEnrich(ctx context.Context, opts models.EnrichOptions, cp service.Checkpoint) (models.EnrichSummary, error)
+}
+
+type AdminHandlers struct {
+	EnrichmentCache EnrichmentCache
+	Enricher        Enricher
+
+	mu        sync.Mutex
+	enrichJob models.EnrichJobStatus
+	cancelJob context.CancelFunc
+	jobs      sync.WaitGroup
+}
+
+func NewAdmin(cache EnrichmentCache, enricher Enricher) *AdminHandlers {
+	return &AdminHandlers{EnrichmentCache: cache, Enricher: enricher}
+}
+
+// InvalidateEnrichmentCache godoc
+// @Summary Invalidate enrichment cache
+// @Description Drop the cached enrichment result for a song, or the whole cache when no song is given
+// @Tags admin
+// @Produce json
+// @Param group_name query string false "Group name"
+// @Param song_name query string false "Song title"
+// @Success 200 {object} models.SongCreateResponse "Cache invalidated"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 401 {object} models.ErrorResponse "Missing or invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the required scope"
+// @Security BearerAuth
+// @Router /admin/enrichment/cache [delete]
+func (h *AdminHandlers) InvalidateEnrichmentCacheHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: "Method not allowed"}, http.StatusMethodNotAllowed)
+		return
+	}
+
+	groupName := r.URL.Query().Get("group_name")
+	songName := r.URL.Query().Get("song_name")
+
+	if groupName == "" && songName == "" {
+		if err := h.EnrichmentCache.Purge(r.Context()); err != nil {
+			utils.WriteResponseBody(w, models.ErrorResponse{Message: "Failed to purge enrichment cache"}, http.StatusInternalServerError)
+			return
+		}
+		utils.WriteResponseBody(w, models.SongCreateResponse{Message: "Enrichment cache purged"}, http.StatusOK)
+		return
+	}
+
+	if groupName == "" || songName == "" {
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: "Both group_name and song_name are required"}, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.EnrichmentCache.Invalidate(r.Context(), groupName, songName); err != nil {
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: "Failed to invalidate enrichment cache"}, http.StatusInternalServerError)
+		return
+	}
+
+	utils.WriteResponseBody(w, models.SongCreateResponse{Message: "Enrichment cache entry invalidated"}, http.StatusOK)
+}
+
+// ReEnrich godoc
+// @Summary Re-enrich the catalog
+// @Description POST starts a background run filling missing release dates, links and lyrics; GET reports the status of the last run
+// @Tags admin
+// @Produce json
+// @Param dry_run query bool false "Only report what would be filled"
+// @Param concurrency query int false "Parallel provider calls"
+// @Param rate query number false "Provider calls per second"
+// @Success 200 {object} models.EnrichJobStatus "Current run status"
+// @Success 202 {object} models.EnrichJobStatus "Run started"
+// @Failure 409 {object} models.ErrorResponse "A run is already in progress"
+// @Failure 401 {object} models.ErrorResponse "Missing or invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the required scope"
+// @Security BearerAuth
+// @Router /admin/enrich [post]
+// @Router /admin/enrich [get]
+func (h *AdminHandlers) ReEnrichHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.mu.Lock()
+		status := h.enrichJob
+		h.mu.Unlock()
+		utils.WriteResponseBody(w, status, http.StatusOK)
+		return
+	case http.MethodPost:
+	default:
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: "Method not allowed"}, http.StatusMethodNotAllowed)
+		return
+	}
+
+	opts := models.EnrichOptions{Concurrency: 4, Rate: 2}
+	if v, err := strconv.ParseBool(r.URL.Query().Get("dry_run")); err == nil {
+		opts.DryRun = v
+	}
+	if v, err := strconv.Atoi(r.URL.Query().Get("concurrency")); err == nil && v > 0 {
+		opts.Concurrency = v
+	}
+	if v, err := strconv.ParseFloat(r.URL.Query().Get("rate"), 64); err == nil && v > 0 {
+		opts.Rate = v
+	}
+
+	h.mu.Lock()
+	if h.enrichJob.Running {
+		h.mu.Unlock()
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: "Re-enrichment is already running"}, http.StatusConflict)
+		return
+	}
+	h.enrichJob = models.EnrichJobStatus{Running: true, StartedAt: time.Now()}
+	status := h.enrichJob
+	ctx, cancel := context.WithCancel(context.Background())
+	h.cancelJob = cancel
+	h.jobs.Add(1)
+	h.mu.Unlock()
+
+	go func() {
+		defer h.jobs.Done()
+		defer cancel()
+		summary, err := h.Enricher.ReEnrich(ctx, opts, nil)
+
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.cancelJob = nil
+		h.enrichJob.Running = false
+		h.enrichJob.FinishedAt = time.Now()
+		h.enrichJob.Summary = &summary
+		if err != nil {
+			h.enrichJob.Error = err.Error()
+		}
+	}()
+
+	utils.WriteResponseBody(w, status, http.StatusAccepted)
+}
+
+// Shutdown stops a running re-enrichment job and waits for it to return.
+func (h *AdminHandlers) Shutdown() {
+	h.mu.Lock()
+	if h.cancelJob != nil {
+		h.cancelJob()
+	}
+	h.mu.Unlock()
+
+	h.jobs.Wait()
+}
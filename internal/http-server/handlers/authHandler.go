@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/service"
+	"github.com/2pizzzza/TestTask/internal/storage"
+	"github.com/2pizzzza/TestTask/internal/utils"
+)
+
+type AuthHandlers struct {
+	AuthService service.AuthService
+	Timeout     time.Duration
+}
+
+func NewAuth(authService service.AuthService, timeout time.Duration) *AuthHandlers {
+	return &AuthHandlers{AuthService: authService, Timeout: timeout}
+}
+
+func writeAuthError(w http.ResponseWriter, ctx context.Context, err error, fallback string) {
+	if writeContextError(w, ctx, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, service.ErrInvalidUser):
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+	case errors.Is(err, storage.ErrUserExists):
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: "Username is taken"}, http.StatusConflict)
+	case errors.Is(err, service.ErrRegistrationClosed):
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: "Registration is closed"}, http.StatusForbidden)
+	case errors.Is(err, service.ErrInvalidCredentials):
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: "Invalid username or password"}, http.StatusUnauthorized)
+	case errors.Is(err, service.ErrInvalidToken):
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: "Invalid or expired refresh token"}, http.StatusUnauthorized)
+	default:
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: fallback}, http.StatusInternalServerError)
+	}
+}
+
+// Register godoc
+// @Summary Register a user
+// @Description Create a viewer account. Usernames are case-insensitive and 3 to 64 characters long; passwords are 8 to 72 bytes long. Editors, moderators and admins are appointed with songlib users.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param credentials body models.CredentialsReq true "Username and password"
+// @Success 201 {object} models.User "User created"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 403 {object} models.ErrorResponse "Registration is closed"
+// @Failure 409 {object} models.ErrorResponse "Username is taken"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /auth/register [post]
+func (h *AuthHandlers) RegisterHandler(w http.ResponseWriter, r *http.Request) {
+	var req models.CredentialsReq
+	if err := utils.ReadRequestBody(r, &req); err != nil {
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: "Invalid request body"}, http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := withTimeout(r, h.Timeout)
+	defer cancel()
+
+	user, err := h.AuthService.Register(ctx, req)
+	if err != nil {
+		writeAuthError(w, ctx, err, "Failed to register user")
+		return
+	}
+
+	utils.WriteResponseBody(w, user, http.StatusCreated)
+}
+
+// Login godoc
+// @Summary Log in
+// @Description Exchange a username and password for a short-lived access token and a refresh token. Send the access token as "Authorization: Bearer <token>".
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param credentials body models.CredentialsReq true "Username and password"
+// @Success 200 {object} models.TokenResponse "Tokens"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 401 {object} models.ErrorResponse "Invalid username or password"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /auth/login [post]
+func (h *AuthHandlers) LoginHandler(w http.ResponseWriter, r *http.Request) {
+	var req models.CredentialsReq
+	if err := utils.ReadRequestBody(r, &req); err != nil {
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: "Invalid request body"}, http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := withTimeout(r, h.Timeout)
+	defer cancel()
+
+	tokens, err := h.AuthService.Login(ctx, req)
+	if err != nil {
+		writeAuthError(w, ctx, err, "Failed to log in")
+		return
+	}
+
+	utils.WriteResponseBody(w, tokens, http.StatusOK)
+}
+
+// Refresh godoc
+// @Summary Refresh tokens
+// @Description Exchange a refresh token for a new access and refresh token. Each refresh token works once.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param token body models.RefreshReq true "Refresh token"
+// @Success 200 {object} models.TokenResponse "Tokens"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 401 {object} models.ErrorResponse "Invalid or expired refresh token"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /auth/refresh [post]
+func (h *AuthHandlers) RefreshHandler(w http.ResponseWriter, r *http.Request) {
+	var req models.RefreshReq
+	if err := utils.ReadRequestBody(r, &req); err != nil || req.RefreshToken == "" {
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: "Invalid request body"}, http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := withTimeout(r, h.Timeout)
+	defer cancel()
+
+	tokens, err := h.AuthService.Refresh(ctx, req.RefreshToken)
+	if err != nil {
+		writeAuthError(w, ctx, err, "Failed to refresh tokens")
+		return
+	}
+
+	utils.WriteResponseBody(w, tokens, http.StatusOK)
+}
+
+// Logout godoc
+// @Summary Log out
+// @Description Revoke a refresh token. Access tokens already issued stay valid until they expire.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param token body models.RefreshReq true "Refresh token"
+// @Success 200 {object} models.SongCreateResponse "Logged out"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 401 {object} models.ErrorResponse "Invalid or expired refresh token"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /auth/logout [post]
+func (h *AuthHandlers) LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	var req models.RefreshReq
+	if err := utils.ReadRequestBody(r, &req); err != nil || req.RefreshToken == "" {
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: "Invalid request body"}, http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := withTimeout(r, h.Timeout)
+	defer cancel()
+
+	if err := h.AuthService.Logout(ctx, req.RefreshToken); err != nil {
+		writeAuthError(w, ctx, err, "Failed to log out")
+		return
+	}
+
+	utils.WriteResponseBody(w, models.SongCreateResponse{Message: "Logged out"}, http.StatusOK)
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/utils"
+)
+
+// StatusClientClosedRequest is the non-standard status used (as in nginx)
+// when the client went away before the response was ready.
+const StatusClientClosedRequest = 499
+
+// withTimeout derives the context for the service call of a request. The
+// request context is cancelled when the client disconnects, so database
+// work stops with it.
+func withTimeout(r *http.Request, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(r.Context())
+	}
+	return context.WithTimeout(r.Context(), timeout)
+}
+
+// writeContextError answers a request whose context ended before the work
+// did. It returns false when err is not caused by the context.
+func writeContextError(w http.ResponseWriter, ctx context.Context, err error) bool {
+	switch {
+	case errors.Is(ctx.Err(), context.Canceled) || errors.Is(err, context.Canceled):
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: "Request cancelled"}, StatusClientClosedRequest)
+	case errors.Is(ctx.Err(), context.DeadlineExceeded) || errors.Is(err, context.DeadlineExceeded):
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: "Request timed out"}, http.StatusServiceUnavailable)
+	default:
+		return false
+	}
+	return true
+}
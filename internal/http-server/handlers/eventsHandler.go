@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/events"
+	"github.com/2pizzzza/TestTask/internal/lib/logger/sl"
+	"github.com/2pizzzza/TestTask/internal/utils"
+)
+
+// historyPageSize is how many events a resuming stream reads from the outbox
+// at a time.
+const historyPageSize = 500
+
+// sentWindow is how many event ids a stream remembers to avoid sending an
+// event twice where the history, the feed backlog and the live channel
+// overlap. The overlap is bounded by EVENTS_FEED_BUFFER.
+const sentWindow = 4096
+
+// EventFeed is the live source of song events.
+type EventFeed interface {
+	Subscribe(buffer int) (<-chan events.Event, func())
+	Since(lastId int64) ([]events.Event, bool)
+}
+
+// EventHistory serves events that already left the in-memory feed.
+type EventHistory interface {
+	PublishedSince(ctx context.Context, lastId int64, limit int) ([]events.Event, error)
+}
+
+type EventHandlers struct {
+	log       *slog.Logger
+	Feed      EventFeed
+	History   EventHistory
+	Heartbeat time.Duration
+
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func NewEvents(log *slog.Logger, feed EventFeed, history EventHistory, heartbeat time.Duration) *EventHandlers {
+	return &EventHandlers{log: log, Feed: feed, History: history, Heartbeat: heartbeat, done: make(chan struct{})}
+}
+
+// Shutdown ends all open streams so that http.Server.Shutdown can drain
+// their connections instead of waiting for the timeout.
+func (h *EventHandlers) Shutdown() {
+	h.stopOnce.Do(func() { close(h.done) })
+}
+
+// SongEvents godoc
+// @Summary Stream song changes
+// @Description Server-Sent Events stream of song.created, song.updated, song.deleted and lyrics.changed events. Send Last-Event-ID (or last_event_id) to resume.
+// @Tags songs
+// @Produce text/event-stream
+// @Param group_name query string false "Only events for this group"
+// @Param last_event_id query int false "Resume after this event id"
+// @Success 200 {string} string "Event stream"
+// @Failure 500 {object} models.ErrorResponse "Streaming unsupported"
+// @Failure 401 {object} models.ErrorResponse "Missing or invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the required scope"
+// @Security BearerAuth
+// @Router /songs/events [get]
+func (h *EventHandlers) SongEventsHandler(w http.ResponseWriter, r *http.Request) {
+	const op = "handlers.events.SongEvents"
+
+	log := sl.FromContext(r.Context(), h.log).With(
+		slog.String("op", op),
+	)
+
+	if !canFlush(w) {
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: "Streaming unsupported"}, http.StatusInternalServerError)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	// Streams outlive the server write timeout; keep the connection open.
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	lastId := parseLastEventID(r)
+	group := strings.ToLower(r.URL.Query().Get("group_name"))
+
+	live, unsubscribe := h.Feed.Subscribe(256)
+	defer unsubscribe()
+
+	var (
+		backlog  []events.Event
+		complete = true
+	)
+	if lastId > 0 {
+		backlog, complete = h.Feed.Since(lastId)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	// Ids are assigned when an event is written, not when it commits, so a
+	// lower id can arrive after a higher one; a high-water mark would drop
+	// it. The stream remembers the ids it sent instead.
+	sent := events.NewWindow(sentWindow)
+	send := func(event events.Event) error {
+		if !matchesGroup(event, group) || !sent.Add(event.Id) {
+			return nil
+		}
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Id, event.Type, data); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+
+	// Events the feed no longer holds are read from the outbox page by
+	// page; the feed backlog and the live channel then continue where the
+	// history ends, with send skipping whatever was already written.
+	if !complete && h.History != nil {
+		for cursor := lastId; ; {
+			page, err := h.History.PublishedSince(r.Context(), cursor, historyPageSize)
+			if err != nil {
+				// The client reconnects and resumes from the last event it got.
+				log.Error("failed to load event history", sl.Err(err))
+				return
+			}
+			for _, event := range page {
+				if err := send(event); err != nil {
+					return
+				}
+			}
+			if len(page) < historyPageSize {
+				break
+			}
+			cursor = page[len(page)-1].Id
+		}
+	}
+
+	for _, event := range backlog {
+		if err := send(event); err != nil {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(h.Heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-h.done:
+			return
+		case event, ok := <-live:
+			if !ok {
+				return
+			}
+			if err := send(event); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// canFlush reports whether w, or a writer it wraps, can flush; this is what
+// http.ResponseController needs to stream.
+func canFlush(w http.ResponseWriter) bool {
+	for {
+		switch t := w.(type) {
+		case http.Flusher:
+			return true
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = t.Unwrap()
+		default:
+			return false
+		}
+	}
+}
+
+func parseLastEventID(r *http.Request) int64 {
+	raw := r.Header.Get("Last-Event-ID")
+	if raw == "" {
+		raw = r.URL.Query().Get("last_event_id")
+	}
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || id < 0 {
+		return 0
+	}
+	return id
+}
+
+func matchesGroup(event events.Event, group string) bool {
+	if group == "" {
+		return true
+	}
+	var payload events.SongPayload
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		return false
+	}
+	return strings.ToLower(payload.GroupName) == group
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/2pizzzza/TestTask/internal/events"
+)
+
+// history serves ids 1..last from the outbox.
+type history struct {
+	last  int64
+	calls int
+}
+
+func (h *history) PublishedSince(_ context.Context, lastId int64, limit int) ([]events.Event, error) {
+	h.calls++
+	var page []events.Event
+	for id := lastId + 1; id <= h.last && len(page) < limit; id++ {
+		page = append(page, events.Event{Id: id, Type: events.SongUpdated})
+	}
+	return page, nil
+}
+
+func TestSongEventsPagesThroughHistory(t *testing.T) {
+	const total = 2*historyPageSize + 10
+
+	// The feed only holds the newest events, so resuming from 1 needs the
+	// outbox for everything older.
+	feed := events.NewMemoryPublisher(5)
+	for id := int64(total - 4); id <= total; id++ {
+		if err := feed.Publish(context.Background(), events.Event{Id: id, Type: events.SongUpdated}); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+	hist := &history{last: total}
+
+	h := NewEvents(slog.New(slog.NewTextHandler(io.Discard, nil)), feed, hist, time.Minute)
+	// With the handler already shut down it writes the backlog and returns.
+	h.Shutdown()
+
+	req := httptest.NewRequest(http.MethodGet, "/songs/events", nil)
+	req.Header.Set("Last-Event-ID", "1")
+	rec := httptest.NewRecorder()
+	h.SongEventsHandler(rec, req)
+
+	ids := streamedIds(rec.Body.String())
+	if len(ids) != total-1 || ids[0] != "2" || ids[len(ids)-1] != strconv.Itoa(total) {
+		t.Fatalf("streamed %d events (%v ... %v), want 2..%d once each", len(ids), ids[:1], ids[len(ids)-1:], total)
+	}
+	if hist.calls != 3 {
+		t.Errorf("PublishedSince called %d times, want 3 pages", hist.calls)
+	}
+}
+
+// replayFeed serves a fixed backlog, then the live events, then ends the
+// stream by closing the channel.
+type replayFeed struct {
+	backlog []events.Event
+	live    []events.Event
+}
+
+func (f *replayFeed) Subscribe(int) (<-chan events.Event, func()) {
+	ch := make(chan events.Event, len(f.live))
+	for _, event := range f.live {
+		ch <- event
+	}
+	close(ch)
+	return ch, func() {}
+}
+
+func (f *replayFeed) Since(int64) ([]events.Event, bool) {
+	return f.backlog, true
+}
+
+func streamedIds(body string) []string {
+	ids := []string{}
+	for _, line := range strings.Split(body, "\n") {
+		if id, ok := strings.CutPrefix(line, "id: "); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func TestSongEventsKeepsLateEvents(t *testing.T) {
+	// Event 2 commits after 5 was published; 5 shows up in the backlog and
+	// again on the live channel.
+	feed := &replayFeed{
+		backlog: []events.Event{{Id: 4}, {Id: 5}},
+		live:    []events.Event{{Id: 5}, {Id: 2}, {Id: 6}},
+	}
+	h := NewEvents(slog.New(slog.NewTextHandler(io.Discard, nil)), feed, nil, time.Minute)
+
+	req := httptest.NewRequest(http.MethodGet, "/songs/events", nil)
+	req.Header.Set("Last-Event-ID", "3")
+	rec := httptest.NewRecorder()
+	h.SongEventsHandler(rec, req)
+
+	if got := strings.Join(streamedIds(rec.Body.String()), ","); got != "4,5,2,6" {
+		t.Errorf("streamed ids %s, want 4,5,2,6", got)
+	}
+}
+
+// plainWriter cannot flush.
+type plainWriter struct {
+	rec *httptest.ResponseRecorder
+}
+
+func (w plainWriter) Header() http.Header         { return w.rec.Header() }
+func (w plainWriter) Write(b []byte) (int, error) { return w.rec.Write(b) }
+func (w plainWriter) WriteHeader(code int)        { w.rec.WriteHeader(code) }
+
+func TestSongEventsWithoutFlushSupport(t *testing.T) {
+	h := NewEvents(slog.New(slog.NewTextHandler(io.Discard, nil)), events.NewMemoryPublisher(1), nil, time.Minute)
+
+	rec := httptest.NewRecorder()
+	h.SongEventsHandler(plainWriter{rec: rec}, httptest.NewRequest(http.MethodGet, "/songs/events", nil))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct == "text/event-stream" {
+		t.Errorf("Content-Type = %s on an error response", ct)
+	}
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/health"
+	"github.com/2pizzzza/TestTask/internal/utils"
+)
+
+type HealthHandlers struct {
+	Checker *health.Checker
+}
+
+func NewHealth(checker *health.Checker) *HealthHandlers {
+	return &HealthHandlers{Checker: checker}
+}
+
+// Liveness godoc
+// @Summary Liveness probe
+// @Description Reports that the process is running. It does not check dependencies.
+// @Tags health
+// @Produce json
+// @Success 200 {object} models.HealthReport "Alive"
+// @Router /healthz [get]
+func (h *HealthHandlers) LivenessHandler(w http.ResponseWriter, r *http.Request) {
+	utils.WriteResponseBody(w, models.HealthReport{Status: models.HealthStatusOK}, http.StatusOK)
+}
+
+// Readiness godoc
+// @Summary Readiness probe
+// @Description Checks the database, the schema version and the enrichment provider. The provider is reported but does not make the service unready.
+// @Tags health
+// @Produce json
+// @Success 200 {object} models.HealthReport "Ready, possibly degraded"
+// @Failure 503 {object} models.HealthReport "Not ready"
+// @Router /readyz [get]
+func (h *HealthHandlers) ReadinessHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.Checker.Started() {
+		utils.WriteResponseBody(w, models.HealthReport{Status: models.HealthStatusStarting}, http.StatusServiceUnavailable)
+		return
+	}
+
+	report := h.Checker.Check(r.Context())
+
+	status := http.StatusOK
+	if report.Status == models.HealthStatusUnavailable {
+		status = http.StatusServiceUnavailable
+	}
+
+	utils.WriteResponseBody(w, report, status)
+}
+
+// Startup godoc
+// @Summary Startup probe
+// @Description Succeeds once the server has connected to its dependencies and started its background workers.
+// @Tags health
+// @Produce json
+// @Success 200 {object} models.HealthReport "Started"
+// @Failure 503 {object} models.HealthReport "Still starting"
+// @Router /startupz [get]
+func (h *HealthHandlers) StartupHandler(w http.ResponseWriter, r *http.Request) {
+	if !h.Checker.Started() {
+		utils.WriteResponseBody(w, models.HealthReport{Status: models.HealthStatusStarting}, http.StatusServiceUnavailable)
+		return
+	}
+
+	utils.WriteResponseBody(w, models.HealthReport{Status: models.HealthStatusOK}, http.StatusOK)
+}
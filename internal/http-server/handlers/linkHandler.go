@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/service"
+	"github.com/2pizzzza/TestTask/internal/storage"
+	"github.com/2pizzzza/TestTask/internal/utils"
+)
+
+func writeLinkError(w http.ResponseWriter, ctx context.Context, err error, fallback string) {
+	if writeContextError(w, ctx, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, service.ErrInvalidLink):
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+	case errors.Is(err, storage.ErrSongNotFound):
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: "Song not found"}, http.StatusNotFound)
+	case errors.Is(err, storage.ErrLinkNotFound):
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: "Link not found"}, http.StatusNotFound)
+	case errors.Is(err, storage.ErrLinkExists):
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: "Link already exists"}, http.StatusConflict)
+	default:
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: fallback}, http.StatusInternalServerError)
+	}
+}
+
+func pathID(r *http.Request, name string) (int64, error) {
+	return strconv.ParseInt(r.PathValue(name), 10, 64)
+}
+
+// GetSongLinks godoc
+// @Summary List song links
+// @Description List the external links of a song
+// @Tags links
+// @Produce json
+// @Param id path int true "Song ID"
+// @Success 200 {array} models.SongLink "Song links"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 404 {object} models.ErrorResponse "Song not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 401 {object} models.ErrorResponse "Missing or invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the required scope"
+// @Security BearerAuth
+// @Router /songs/{id}/links [get]
+func (h *Handlers) GetSongLinksHandler(w http.ResponseWriter, r *http.Request) {
+	songId, err := pathID(r, "id")
+	if err != nil {
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: "Invalid song ID"}, http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := withTimeout(r, h.Timeout)
+	defer cancel()
+
+	links, err := h.LinkService.GetSongLinks(ctx, songId)
+	if err != nil {
+		writeLinkError(w, ctx, err, "Failed to get links")
+		return
+	}
+
+	utils.WriteResponseBody(w, links, http.StatusOK)
+}
+
+// CreateSongLink godoc
+// @Summary Add a song link
+// @Description Add an external link for a platform (spotify, youtube, apple_music, bandcamp, other)
+// @Tags links
+// @Accept json
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param link body models.SongLinkReq true "Link data"
+// @Success 201 {object} models.SongLink "Link created"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 404 {object} models.ErrorResponse "Song not found"
+// @Failure 409 {object} models.ErrorResponse "Link already exists"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 401 {object} models.ErrorResponse "Missing or invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the required scope"
+// @Security BearerAuth
+// @Router /songs/{id}/links [post]
+func (h *Handlers) CreateSongLinkHandler(w http.ResponseWriter, r *http.Request) {
+	songId, err := pathID(r, "id")
+	if err != nil {
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: "Invalid song ID"}, http.StatusBadRequest)
+		return
+	}
+
+	var req models.SongLinkReq
+	if err := utils.ReadRequestBody(r, &req); err != nil {
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: "Invalid request body"}, http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := withTimeout(r, h.Timeout)
+	defer cancel()
+
+	link, err := h.LinkService.AddSongLink(ctx, songId, req)
+	if err != nil {
+		writeLinkError(w, ctx, err, "Failed to create link")
+		return
+	}
+
+	utils.WriteResponseBody(w, link, http.StatusCreated)
+}
+
+// UpdateSongLink godoc
+// @Summary Update a song link
+// @Description Replace the platform and URL of a song link
+// @Tags links
+// @Accept json
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param linkId path int true "Link ID"
+// @Param link body models.SongLinkReq true "Link data"
+// @Success 200 {object} models.SongLink "Link updated"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 404 {object} models.ErrorResponse "Link not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 401 {object} models.ErrorResponse "Missing or invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the required scope"
+// @Security BearerAuth
+// @Router /songs/{id}/links/{linkId} [put]
+func (h *Handlers) UpdateSongLinkHandler(w http.ResponseWriter, r *http.Request) {
+	songId, err := pathID(r, "id")
+	if err != nil {
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: "Invalid song ID"}, http.StatusBadRequest)
+		return
+	}
+	linkId, err := pathID(r, "linkId")
+	if err != nil {
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: "Invalid link ID"}, http.StatusBadRequest)
+		return
+	}
+
+	var req models.SongLinkReq
+	if err := utils.ReadRequestBody(r, &req); err != nil {
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: "Invalid request body"}, http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := withTimeout(r, h.Timeout)
+	defer cancel()
+
+	link, err := h.LinkService.UpdateSongLink(ctx, songId, linkId, req)
+	if err != nil {
+		writeLinkError(w, ctx, err, "Failed to update link")
+		return
+	}
+
+	utils.WriteResponseBody(w, link, http.StatusOK)
+}
+
+// DeleteSongLink godoc
+// @Summary Delete a song link
+// @Description Remove an external link from a song
+// @Tags links
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param linkId path int true "Link ID"
+// @Success 200 {object} models.SongCreateResponse "Link deleted"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 404 {object} models.ErrorResponse "Link not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 401 {object} models.ErrorResponse "Missing or invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the required scope"
+// @Security BearerAuth
+// @Router /songs/{id}/links/{linkId} [delete]
+func (h *Handlers) DeleteSongLinkHandler(w http.ResponseWriter, r *http.Request) {
+	songId, err := pathID(r, "id")
+	if err != nil {
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: "Invalid song ID"}, http.StatusBadRequest)
+		return
+	}
+	linkId, err := pathID(r, "linkId")
+	if err != nil {
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: "Invalid link ID"}, http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := withTimeout(r, h.Timeout)
+	defer cancel()
+
+	if err := h.LinkService.DeleteSongLink(ctx, songId, linkId); err != nil {
+		writeLinkError(w, ctx, err, "Failed to delete link")
+		return
+	}
+
+	utils.WriteResponseBody(w, models.SongCreateResponse{Message: "Link deleted"}, http.StatusOK)
+}
+
+// GetBrokenLinks godoc
+// @Summary List broken links
+// @Description List stored song links that failed their last check
+// @Tags links
+// @Produce json
+// @Param limit query int false "Number of links to return"
+// @Param offset query int false "Offset for pagination"
+// @Success 200 {array} models.SongLink "Broken links"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 401 {object} models.ErrorResponse "Missing or invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the required scope"
+// @Security BearerAuth
+// @Router /links/broken [get]
+func (h *Handlers) GetBrokenLinksHandler(w http.ResponseWriter, r *http.Request) {
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 50
+	}
+	offset, err := strconv.Atoi(r.URL.Query().Get("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	ctx, cancel := withTimeout(r, h.Timeout)
+	defer cancel()
+
+	links, err := h.LinkService.GetBrokenLinks(ctx, limit, offset)
+	if err != nil {
+		writeLinkError(w, ctx, err, "Failed to get broken links")
+		return
+	}
+
+	utils.WriteResponseBody(w, links, http.StatusOK)
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/storage"
+	"github.com/2pizzzza/TestTask/internal/utils"
+)
+
+func writeLyricsError(w http.ResponseWriter, ctx context.Context, err error, fallback string) {
+	if writeContextError(w, ctx, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, storage.ErrSongNotFound):
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: "Song not found"}, http.StatusNotFound)
+	case errors.Is(err, storage.ErrRevisionNotFound):
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: "Lyrics revision not found"}, http.StatusNotFound)
+	default:
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: fallback}, http.StatusInternalServerError)
+	}
+}
+
+// UpdateSongLyrics godoc
+// @Summary Edit song lyrics
+// @Description Replace the lyrics of a song by hand; an empty text clears them. The previous lyrics are kept as a revision. Needs the songs:write scope.
+// @Tags lyrics
+// @Accept json
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param lyrics body models.LyricsUpdateReq true "New lyrics"
+// @Success 200 {object} models.Song "Lyrics updated"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 404 {object} models.ErrorResponse "Song not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 401 {object} models.ErrorResponse "Missing or invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the required scope"
+// @Security BearerAuth
+// @Router /songs/{id}/lyrics [put]
+func (h *Handlers) UpdateSongLyricsHandler(w http.ResponseWriter, r *http.Request) {
+	songId, err := pathID(r, "id")
+	if err != nil {
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: "Invalid song ID"}, http.StatusBadRequest)
+		return
+	}
+
+	var req models.LyricsUpdateReq
+	if err := utils.ReadRequestBody(r, &req); err != nil {
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: "Invalid request body"}, http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := withTimeout(r, h.Timeout)
+	defer cancel()
+
+	song, err := h.SongService.SetLyrics(ctx, songId, req.Lyrics)
+	if err != nil {
+		writeLyricsError(w, ctx, err, "Failed to update lyrics")
+		return
+	}
+
+	utils.WriteResponseBody(w, song, http.StatusOK)
+}
+
+// GetLyricsRevisions godoc
+// @Summary List lyrics revisions
+// @Description List the lyrics a song had before each change, newest first
+// @Tags lyrics
+// @Produce json
+// @Param id path int true "Song ID"
+// @Success 200 {array} models.LyricsRevision "Lyrics revisions"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 404 {object} models.ErrorResponse "Song not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 401 {object} models.ErrorResponse "Missing or invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the required scope"
+// @Security BearerAuth
+// @Router /songs/{id}/lyrics/revisions [get]
+func (h *Handlers) GetLyricsRevisionsHandler(w http.ResponseWriter, r *http.Request) {
+	songId, err := pathID(r, "id")
+	if err != nil {
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: "Invalid song ID"}, http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := withTimeout(r, h.Timeout)
+	defer cancel()
+
+	revisions, err := h.SongService.LyricsRevisions(ctx, songId)
+	if err != nil {
+		writeLyricsError(w, ctx, err, "Failed to get lyrics revisions")
+		return
+	}
+
+	utils.WriteResponseBody(w, revisions, http.StatusOK)
+}
+
+// RollbackLyrics godoc
+// @Summary Roll back song lyrics
+// @Description Restore the lyrics of a revision. The replaced lyrics become a new revision, so the rollback can be undone. Needs the songs:moderate scope.
+// @Tags lyrics
+// @Accept json
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param rollback body models.LyricsRollbackReq true "Revision to restore"
+// @Success 200 {object} models.Song "Lyrics rolled back"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 404 {object} models.ErrorResponse "Song or revision not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 401 {object} models.ErrorResponse "Missing or invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the required scope"
+// @Security BearerAuth
+// @Router /songs/{id}/lyrics/rollback [post]
+func (h *Handlers) RollbackLyricsHandler(w http.ResponseWriter, r *http.Request) {
+	songId, err := pathID(r, "id")
+	if err != nil {
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: "Invalid song ID"}, http.StatusBadRequest)
+		return
+	}
+
+	var req models.LyricsRollbackReq
+	if err := utils.ReadRequestBody(r, &req); err != nil || req.RevisionId <= 0 {
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: "Invalid request body"}, http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := withTimeout(r, h.Timeout)
+	defer cancel()
+
+	song, err := h.SongService.RollbackLyrics(ctx, songId, req.RevisionId)
+	if err != nil {
+		writeLyricsError(w, ctx, err, "Failed to roll back lyrics")
+		return
+	}
+
+	utils.WriteResponseBody(w, song, http.StatusOK)
+}
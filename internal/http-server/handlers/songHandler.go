@@ -1,23 +1,24 @@
 package handlers
 
 import (
-	"context"
 	"errors"
 	"github.com/2pizzzza/TestTask/internal/domain/models"
 	"github.com/2pizzzza/TestTask/internal/service"
 	"github.com/2pizzzza/TestTask/internal/storage"
 	"github.com/2pizzzza/TestTask/internal/utils"
-	"log"
 	"net/http"
 	"strconv"
+	"time"
 )
 
 type Handlers struct {
 	SongService service.SongService
+	LinkService service.LinkService
+	Timeout     time.Duration
 }
 
-func New(songService service.SongService) *Handlers {
-	return &Handlers{SongService: songService}
+func New(songService service.SongService, linkService service.LinkService, timeout time.Duration) *Handlers {
+	return &Handlers{SongService: songService, LinkService: linkService, Timeout: timeout}
 }
 
 // CreateSong godoc
@@ -29,7 +30,11 @@ func New(songService service.SongService) *Handlers {
 // @Param song body models.SongCreateReq true "Song data"
 // @Success 201 {object} models.SongCreateResponse "Song created successfully"
 // @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 409 {object} models.ErrorResponse "Song already exists"
 // @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 401 {object} models.ErrorResponse "Missing or invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the required scope"
+// @Security BearerAuth
 // @Router /songs/create [post]
 func (h *Handlers) CreateSongHandler(w http.ResponseWriter, r *http.Request) {
 	var req models.SongCreateReq
@@ -43,8 +48,19 @@ func (h *Handlers) CreateSongHandler(w http.ResponseWriter, r *http.Request) {
 		SongName:  req.SongName,
 	}
 
-	msg, err := h.SongService.CreateSong(context.Background(), songCreateReq)
+	// The service bounds the storage calls itself, so a slow enrichment API
+	// does not eat into the database deadline.
+	ctx := r.Context()
+
+	msg, err := h.SongService.CreateSong(ctx, songCreateReq)
 	if err != nil {
+		if writeContextError(w, ctx, err) {
+			return
+		}
+		if errors.Is(err, storage.ErrSongExists) {
+			utils.WriteResponseBody(w, models.ErrorResponse{Message: "Song already exists"}, http.StatusConflict)
+			return
+		}
 		utils.WriteResponseBody(w, models.ErrorResponse{Message: "Failed to create song"}, http.StatusInternalServerError)
 		return
 	}
@@ -58,7 +74,7 @@ func (h *Handlers) CreateSongHandler(w http.ResponseWriter, r *http.Request) {
 
 // UpdateSong godoc
 // @Summary Update an existing song
-// @Description Update the details of a song by ID
+// @Description Update the group and title of a song by ID; an empty field keeps its current value
 // @Tags songs
 // @Accept json
 // @Produce json
@@ -68,10 +84,16 @@ func (h *Handlers) CreateSongHandler(w http.ResponseWriter, r *http.Request) {
 // @Failure 400 {object} models.ErrorResponse "Bad request"
 // @Failure 404 {object} models.ErrorResponse "Song not found"
 // @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 401 {object} models.ErrorResponse "Missing or invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the required scope"
+// @Security BearerAuth
 // @Router /songs/update [put]
 func (h *Handlers) UpdateSongHandler(w http.ResponseWriter, r *http.Request) {
 	var req models.SongUpdateReq
-	utils.ReadRequestBody(r, &req)
+	if err := utils.ReadRequestBody(r, &req); err != nil {
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: "Invalid request body"}, http.StatusBadRequest)
+		return
+	}
 
 	songUpdateReq := models.SongUpdateReq{
 		Id:           req.Id,
@@ -79,8 +101,18 @@ func (h *Handlers) UpdateSongHandler(w http.ResponseWriter, r *http.Request) {
 		NewSongName:  req.NewSongName,
 	}
 
-	song, err := h.SongService.UpdateSong(context.Background(), songUpdateReq)
+	ctx, cancel := withTimeout(r, h.Timeout)
+	defer cancel()
+
+	song, err := h.SongService.UpdateSong(ctx, songUpdateReq)
 	if err != nil {
+		if writeContextError(w, ctx, err) {
+			return
+		}
+		if errors.Is(err, storage.ErrSongNotFound) {
+			utils.WriteResponseBody(w, models.ErrorResponse{Message: "Song not found"}, http.StatusNotFound)
+			return
+		}
 		utils.WriteResponseBody(w, models.ErrorResponse{Message: "Failed to update song"}, http.StatusInternalServerError)
 		return
 	}
@@ -97,6 +129,9 @@ func (h *Handlers) UpdateSongHandler(w http.ResponseWriter, r *http.Request) {
 // @Success 200 {object} models.Song "Song found"
 // @Failure 404 {object} models.ErrorResponse "Song not found"
 // @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 401 {object} models.ErrorResponse "Missing or invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the required scope"
+// @Security BearerAuth
 // @Router /songs/info [get]
 func (h *Handlers) GetSongByIDHandler(w http.ResponseWriter, r *http.Request) {
 	idStr := r.URL.Query().Get("id")
@@ -111,8 +146,14 @@ func (h *Handlers) GetSongByIDHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	song, err := h.SongService.GetSongByID(context.Background(), id)
+	ctx, cancel := withTimeout(r, h.Timeout)
+	defer cancel()
+
+	song, err := h.SongService.GetSongByID(ctx, id)
 	if err != nil {
+		if writeContextError(w, ctx, err) {
+			return
+		}
 		if errors.Is(err, storage.ErrSongNotFound) {
 			utils.WriteResponseBody(w, models.ErrorResponse{Message: "Song not found"}, http.StatusNotFound)
 			return
@@ -126,17 +167,19 @@ func (h *Handlers) GetSongByIDHandler(w http.ResponseWriter, r *http.Request) {
 
 // DeleteSong godoc
 // @Summary Delete a song by ID
-// @Description Remove a song from the library by its ID
+// @Description Remove a song from the library by its ID. Needs the songs:moderate scope.
 // @Tags songs
 // @Produce json
 // @Param id query int true "Song ID"
 // @Success 200 {string} string "Successfully deleted song"
 // @Failure 404 {object} models.ErrorResponse "Song not found"
 // @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 401 {object} models.ErrorResponse "Missing or invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the required scope"
+// @Security BearerAuth
 // @Router /songs/delete [delete]
 func (h *Handlers) DeleteSongHandler(w http.ResponseWriter, r *http.Request) {
 	idStr := r.URL.Query().Get("id")
-	log.Print("asdsa", idStr)
 
 	if idStr == "" {
 		utils.WriteResponseBody(w, models.ErrorResponse{Message: "Missing song ID"}, http.StatusBadRequest)
@@ -149,8 +192,14 @@ func (h *Handlers) DeleteSongHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	msg, err := h.SongService.DeleteSong(context.Background(), id)
+	ctx, cancel := withTimeout(r, h.Timeout)
+	defer cancel()
+
+	msg, err := h.SongService.DeleteSong(ctx, id)
 	if err != nil {
+		if writeContextError(w, ctx, err) {
+			return
+		}
 		if errors.Is(err, storage.ErrSongNotFound) {
 			utils.WriteResponseBody(w, models.ErrorResponse{Message: "Song not found"}, http.StatusNotFound)
 			return
@@ -174,6 +223,9 @@ func (h *Handlers) DeleteSongHandler(w http.ResponseWriter, r *http.Request) {
 // @Param offset query int false "Offset for pagination"
 // @Success 200 {array} models.Song "List of songs"
 // @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 401 {object} models.ErrorResponse "Missing or invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the required scope"
+// @Security BearerAuth
 // @Router /songs [get]
 func (h *Handlers) GetAllSongsHandler(w http.ResponseWriter, r *http.Request) {
 	filter := models.SongFilter{
@@ -188,8 +240,14 @@ func (h *Handlers) GetAllSongsHandler(w http.ResponseWriter, r *http.Request) {
 	limit, _ := strconv.Atoi(limitStr)
 	offset, _ := strconv.Atoi(offsetStr)
 
-	songs, err := h.SongService.GetAllSong(context.Background(), filter, limit, offset)
+	ctx, cancel := withTimeout(r, h.Timeout)
+	defer cancel()
+
+	songs, err := h.SongService.GetAllSong(ctx, filter, limit, offset)
 	if err != nil {
+		if writeContextError(w, ctx, err) {
+			return
+		}
 		utils.WriteResponseBody(w, models.ErrorResponse{Message: "Failed to fetch songs"}, http.StatusInternalServerError)
 		return
 	}
@@ -210,6 +268,9 @@ func (h *Handlers) GetAllSongsHandler(w http.ResponseWriter, r *http.Request) {
 // @Failure 400 {object} models.ErrorResponse "Bad request"
 // @Failure 404 {object} models.ErrorResponse "Song not found"
 // @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 401 {object} models.ErrorResponse "Missing or invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the required scope"
+// @Security BearerAuth
 // @Router /songs/{id}/lyrics [get]
 func (h *Handlers) GetSongLyricsHandler(w http.ResponseWriter, r *http.Request) {
 	idStr := r.URL.Query().Get("id")
@@ -229,8 +290,14 @@ func (h *Handlers) GetSongLyricsHandler(w http.ResponseWriter, r *http.Request)
 		limit = 10
 	}
 
-	lyricsResp, err := h.SongService.GetLyricsByIDWithPagination(context.Background(), id, page, limit)
+	ctx, cancel := withTimeout(r, h.Timeout)
+	defer cancel()
+
+	lyricsResp, err := h.SongService.GetLyricsByIDWithPagination(ctx, id, page, limit)
 	if err != nil {
+		if writeContextError(w, ctx, err) {
+			return
+		}
 		if errors.Is(err, storage.ErrSongNotFound) {
 			utils.WriteResponseBody(w, models.ErrorResponse{Message: "Song not found"}, http.StatusNotFound)
 		} else {
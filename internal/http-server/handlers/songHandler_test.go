@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/2pizzzza/TestTask/internal/enrichment"
+	"github.com/2pizzzza/TestTask/internal/enrichstub"
+	"github.com/2pizzzza/TestTask/internal/service"
+	"github.com/2pizzzza/TestTask/internal/storage/memory"
+	"github.com/2pizzzza/TestTask/internal/utils"
+)
+
+func newSongHandlers(t *testing.T) *Handlers {
+	t.Helper()
+
+	stub := enrichstub.NewServer([]enrichstub.Fixture{{
+		Artist:   "Muse",
+		Song:     "Uprising",
+		Response: utils.TrackInfo{ReleaseDate: "07.09.2009", LyricsSource: "stub"},
+	}}, enrichstub.Options{Seed: 1})
+	t.Cleanup(stub.Close)
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	db := memory.New()
+	songs := service.New(*log, db, db, db, db, enrichment.NewClient(stub.URL+"/search"), time.Second)
+	return New(songs, nil, time.Second)
+}
+
+func TestSongHandlersStatusCodes(t *testing.T) {
+	h := newSongHandlers(t)
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		method  string
+		body    string
+		want    int
+	}{
+		{name: "create", handler: h.CreateSongHandler, method: http.MethodPost, body: `{"group_name":"Muse","song_name":"Uprising"}`, want: http.StatusCreated},
+		{name: "create duplicate", handler: h.CreateSongHandler, method: http.MethodPost, body: `{"group_name":"Muse","song_name":"Uprising"}`, want: http.StatusConflict},
+		{name: "update malformed body", handler: h.UpdateSongHandler, method: http.MethodPut, body: `{"id":`, want: http.StatusBadRequest},
+		{name: "update missing song", handler: h.UpdateSongHandler, method: http.MethodPut, body: `{"id":999,"new_song_name":"Resistance"}`, want: http.StatusNotFound},
+	}
+
+	// The cases run in order: the duplicate needs the first song.
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, "/songs", strings.NewReader(tt.body))
+		rec := httptest.NewRecorder()
+		tt.handler(rec, req)
+
+		if rec.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d (body %s)", tt.name, rec.Code, tt.want, rec.Body)
+		}
+	}
+}
+
+func TestLyricsHandlersStatusCodes(t *testing.T) {
+	h := newSongHandlers(t)
+
+	create := httptest.NewRecorder()
+	h.CreateSongHandler(create, httptest.NewRequest(http.MethodPost, "/songs/create", strings.NewReader(`{"group_name":"Muse","song_name":"Uprising"}`)))
+	if create.Code != http.StatusCreated {
+		t.Fatalf("create: status = %d (body %s)", create.Code, create.Body)
+	}
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		method  string
+		id      string
+		body    string
+		want    int
+	}{
+		{name: "edit", handler: h.UpdateSongLyricsHandler, method: http.MethodPut, id: "1", body: `{"lyrics":"Paranoia is in bloom"}`, want: http.StatusOK},
+		{name: "edit again", handler: h.UpdateSongLyricsHandler, method: http.MethodPut, id: "1", body: `{"lyrics":"vandalised"}`, want: http.StatusOK},
+		{name: "edit invalid id", handler: h.UpdateSongLyricsHandler, method: http.MethodPut, id: "x", body: `{"lyrics":""}`, want: http.StatusBadRequest},
+		{name: "edit malformed body", handler: h.UpdateSongLyricsHandler, method: http.MethodPut, id: "1", body: `{"lyrics":`, want: http.StatusBadRequest},
+		{name: "edit missing song", handler: h.UpdateSongLyricsHandler, method: http.MethodPut, id: "999", body: `{"lyrics":""}`, want: http.StatusNotFound},
+		{name: "revisions", handler: h.GetLyricsRevisionsHandler, method: http.MethodGet, id: "1", want: http.StatusOK},
+		{name: "revisions missing song", handler: h.GetLyricsRevisionsHandler, method: http.MethodGet, id: "999", want: http.StatusNotFound},
+		{name: "rollback", handler: h.RollbackLyricsHandler, method: http.MethodPost, id: "1", body: `{"revision_id":2}`, want: http.StatusOK},
+		{name: "rollback without revision", handler: h.RollbackLyricsHandler, method: http.MethodPost, id: "1", body: `{}`, want: http.StatusBadRequest},
+		{name: "rollback missing revision", handler: h.RollbackLyricsHandler, method: http.MethodPost, id: "1", body: `{"revision_id":999}`, want: http.StatusNotFound},
+		{name: "rollback missing song", handler: h.RollbackLyricsHandler, method: http.MethodPost, id: "999", body: `{"revision_id":2}`, want: http.StatusNotFound},
+	}
+
+	// The cases run in order: the rollback needs the revisions of the edits.
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, "/songs/"+tt.id+"/lyrics", strings.NewReader(tt.body))
+		req.SetPathValue("id", tt.id)
+		rec := httptest.NewRecorder()
+		tt.handler(rec, req)
+
+		if rec.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d (body %s)", tt.name, rec.Code, tt.want, rec.Body)
+		}
+	}
+
+	// Revision 2 holds the lyrics of the first edit.
+	song, err := h.SongService.GetSongByID(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("GetSongByID: %v", err)
+	}
+	if song.Lyrics != "Paranoia is in bloom" {
+		t.Errorf("lyrics after rollback = %q, want the first edit", song.Lyrics)
+	}
+}
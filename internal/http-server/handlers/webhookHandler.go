@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/service"
+	"github.com/2pizzzza/TestTask/internal/storage"
+	"github.com/2pizzzza/TestTask/internal/utils"
+)
+
+type WebhookHandlers struct {
+	WebhookService service.WebhookService
+	Timeout        time.Duration
+}
+
+func NewWebhooks(webhookService service.WebhookService, timeout time.Duration) *WebhookHandlers {
+	return &WebhookHandlers{WebhookService: webhookService, Timeout: timeout}
+}
+
+func writeWebhookError(w http.ResponseWriter, ctx context.Context, err error, fallback string) {
+	if writeContextError(w, ctx, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, service.ErrInvalidWebhook):
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+	case errors.Is(err, storage.ErrWebhookNotFound):
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: "Webhook not found"}, http.StatusNotFound)
+	default:
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: fallback}, http.StatusInternalServerError)
+	}
+}
+
+// ListWebhooks godoc
+// @Summary List webhooks
+// @Description List registered webhook subscriptions
+// @Tags webhooks
+// @Produce json
+// @Success 200 {array} models.Webhook "Webhooks"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 401 {object} models.ErrorResponse "Missing or invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the required scope"
+// @Security BearerAuth
+// @Router /webhooks [get]
+func (h *WebhookHandlers) ListWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := withTimeout(r, h.Timeout)
+	defer cancel()
+
+	hooks, err := h.WebhookService.ListWebhooks(ctx)
+	if err != nil {
+		writeWebhookError(w, ctx, err, "Failed to list webhooks")
+		return
+	}
+
+	utils.WriteResponseBody(w, hooks, http.StatusOK)
+}
+
+// CreateWebhook godoc
+// @Summary Create a webhook
+// @Description Subscribe a URL to song events. Deliveries are signed with HMAC-SHA256 of "timestamp.body" in the X-Webhook-Signature-256 header. The secret is only returned here.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param webhook body models.WebhookReq true "Webhook data"
+// @Success 201 {object} models.Webhook "Webhook created"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 401 {object} models.ErrorResponse "Missing or invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the required scope"
+// @Security BearerAuth
+// @Router /webhooks [post]
+func (h *WebhookHandlers) CreateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	var req models.WebhookReq
+	if err := utils.ReadRequestBody(r, &req); err != nil {
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: "Invalid request body"}, http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := withTimeout(r, h.Timeout)
+	defer cancel()
+
+	hook, err := h.WebhookService.CreateWebhook(ctx, req)
+	if err != nil {
+		writeWebhookError(w, ctx, err, "Failed to create webhook")
+		return
+	}
+
+	utils.WriteResponseBody(w, hook, http.StatusCreated)
+}
+
+// GetWebhook godoc
+// @Summary Get a webhook
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} models.Webhook "Webhook"
+// @Failure 404 {object} models.ErrorResponse "Webhook not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 401 {object} models.ErrorResponse "Missing or invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the required scope"
+// @Security BearerAuth
+// @Router /webhooks/{id} [get]
+func (h *WebhookHandlers) GetWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: "Invalid webhook ID"}, http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := withTimeout(r, h.Timeout)
+	defer cancel()
+
+	hook, err := h.WebhookService.GetWebhook(ctx, id)
+	if err != nil {
+		writeWebhookError(w, ctx, err, "Failed to get webhook")
+		return
+	}
+
+	utils.WriteResponseBody(w, hook, http.StatusOK)
+}
+
+// UpdateWebhook godoc
+// @Summary Update a webhook
+// @Description Replace the URL, event filter, secret or active flag. Re-activating resets the failure counter.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Param webhook body models.WebhookReq true "Webhook data"
+// @Success 200 {object} models.Webhook "Webhook updated"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 404 {object} models.ErrorResponse "Webhook not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 401 {object} models.ErrorResponse "Missing or invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the required scope"
+// @Security BearerAuth
+// @Router /webhooks/{id} [put]
+func (h *WebhookHandlers) UpdateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: "Invalid webhook ID"}, http.StatusBadRequest)
+		return
+	}
+
+	var req models.WebhookReq
+	if err := utils.ReadRequestBody(r, &req); err != nil {
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: "Invalid request body"}, http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := withTimeout(r, h.Timeout)
+	defer cancel()
+
+	hook, err := h.WebhookService.UpdateWebhook(ctx, id, req)
+	if err != nil {
+		writeWebhookError(w, ctx, err, "Failed to update webhook")
+		return
+	}
+
+	utils.WriteResponseBody(w, hook, http.StatusOK)
+}
+
+// DeleteWebhook godoc
+// @Summary Delete a webhook
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} models.SongCreateResponse "Webhook deleted"
+// @Failure 404 {object} models.ErrorResponse "Webhook not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 401 {object} models.ErrorResponse "Missing or invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the required scope"
+// @Security BearerAuth
+// @Router /webhooks/{id} [delete]
+func (h *WebhookHandlers) DeleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: "Invalid webhook ID"}, http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := withTimeout(r, h.Timeout)
+	defer cancel()
+
+	if err := h.WebhookService.DeleteWebhook(ctx, id); err != nil {
+		writeWebhookError(w, ctx, err, "Failed to delete webhook")
+		return
+	}
+
+	utils.WriteResponseBody(w, models.SongCreateResponse{Message: "Webhook deleted"}, http.StatusOK)
+}
+
+// ListWebhookDeliveries godoc
+// @Summary List webhook deliveries
+// @Description List recent delivery attempts with their responses
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Param limit query int false "Number of deliveries to return"
+// @Success 200 {array} models.WebhookDelivery "Deliveries"
+// @Failure 404 {object} models.ErrorResponse "Webhook not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 401 {object} models.ErrorResponse "Missing or invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the required scope"
+// @Security BearerAuth
+// @Router /webhooks/{id}/deliveries [get]
+func (h *WebhookHandlers) ListDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: "Invalid webhook ID"}, http.StatusBadRequest)
+		return
+	}
+
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 50
+	}
+
+	ctx, cancel := withTimeout(r, h.Timeout)
+	defer cancel()
+
+	deliveries, err := h.WebhookService.ListDeliveries(ctx, id, limit)
+	if err != nil {
+		writeWebhookError(w, ctx, err, "Failed to list deliveries")
+		return
+	}
+
+	utils.WriteResponseBody(w, deliveries, http.StatusOK)
+}
+
+// SendTestWebhook godoc
+// @Summary Send a test event
+// @Description Deliver a signed webhook.test event once and return the attempt
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} models.WebhookDelivery "Delivery attempt"
+// @Failure 404 {object} models.ErrorResponse "Webhook not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 401 {object} models.ErrorResponse "Missing or invalid API key"
+// @Failure 403 {object} models.ErrorResponse "API key lacks the required scope"
+// @Security BearerAuth
+// @Router /webhooks/{id}/test [post]
+func (h *WebhookHandlers) SendTestEventHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		utils.WriteResponseBody(w, models.ErrorResponse{Message: "Invalid webhook ID"}, http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := withTimeout(r, h.Timeout)
+	defer cancel()
+
+	delivery, err := h.WebhookService.SendTestEvent(ctx, id)
+	if err != nil {
+		writeWebhookError(w, ctx, err, "Failed to send test event")
+		return
+	}
+
+	utils.WriteResponseBody(w, delivery, http.StatusOK)
+}
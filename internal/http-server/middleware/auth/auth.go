@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/lib/logger/sl"
+	"github.com/2pizzzza/TestTask/internal/service"
+	"github.com/2pizzzza/TestTask/internal/utils"
+)
+
+// Authenticator resolves the bearer token presented with a request. It
+// returns service.ErrUnauthenticated for tokens it does not accept.
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (models.Principal, error)
+}
+
+// Chain tries each authenticator in turn, so API keys and user access
+// tokens can share the Authorization header.
+type Chain []Authenticator
+
+func (c Chain) Authenticate(ctx context.Context, token string) (models.Principal, error) {
+	for _, a := range c {
+		p, err := a.Authenticate(ctx, token)
+		if !errors.Is(err, service.ErrUnauthenticated) {
+			return p, err
+		}
+	}
+	return models.Principal{}, service.ErrUnauthenticated
+}
+
+// Policy returns the scope a request needs, or "" when the route is public.
+type Policy func(r *http.Request) string
+
+type principalKey struct{}
+
+// Middleware lets a request through when the route is public or the request
+// carries an API key or a user access token, as "Authorization: Bearer
+// <token>", that grants the scope the policy asks for. The principal is
+// stored in the context and its id is added to the request-scoped logger.
+func Middleware(log *slog.Logger, authn Authenticator, policy Policy, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scope := policy(r)
+		if scope == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token, ok := bearer(r)
+		if !ok {
+			unauthorized(w, "", "Missing bearer token")
+			return
+		}
+
+		p, err := authn.Authenticate(r.Context(), token)
+		if err != nil {
+			if errors.Is(err, service.ErrUnauthenticated) {
+				unauthorized(w, "invalid_token", "Invalid or expired token")
+				return
+			}
+			sl.FromContext(r.Context(), log).Error("failed to authenticate request", sl.Err(err))
+			utils.WriteResponseBody(w, models.ErrorResponse{Message: "Failed to check credentials"}, http.StatusInternalServerError)
+			return
+		}
+
+		if !p.Allows(scope) {
+			w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+scope+`"`)
+			utils.WriteResponseBody(w, models.ErrorResponse{Message: "Token lacks scope " + scope}, http.StatusForbidden)
+			return
+		}
+
+		reqLog := sl.FromContext(r.Context(), log)
+		if p.UserId != 0 {
+			reqLog = reqLog.With(slog.Int64("user_id", p.UserId))
+		} else {
+			reqLog = reqLog.With(slog.Int64("api_key_id", p.APIKeyId))
+		}
+		ctx := context.WithValue(r.Context(), principalKey{}, p)
+		ctx = sl.WithLogger(ctx, reqLog)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// FromContext returns the principal that authenticated the request, if any.
+func FromContext(ctx context.Context) (models.Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(models.Principal)
+	return p, ok
+}
+
+func bearer(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+func unauthorized(w http.ResponseWriter, reason, message string) {
+	challenge := "Bearer"
+	if reason != "" {
+		challenge += ` error="` + reason + `"`
+	}
+	w.Header().Set("WWW-Authenticate", challenge)
+	utils.WriteResponseBody(w, models.ErrorResponse{Message: message}, http.StatusUnauthorized)
+}
@@ -0,0 +1,214 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/2pizzzza/TestTask/internal/config"
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/http-server/middleware/auth"
+	"github.com/2pizzzza/TestTask/internal/service"
+	"github.com/2pizzzza/TestTask/internal/storage/sqlite"
+)
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// policy makes /public public, /write need songs:write and everything else
+// songs:read.
+func policy(r *http.Request) string {
+	switch r.URL.Path {
+	case "/public":
+		return ""
+	case "/write":
+		return models.ScopeSongsWrite
+	default:
+		return models.ScopeSongsRead
+	}
+}
+
+// whoami answers with the name of the authenticated principal.
+var whoami = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	p, _ := auth.FromContext(r.Context())
+	io.WriteString(w, p.Name)
+})
+
+// newKeys returns an API key service on a fresh sqlite database with a
+// songs:read key and a revoked one.
+func newKeys(t *testing.T) (keys *service.APIKeyRep, reader, revoked string) {
+	t.Helper()
+	ctx := context.Background()
+
+	db, err := sqlite.New(ctx, config.SQLiteConfig{Path: filepath.Join(t.TempDir(), "auth.db"), BusyTimeout: time.Second})
+	if err != nil {
+		t.Fatalf("sqlite.New: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.MigrateUp(ctx, discard); err != nil {
+		t.Fatalf("MigrateUp: %v", err)
+	}
+
+	keys = service.NewAPIKeys(discard, db)
+	if _, reader, err = keys.CreateAPIKey(ctx, "reader", []string{models.ScopeSongsRead}); err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+	old, revoked, err := keys.CreateAPIKey(ctx, "old", []string{models.ScopeAdmin})
+	if err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+	if _, err := keys.RevokeAPIKey(ctx, old.Id); err != nil {
+		t.Fatalf("RevokeAPIKey: %v", err)
+	}
+
+	return keys, reader, revoked
+}
+
+// tamper changes the last character of a key.
+func tamper(key string) string {
+	last := "x"
+	if strings.HasSuffix(key, last) {
+		last = "y"
+	}
+	return key[:len(key)-1] + last
+}
+
+func TestMiddleware(t *testing.T) {
+	keys, reader, revoked := newKeys(t)
+	handler := auth.Middleware(discard, keys, policy, whoami)
+
+	tests := []struct {
+		name          string
+		path          string
+		header        string
+		wantStatus    int
+		wantChallenge string
+		wantBody      string
+	}{
+		{name: "public route without token", path: "/public", wantStatus: http.StatusOK},
+		{name: "public route ignores a bad token", path: "/public", header: "Bearer nope", wantStatus: http.StatusOK},
+		{name: "missing token", path: "/songs", wantStatus: http.StatusUnauthorized, wantChallenge: "Bearer"},
+		{name: "other scheme", path: "/songs", header: "Basic " + reader, wantStatus: http.StatusUnauthorized, wantChallenge: "Bearer"},
+		{name: "empty bearer", path: "/songs", header: "Bearer   ", wantStatus: http.StatusUnauthorized, wantChallenge: "Bearer"},
+		{name: "malformed token", path: "/songs", header: "Bearer nope", wantStatus: http.StatusUnauthorized,
+			wantChallenge: `Bearer error="invalid_token"`},
+		{name: "unknown key", path: "/songs", header: "Bearer " + tamper(reader),
+			wantStatus: http.StatusUnauthorized, wantChallenge: `Bearer error="invalid_token"`},
+		{name: "revoked key", path: "/songs", header: "Bearer " + revoked, wantStatus: http.StatusUnauthorized,
+			wantChallenge: `Bearer error="invalid_token"`},
+		{name: "granted scope", path: "/songs", header: "Bearer " + reader, wantStatus: http.StatusOK, wantBody: "reader"},
+		{name: "scheme is case insensitive", path: "/songs", header: "bearer " + reader, wantStatus: http.StatusOK, wantBody: "reader"},
+		{name: "insufficient scope", path: "/write", header: "Bearer " + reader, wantStatus: http.StatusForbidden,
+			wantChallenge: `Bearer error="insufficient_scope", scope="songs:write"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body %q)", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if got := rec.Header().Get("WWW-Authenticate"); got != tt.wantChallenge {
+				t.Errorf("WWW-Authenticate = %q, want %q", got, tt.wantChallenge)
+			}
+			if tt.wantBody != "" && rec.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", rec.Body, tt.wantBody)
+			}
+		})
+	}
+}
+
+// authFunc adapts a function to auth.Authenticator.
+type authFunc func(ctx context.Context, token string) (models.Principal, error)
+
+func (f authFunc) Authenticate(ctx context.Context, token string) (models.Principal, error) {
+	return f(ctx, token)
+}
+
+// accepts authenticates only token, as name.
+func accepts(token, name string) authFunc {
+	return func(_ context.Context, got string) (models.Principal, error) {
+		if got != token {
+			return models.Principal{}, service.ErrUnauthenticated
+		}
+		return models.Principal{Name: name, Scopes: []string{models.ScopeSongsRead}}, nil
+	}
+}
+
+func TestMiddlewareStorageFailure(t *testing.T) {
+	failing := authFunc(func(context.Context, string) (models.Principal, error) {
+		return models.Principal{}, errors.New("database is down")
+	})
+	handler := auth.Middleware(discard, failing, policy, whoami)
+
+	req := httptest.NewRequest(http.MethodGet, "/songs", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500 when credentials cannot be checked", rec.Code)
+	}
+}
+
+func TestChain(t *testing.T) {
+	boom := errors.New("database is down")
+	failing := authFunc(func(context.Context, string) (models.Principal, error) {
+		return models.Principal{}, boom
+	})
+
+	tests := []struct {
+		name     string
+		chain    auth.Chain
+		token    string
+		wantName string
+		wantErr  error
+	}{
+		{name: "first accepts", chain: auth.Chain{accepts("a", "first"), accepts("a", "second")}, token: "a", wantName: "first"},
+		{name: "falls through to the next", chain: auth.Chain{accepts("a", "first"), accepts("b", "second")}, token: "b", wantName: "second"},
+		{name: "nobody accepts", chain: auth.Chain{accepts("a", "first"), accepts("b", "second")}, token: "c",
+			wantErr: service.ErrUnauthenticated},
+		{name: "empty chain", chain: auth.Chain{}, token: "a", wantErr: service.ErrUnauthenticated},
+		{name: "other errors stop the chain", chain: auth.Chain{failing, accepts("a", "second")}, token: "a", wantErr: boom},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := tt.chain.Authenticate(context.Background(), tt.token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Authenticate: err = %v, want %v", err, tt.wantErr)
+			}
+			if p.Name != tt.wantName {
+				t.Errorf("principal = %q, want %q", p.Name, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestMiddlewareWithChain(t *testing.T) {
+	keys, reader, _ := newKeys(t)
+	handler := auth.Middleware(discard, auth.Chain{keys, accepts("user-token", "alice")}, policy, whoami)
+
+	for token, want := range map[string]string{reader: "reader", "user-token": "alice"} {
+		req := httptest.NewRequest(http.MethodGet, "/songs", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK || rec.Body.String() != want {
+			t.Errorf("token for %s: status %d, body %q", want, rec.Code, rec.Body)
+		}
+	}
+}
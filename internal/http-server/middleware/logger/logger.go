@@ -4,6 +4,8 @@ import (
 	"log/slog"
 	"net/http"
 	"time"
+
+	"github.com/2pizzzza/TestTask/internal/lib/logger/sl"
 )
 
 type loggingResponseWriter struct {
@@ -23,7 +25,12 @@ func (lrw *loggingResponseWriter) WriteHeader(statusCode int) {
 	lrw.ResponseWriter.WriteHeader(statusCode)
 }
 
-func LoggingMiddleware(next http.Handler) http.Handler {
+// Observer receives the measurements taken for every request, e.g. to export metrics.
+type Observer interface {
+	ObserveRequest(r *http.Request, status, bytes int, duration time.Duration)
+}
+
+func LoggingMiddleware(next http.Handler, observers ...Observer) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 
@@ -32,7 +39,7 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 		next.ServeHTTP(lrw, r)
 
 		duration := time.Since(start)
-		slog.Info("Request completed",
+		sl.FromContext(r.Context(), slog.Default()).Info("Request completed",
 			"method", r.Method,
 			"path", r.URL.Path,
 			"status", lrw.statusCode,
@@ -40,5 +47,14 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 			"duration", duration,
 			"client_ip", r.RemoteAddr,
 		)
+
+		for _, o := range observers {
+			o.ObserveRequest(r, lrw.statusCode, lrw.bytes, duration)
+		}
 	})
 }
+
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to flush.
+func (lrw *loggingResponseWriter) Unwrap() http.ResponseWriter {
+	return lrw.ResponseWriter
+}
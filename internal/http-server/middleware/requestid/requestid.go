@@ -0,0 +1,71 @@
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+
+	"github.com/2pizzzza/TestTask/internal/lib/logger/sl"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	Header = "X-Request-ID"
+
+	maxLength = 128
+)
+
+type idKey struct{}
+
+// Middleware tags every request with an id, taken from the X-Request-ID
+// header when the client sent a usable one. The id is echoed in the response
+// and attached, with the trace id if any, to a request-scoped logger stored
+// in the context.
+func Middleware(log *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(Header)
+		if !valid(id) {
+			id = newID()
+		}
+
+		w.Header().Set(Header, id)
+
+		reqLog := log.With(slog.String("request_id", id))
+		if sc := trace.SpanContextFromContext(r.Context()); sc.IsValid() {
+			reqLog = reqLog.With(slog.String("trace_id", sc.TraceID().String()))
+		}
+
+		ctx := context.WithValue(r.Context(), idKey{}, id)
+		ctx = sl.WithLogger(ctx, reqLog)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// FromContext returns the request id, or "" outside a request.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(idKey{}).(string)
+	return id
+}
+
+func valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,46 @@
+// Package apikey generates API keys and derives what is stored for them.
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+const (
+	// tokenPrefix marks songlib keys, e.g. for secret scanners.
+	tokenPrefix = "slk_"
+	// prefixLength is how much of a key is kept in clear to tell keys apart.
+	prefixLength = len(tokenPrefix) + 8
+)
+
+// Generate returns a new random key.
+func Generate() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return tokenPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Hash returns the hex SHA-256 of the key. Keys carry 256 random bits, so a
+// fast unsalted hash is enough and lets keys be looked up by their hash.
+func Hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Prefix returns the leading part of the key that is stored in clear.
+func Prefix(key string) string {
+	if len(key) < prefixLength {
+		return key
+	}
+	return key[:prefixLength]
+}
+
+// Valid reports whether s looks like a key generated by Generate.
+func Valid(s string) bool {
+	return strings.HasPrefix(s, tokenPrefix) && len(s) > prefixLength
+}
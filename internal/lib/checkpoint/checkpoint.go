@@ -0,0 +1,63 @@
+package checkpoint
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// File persists the last fully processed id so an interrupted run can resume.
+type File struct {
+	Path string
+}
+
+func New(path string) *File {
+	return &File{Path: path}
+}
+
+// Load returns the stored id, or 0 when no checkpoint exists yet.
+func (f *File) Load() (int64, error) {
+	const op = "checkpoint.Load"
+
+	data, err := os.ReadFile(f.Path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (f *File) Save(id int64) error {
+	const op = "checkpoint.Save"
+
+	tmp := f.Path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strconv.FormatInt(id, 10)), 0o644); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := os.Rename(tmp, f.Path); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Clear removes the checkpoint after a run has completed.
+func (f *File) Clear() error {
+	const op = "checkpoint.Clear"
+
+	if err := os.Remove(f.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
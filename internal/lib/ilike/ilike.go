@@ -0,0 +1,76 @@
+// Package ilike implements the postgres ILIKE operator for backends that
+// have to reproduce its semantics.
+package ilike
+
+import "strings"
+
+// Match reports whether s matches a SQL LIKE pattern ignoring case: "%"
+// matches any run of characters, "_" exactly one and a backslash escapes the
+// character after it, as in postgres.
+func Match(s, pattern string) bool {
+	return like([]rune(strings.ToLower(s)), compile([]rune(strings.ToLower(pattern))))
+}
+
+type kind uint8
+
+const (
+	literal  kind = iota
+	single        // _
+	wildcard      // %
+)
+
+type token struct {
+	kind kind
+	r    rune
+}
+
+// compile resolves escapes, so that matching never has to look back at them.
+// A trailing backslash matches itself.
+func compile(p []rune) []token {
+	tokens := make([]token, 0, len(p))
+	for i := 0; i < len(p); i++ {
+		switch p[i] {
+		case '%':
+			tokens = append(tokens, token{kind: wildcard})
+		case '_':
+			tokens = append(tokens, token{kind: single})
+		case '\\':
+			if i+1 < len(p) {
+				i++
+			}
+			fallthrough
+		default:
+			tokens = append(tokens, token{kind: literal, r: p[i]})
+		}
+	}
+	return tokens
+}
+
+// like matches greedily and, on a mismatch, only retries from the last "%"
+// with one more character swallowed by it. Earlier "%" never need to be
+// revisited, so the work is bounded by len(s) * len(p) whatever the pattern.
+func like(s []rune, p []token) bool {
+	si, pi := 0, 0
+	star, mark := -1, 0
+
+	for si < len(s) {
+		switch {
+		case pi < len(p) && p[pi].kind == wildcard:
+			star, mark = pi, si
+			pi++
+		case pi < len(p) && (p[pi].kind == single || p[pi].r == s[si]):
+			si++
+			pi++
+		case star >= 0:
+			mark++
+			si, pi = mark, star+1
+		default:
+			return false
+		}
+	}
+
+	for pi < len(p) && p[pi].kind == wildcard {
+		pi++
+	}
+	return pi == len(p)
+}
@@ -0,0 +1,61 @@
+package ilike
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		s, pattern string
+		want       bool
+	}{
+		{"Muse", "muse", true},
+		{"Muse", "MUSE", true},
+		{"Muse", "mus", false},
+		{"Muse", "%", true},
+		{"", "%", true},
+		{"", "", true},
+		{"", "_", false},
+		{"Muse", "m_se", true},
+		{"Muse", "m__e", true},
+		{"Muse", "m_e", false},
+		{"Supermassive Black Hole", "%black%", true},
+		{"Supermassive Black Hole", "super%hole", true},
+		{"Supermassive Black Hole", "%hole%black%", false},
+		{"abcabc", "%abc", true},
+		{"abcab", "%abc", false},
+		{"aab", "%a_b", true},
+		{"mississippi", "%iss%ppi", true},
+		{"mississippi", "m%%%i", true},
+		{"100%", "100\\%", true},
+		{"1000", "100\\%", false},
+		{"a_b", "a\\_b", true},
+		{"axb", "a\\_b", false},
+		{"a\\b", "a\\\\b", true},
+		{"ab\\", "ab\\", true},
+		{"Ёлка", "ё%", true},
+	}
+
+	for _, tt := range tests {
+		if got := Match(tt.s, tt.pattern); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.s, tt.pattern, got, tt.want)
+		}
+	}
+}
+
+// A backtracking matcher retries every split of s between the "%"s, which
+// for this input takes longer than the test timeout.
+func TestMatchAdversarialPattern(t *testing.T) {
+	s := strings.Repeat("a", 10000)
+	pattern := strings.Repeat("%a", 30) + "%b"
+
+	start := time.Now()
+	if Match(s, pattern) {
+		t.Fatal("Match reported a match for a pattern ending in b")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Match took %v, want it bounded by len(s) * len(pattern)", elapsed)
+	}
+}
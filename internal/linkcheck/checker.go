@@ -0,0 +1,279 @@
+package linkcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/2pizzzza/TestTask/internal/config"
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/lib/logger/sl"
+)
+
+const userAgent = "SongLibraries-LinkChecker/1.0"
+
+type Repository interface {
+	LinksToCheck(ctx context.Context, checkedBefore time.Time, limit int) ([]models.SongLink, error)
+	SaveLinkCheck(ctx context.Context, linkId int64, check models.LinkCheck) error
+	ReplaceLinkURL(ctx context.Context, linkId int64, url string) error
+}
+
+// Checker periodically probes stored song links and records their status.
+type Checker struct {
+	log    *slog.Logger
+	repo   Repository
+	cfg    config.LinkCheckConfig
+	client *http.Client
+
+	mu    sync.Mutex
+	hosts map[string]*hostLimiter
+}
+
+type hostLimiter struct {
+	sem  chan struct{}
+	refs int // requests holding or waiting for the limiter, guarded by Checker.mu
+
+	mu   sync.Mutex
+	last time.Time
+}
+
+func New(log *slog.Logger, repo Repository, cfg config.LinkCheckConfig) *Checker {
+	return &Checker{
+		log:  log,
+		repo: repo,
+		cfg:  cfg,
+		client: &http.Client{
+			Timeout: cfg.Timeout,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		hosts: make(map[string]*hostLimiter),
+	}
+}
+
+// Run checks links every interval until ctx is cancelled.
+func (c *Checker) Run(ctx context.Context) {
+	const op = "linkcheck.Run"
+
+	log := c.log.With(
+		slog.String("op", op),
+	)
+
+	ticker := time.NewTicker(c.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		checked, err := c.CheckOnce(ctx)
+		if err != nil && !errors.Is(err, context.Canceled) {
+			log.Error("link check round failed", sl.Err(err))
+		} else if checked > 0 {
+			log.Info("link check round finished", slog.Int("checked", checked))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckOnce probes one batch of links that are due for a check.
+func (c *Checker) CheckOnce(ctx context.Context) (int, error) {
+	const op = "linkcheck.CheckOnce"
+
+	links, err := c.repo.LinksToCheck(ctx, time.Now().Add(-c.cfg.RecheckAfter), c.cfg.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	sem := make(chan struct{}, c.cfg.Concurrency)
+	var wg sync.WaitGroup
+
+	for _, link := range links {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return 0, fmt.Errorf("%s: %w", op, ctx.Err())
+		}
+
+		wg.Add(1)
+		go func(link models.SongLink) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			c.checkLink(ctx, link)
+		}(link)
+	}
+
+	wg.Wait()
+	c.pruneHosts()
+
+	return len(links), nil
+}
+
+func (c *Checker) checkLink(ctx context.Context, link models.SongLink) {
+	log := c.log.With(
+		slog.String("op", "linkcheck.checkLink"),
+		slog.Int64("link_id", link.Id),
+	)
+
+	check, failed := c.probe(ctx, link.URL)
+	if ctx.Err() != nil {
+		return
+	}
+
+	// One timeout or DNS hiccup says little about a link, so it is only
+	// marked broken after FailureThreshold failed checks in a row.
+	if failed {
+		check.Failures = link.Failures + 1
+		check.Broken = check.Broken || check.Failures >= c.cfg.FailureThreshold
+	}
+
+	if check.Permanent && c.cfg.AutoUpdateRedirects && check.RedirectURL != "" {
+		if err := c.repo.ReplaceLinkURL(ctx, link.Id, check.RedirectURL); err != nil {
+			log.Error("failed to follow permanent redirect", sl.Err(err))
+		} else {
+			log.Info("link moved permanently, url updated", slog.String("url", check.RedirectURL))
+			return
+		}
+	}
+
+	if err := c.repo.SaveLinkCheck(ctx, link.Id, check); err != nil {
+		log.Error("failed to save link check", sl.Err(err))
+	}
+}
+
+// probe issues a HEAD request, falling back to GET for servers that do not
+// support HEAD. Redirects are not followed; the first hop is recorded. It
+// reports whether the check failed; only an unparsable url is marked broken
+// right away.
+func (c *Checker) probe(ctx context.Context, rawURL string) (models.LinkCheck, bool) {
+	check := models.LinkCheck{CheckedAt: time.Now()}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		check.Broken = true
+		check.Error = "invalid url"
+		return check, true
+	}
+
+	release, err := c.acquireHost(ctx, u.Host)
+	if err != nil {
+		check.Error = err.Error()
+		return check, false
+	}
+	defer release()
+
+	resp, err := c.do(ctx, http.MethodHead, u)
+	if err == nil && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented) {
+		resp, err = c.do(ctx, http.MethodGet, u)
+	}
+	if err != nil {
+		check.Error = err.Error()
+		return check, true
+	}
+
+	check.StatusCode = resp.StatusCode
+
+	if resp.StatusCode >= 300 && resp.StatusCode < 400 {
+		if loc, err := resp.Location(); err == nil {
+			check.RedirectURL = loc.String()
+		}
+		check.Permanent = resp.StatusCode == http.StatusMovedPermanently || resp.StatusCode == http.StatusPermanentRedirect
+	}
+
+	return check, resp.StatusCode >= 400
+}
+
+func (c *Checker) do(ctx context.Context, method string, u *url.URL) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	return resp, nil
+}
+
+// acquireHost limits parallel requests per host and keeps a minimum delay
+// between consecutive requests to the same host.
+func (c *Checker) acquireHost(ctx context.Context, host string) (func(), error) {
+	c.mu.Lock()
+	h, ok := c.hosts[host]
+	if !ok {
+		h = &hostLimiter{sem: make(chan struct{}, c.cfg.PerHost)}
+		c.hosts[host] = h
+	}
+	h.refs++
+	c.mu.Unlock()
+
+	done := func() {
+		c.mu.Lock()
+		h.refs--
+		c.mu.Unlock()
+	}
+
+	select {
+	case h.sem <- struct{}{}:
+	case <-ctx.Done():
+		done()
+		return nil, ctx.Err()
+	}
+
+	h.mu.Lock()
+	wait := time.Until(h.last.Add(c.cfg.HostDelay))
+	if wait < 0 {
+		wait = 0
+	}
+	h.last = time.Now().Add(wait)
+	h.mu.Unlock()
+
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			<-h.sem
+			done()
+			return nil, ctx.Err()
+		}
+	}
+
+	return func() {
+		<-h.sem
+		done()
+	}, nil
+}
+
+// pruneHosts forgets hosts nobody is waiting for whose delay has passed, so
+// the limiter map only holds hosts seen recently.
+func (c *Checker) pruneHosts() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for host, h := range c.hosts {
+		if h.refs > 0 {
+			continue
+		}
+		h.mu.Lock()
+		idle := time.Since(h.last) >= c.cfg.HostDelay
+		h.mu.Unlock()
+		if idle {
+			delete(c.hosts, host)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package linkcheck
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/2pizzzza/TestTask/internal/config"
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+)
+
+// fakeRepo keeps links in memory and hands out every link on each round.
+type fakeRepo struct {
+	mu    sync.Mutex
+	links map[int64]*models.SongLink
+}
+
+func (r *fakeRepo) LinksToCheck(_ context.Context, _ time.Time, _ int) ([]models.SongLink, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	links := make([]models.SongLink, 0, len(r.links))
+	for _, l := range r.links {
+		links = append(links, *l)
+	}
+	return links, nil
+}
+
+func (r *fakeRepo) SaveLinkCheck(_ context.Context, linkId int64, check models.LinkCheck) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	l := r.links[linkId]
+	l.StatusCode = check.StatusCode
+	l.CheckError = check.Error
+	l.Broken = check.Broken
+	l.Failures = check.Failures
+	return nil
+}
+
+func (r *fakeRepo) ReplaceLinkURL(_ context.Context, linkId int64, url string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.links[linkId].URL = url
+	return nil
+}
+
+func (r *fakeRepo) link(id int64) models.SongLink {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return *r.links[id]
+}
+
+func newChecker(repo Repository) *Checker {
+	return New(slog.New(slog.NewTextHandler(io.Discard, nil)), repo, config.LinkCheckConfig{
+		BatchSize:        10,
+		Concurrency:      2,
+		PerHost:          1,
+		Timeout:          time.Second,
+		FailureThreshold: 3,
+	})
+}
+
+func TestCheckerNeedsConsecutiveFailures(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		status = http.StatusServiceUnavailable
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+	setStatus := func(code int) {
+		mu.Lock()
+		status = code
+		mu.Unlock()
+	}
+
+	repo := &fakeRepo{links: map[int64]*models.SongLink{1: {Id: 1, URL: srv.URL}}}
+	c := newChecker(repo)
+	ctx := context.Background()
+
+	round := func() models.SongLink {
+		t.Helper()
+		if _, err := c.CheckOnce(ctx); err != nil {
+			t.Fatalf("CheckOnce: %v", err)
+		}
+		return repo.link(1)
+	}
+
+	for i := 1; i < 3; i++ {
+		if l := round(); l.Broken || l.Failures != i {
+			t.Fatalf("after %d failures: broken = %v, failures = %d, want not broken and %d failures", i, l.Broken, l.Failures, i)
+		}
+	}
+	if l := round(); !l.Broken || l.Failures != 3 {
+		t.Fatalf("after 3 failures: broken = %v, failures = %d, want broken", l.Broken, l.Failures)
+	}
+
+	setStatus(http.StatusOK)
+	if l := round(); l.Broken || l.Failures != 0 {
+		t.Fatalf("after a success: broken = %v, failures = %d, want a healthy link", l.Broken, l.Failures)
+	}
+
+	// A success resets the count, so a single failure later is tolerated.
+	setStatus(http.StatusBadGateway)
+	if l := round(); l.Broken || l.Failures != 1 {
+		t.Fatalf("after a new failure: broken = %v, failures = %d, want not broken and 1 failure", l.Broken, l.Failures)
+	}
+}
+
+func TestCheckerMarksInvalidURLBrokenAtOnce(t *testing.T) {
+	repo := &fakeRepo{links: map[int64]*models.SongLink{1: {Id: 1, URL: "not a url"}}}
+	c := newChecker(repo)
+
+	if _, err := c.CheckOnce(context.Background()); err != nil {
+		t.Fatalf("CheckOnce: %v", err)
+	}
+	if l := repo.link(1); !l.Broken {
+		t.Fatalf("invalid url: broken = false, want true")
+	}
+}
+
+func TestCheckerForgetsIdleHosts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	repo := &fakeRepo{links: map[int64]*models.SongLink{1: {Id: 1, URL: srv.URL}}}
+	c := newChecker(repo)
+
+	if _, err := c.CheckOnce(context.Background()); err != nil {
+		t.Fatalf("CheckOnce: %v", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.hosts) != 0 {
+		t.Errorf("hosts after the round = %d, want idle hosts pruned", len(c.hosts))
+	}
+}
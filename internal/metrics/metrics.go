@@ -0,0 +1,180 @@
+// Package metrics exposes service metrics in the Prometheus text format.
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/enrichment"
+	"github.com/2pizzzza/TestTask/internal/lib/logger/sl"
+	"github.com/2pizzzza/TestTask/internal/utils"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "songlib"
+
+// routeUnmatched labels requests that did not match any route, so unknown
+// paths do not create new series.
+const routeUnmatched = "unmatched"
+
+// methodOther labels requests with a non-standard method, which a client
+// can otherwise choose freely.
+const methodOther = "OTHER"
+
+type CatalogSource interface {
+	CatalogStats(ctx context.Context) (models.CatalogStats, error)
+}
+
+type Metrics struct {
+	registry *prometheus.Registry
+	mux      *http.ServeMux
+
+	requests      *prometheus.HistogramVec
+	responseBytes *prometheus.HistogramVec
+	enrichment    *prometheus.HistogramVec
+}
+
+// New creates the registry. Requests are labelled with the mux pattern that
+// served them.
+func New(mux *http.ServeMux) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		mux:      mux,
+		requests: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "HTTP request latency by route pattern and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		responseBytes: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "response_size_bytes",
+			Help:      "HTTP response body size by route pattern.",
+			Buckets:   prometheus.ExponentialBuckets(64, 4, 8),
+		}, []string{"method", "route"}),
+		enrichment: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "enrichment",
+			Name:      "request_duration_seconds",
+			Help:      "Enrichment provider call latency by outcome (ok, not_found, error).",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"outcome"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.responseBytes,
+		m.enrichment,
+	)
+
+	return m
+}
+
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// ObserveRequest implements logger.Observer.
+func (m *Metrics) ObserveRequest(r *http.Request, status, bytes int, duration time.Duration) {
+	route := routeUnmatched
+	if _, pattern := m.mux.Handler(r); pattern != "" {
+		route = pattern
+	}
+
+	method := methodLabel(r.Method)
+	m.requests.WithLabelValues(method, route, strconv.Itoa(status)).Observe(duration.Seconds())
+	m.responseBytes.WithLabelValues(method, route).Observe(float64(bytes))
+}
+
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return methodOther
+}
+
+// RegisterDB exports the connection pool statistics of db.
+func (m *Metrics) RegisterDB(db *sql.DB) {
+	m.registry.MustRegister(collectors.NewDBStatsCollector(db, namespace))
+}
+
+// RegisterCatalog exports catalog sizes, read from src on every scrape.
+func (m *Metrics) RegisterCatalog(log *slog.Logger, src CatalogSource, timeout time.Duration) {
+	m.registry.MustRegister(&catalogCollector{log: log, src: src, timeout: timeout})
+}
+
+// InstrumentProvider records the latency and outcome of every call to next.
+func (m *Metrics) InstrumentProvider(next enrichment.Provider) enrichment.Provider {
+	return &instrumentedProvider{next: next, calls: m.enrichment}
+}
+
+type instrumentedProvider struct {
+	next  enrichment.Provider
+	calls *prometheus.HistogramVec
+}
+
+func (p *instrumentedProvider) FetchTrackInfo(ctx context.Context, songName, groupName string) (*utils.TrackInfo, error) {
+	start := time.Now()
+	info, err := p.next.FetchTrackInfo(ctx, songName, groupName)
+
+	outcome := "ok"
+	switch {
+	case errors.Is(err, enrichment.ErrTrackNotFound):
+		outcome = "not_found"
+	case err != nil:
+		outcome = "error"
+	}
+	p.calls.WithLabelValues(outcome).Observe(time.Since(start).Seconds())
+
+	return info, err
+}
+
+var (
+	songsDesc = prometheus.NewDesc(namespace+"_catalog_songs",
+		"Number of songs in the catalog.", nil, nil)
+	groupsDesc = prometheus.NewDesc(namespace+"_catalog_groups",
+		"Number of groups in the catalog.", nil, nil)
+	missingLyricsDesc = prometheus.NewDesc(namespace+"_catalog_songs_missing_lyrics",
+		"Number of songs without lyrics.", nil, nil)
+)
+
+type catalogCollector struct {
+	log     *slog.Logger
+	src     CatalogSource
+	timeout time.Duration
+}
+
+func (c *catalogCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- songsDesc
+	ch <- groupsDesc
+	ch <- missingLyricsDesc
+}
+
+func (c *catalogCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	stats, err := c.src.CatalogStats(ctx)
+	if err != nil {
+		c.log.Error("failed to collect catalog metrics", slog.String("op", "metrics.catalogCollector.Collect"), sl.Err(err))
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(songsDesc, prometheus.GaugeValue, float64(stats.Songs))
+	ch <- prometheus.MustNewConstMetric(groupsDesc, prometheus.GaugeValue, float64(stats.Groups))
+	ch <- prometheus.MustNewConstMetric(missingLyricsDesc, prometheus.GaugeValue, float64(stats.MissingLyrics))
+}
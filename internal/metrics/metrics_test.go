@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestObserveRequestBoundsMethods(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/songs", func(http.ResponseWriter, *http.Request) {})
+	m := New(mux)
+
+	for _, method := range []string{http.MethodGet, "PROPFIND", "X-RANDOM-1", "X-RANDOM-2"} {
+		m.ObserveRequest(httptest.NewRequest(method, "/songs", nil), http.StatusOK, 10, time.Millisecond)
+	}
+
+	families, err := m.registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+	var methods []string
+	for _, family := range families {
+		if family.GetName() != "songlib_http_request_duration_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "method" {
+					methods = append(methods, label.GetValue())
+				}
+			}
+		}
+	}
+	if strings.Join(methods, ",") != "GET,OTHER" {
+		t.Errorf("method labels = %v, want [GET OTHER]", methods)
+	}
+}
+
+func TestMethodLabel(t *testing.T) {
+	for method, want := range map[string]string{
+		http.MethodGet:          http.MethodGet,
+		http.MethodDelete:       http.MethodDelete,
+		"get":                   methodOther,
+		"PROPFIND":              methodOther,
+		strings.Repeat("X", 64): methodOther,
+	} {
+		if got := methodLabel(method); got != want {
+			t.Errorf("methodLabel(%q) = %q, want %q", method, got, want)
+		}
+	}
+}
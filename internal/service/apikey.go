@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/lib/apikey"
+	"github.com/2pizzzza/TestTask/internal/lib/logger/sl"
+	"github.com/2pizzzza/TestTask/internal/storage"
+)
+
+// lastUsedResolution limits how often using a key is written back, so a busy
+// key does not turn every request into a write.
+const lastUsedResolution = time.Minute
+
+var (
+	ErrInvalidAPIKey = errors.New("invalid api key")
+	// ErrUnauthenticated is returned for unknown and revoked API keys and
+	// for invalid or expired tokens alike.
+	ErrUnauthenticated = errors.New("invalid credentials")
+)
+
+var apiKeyScopes = []string{models.ScopeSongsRead, models.ScopeSongsWrite, models.ScopeSongsModerate, models.ScopeAdmin}
+
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, key models.APIKey, hash string) (models.APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int64) (models.APIKey, error)
+	APIKeyByHash(ctx context.Context, hash string) (models.APIKey, error)
+	TouchAPIKey(ctx context.Context, id int64, at time.Time) error
+}
+
+type APIKeyRep struct {
+	log  *slog.Logger
+	repo APIKeyRepository
+}
+
+func NewAPIKeys(log *slog.Logger, repo APIKeyRepository) *APIKeyRep {
+	return &APIKeyRep{
+		log:  log,
+		repo: repo,
+	}
+}
+
+// logger prefers the request-scoped logger carried by ctx.
+func (s *APIKeyRep) logger(ctx context.Context) *slog.Logger {
+	return sl.FromContext(ctx, s.log)
+}
+
+// CreateAPIKey generates a key with the given scopes. The key is returned
+// only here; the storage keeps its hash.
+func (s *APIKeyRep) CreateAPIKey(ctx context.Context, name string, scopes []string) (models.APIKey, string, error) {
+	const op = "service.apikey.CreateAPIKey"
+
+	log := s.logger(ctx).With(
+		slog.String("op", op),
+	)
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return models.APIKey{}, "", fmt.Errorf("%s: %w: name is required", op, ErrInvalidAPIKey)
+	}
+	if len(scopes) == 0 {
+		return models.APIKey{}, "", fmt.Errorf("%s: %w: at least one scope is required", op, ErrInvalidAPIKey)
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(apiKeyScopes, scope) {
+			return models.APIKey{}, "", fmt.Errorf("%s: %w: unknown scope %q, use one of %v",
+				op, ErrInvalidAPIKey, scope, apiKeyScopes)
+		}
+	}
+
+	secret, err := apikey.Generate()
+	if err != nil {
+		return models.APIKey{}, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	scopes = slices.Clone(scopes)
+	slices.Sort(scopes)
+	key := models.APIKey{Name: name, Prefix: apikey.Prefix(secret), Scopes: slices.Compact(scopes)}
+	created, err := s.repo.CreateAPIKey(ctx, key, apikey.Hash(secret))
+	if err != nil {
+		log.Error("failed to create api key", sl.Err(err))
+		return models.APIKey{}, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("the api key was created", slog.Int64("api_key_id", created.Id))
+
+	return created, secret, nil
+}
+
+func (s *APIKeyRep) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	const op = "service.apikey.ListAPIKeys"
+
+	keys, err := s.repo.ListAPIKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return keys, nil
+}
+
+func (s *APIKeyRep) RevokeAPIKey(ctx context.Context, id int64) (models.APIKey, error) {
+	const op = "service.apikey.RevokeAPIKey"
+
+	key, err := s.repo.RevokeAPIKey(ctx, id)
+	if err != nil {
+		return models.APIKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.logger(ctx).Info("the api key was revoked", slog.String("op", op), slog.Int64("api_key_id", id))
+
+	return key, nil
+}
+
+// Authenticate returns the active key matching secret as a principal and
+// records its use. A failure to record the use is logged and does not reject
+// the key.
+func (s *APIKeyRep) Authenticate(ctx context.Context, secret string) (models.Principal, error) {
+	const op = "service.apikey.Authenticate"
+
+	if !apikey.Valid(secret) {
+		return models.Principal{}, fmt.Errorf("%s: %w", op, ErrUnauthenticated)
+	}
+
+	key, err := s.repo.APIKeyByHash(ctx, apikey.Hash(secret))
+	if errors.Is(err, storage.ErrAPIKeyNotFound) || err == nil && key.RevokedAt != nil {
+		return models.Principal{}, fmt.Errorf("%s: %w", op, ErrUnauthenticated)
+	}
+	if err != nil {
+		return models.Principal{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	now := time.Now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedResolution {
+		if err := s.repo.TouchAPIKey(ctx, key.Id, now); err != nil {
+			s.logger(ctx).Warn("failed to record api key use", slog.String("op", op),
+				slog.Int64("api_key_id", key.Id), sl.Err(err))
+		}
+	}
+
+	return models.Principal{APIKeyId: key.Id, Name: key.Name, Scopes: key.Scopes}, nil
+}
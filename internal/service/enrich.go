@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/enrichment"
+	"github.com/2pizzzza/TestTask/internal/lib/logger/sl"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Checkpoint stores the progress of a re-enrichment run.
+type Checkpoint interface {
+	Load() (int64, error)
+	Save(id int64) error
+	Clear() error
+}
+
+type enrichResult struct {
+	songID   int64
+	filled   bool
+	notFound bool
+	err      error
+}
+
+// ReEnrich scans the catalog for songs with missing details and fills them
+// from the enrichment provider. Progress is saved to cp after every batch,
+// so a later run resumes where an interrupted one stopped; cp may be nil.
+func (s *SongRep) ReEnrich(
+	ctx context.Context, opts models.EnrichOptions, cp Checkpoint) (models.EnrichSummary, error) {
+
+	const op = "service.enrich.ReEnrich"
+
+	ctx, span := tracer.Start(ctx, op)
+	defer span.End()
+
+	log := s.logger(ctx).With(
+		slog.String("op", op),
+	)
+
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 1
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+
+	summary := models.EnrichSummary{DryRun: opts.DryRun, Failures: []models.EnrichFailure{}}
+
+	var afterId int64
+	if cp != nil {
+		id, err := cp.Load()
+		if err != nil {
+			return summary, fmt.Errorf("%s: %w", op, err)
+		}
+		afterId = id
+		if afterId > 0 {
+			log.Info("resuming from checkpoint", slog.Int64("after_id", afterId))
+		}
+	}
+
+	var tick <-chan time.Time
+	if opts.Rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / opts.Rate))
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		songs, err := s.songRep.ListIncomplete(ctx, afterId, opts.BatchSize)
+		if err != nil {
+			return summary, fmt.Errorf("%s: %w", op, err)
+		}
+		if len(songs) == 0 {
+			break
+		}
+
+		jobs := make(chan *models.Song)
+		results := make(chan enrichResult)
+
+		var wg sync.WaitGroup
+		for i := 0; i < opts.Concurrency; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for song := range jobs {
+					results <- s.enrichOne(ctx, song, opts.DryRun)
+				}
+			}()
+		}
+
+		go func() {
+			defer close(jobs)
+			for _, song := range songs {
+				if tick != nil {
+					select {
+					case <-tick:
+					case <-ctx.Done():
+						return
+					}
+				}
+				select {
+				case jobs <- song:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+
+		go func() {
+			wg.Wait()
+			close(results)
+		}()
+
+		for res := range results {
+			summary.Scanned++
+			switch {
+			case res.err != nil:
+				summary.Failed++
+				summary.Failures = append(summary.Failures, models.EnrichFailure{SongID: res.songID, Error: res.err.Error()})
+				log.Error("failed to enrich song", slog.Int64("song_id", res.songID), sl.Err(res.err))
+			case res.notFound:
+				summary.NotFound++
+			case res.filled:
+				summary.Filled++
+			}
+		}
+
+		if err := ctx.Err(); err != nil {
+			log.Info("re-enrichment interrupted", slog.Int64("after_id", afterId))
+			return summary, fmt.Errorf("%s: %w", op, err)
+		}
+
+		afterId = songs[len(songs)-1].Id
+		if cp != nil && !opts.DryRun {
+			if err := cp.Save(afterId); err != nil {
+				return summary, fmt.Errorf("%s: %w", op, err)
+			}
+		}
+	}
+
+	if cp != nil && !opts.DryRun {
+		if err := cp.Clear(); err != nil {
+			return summary, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	log.Info("re-enrichment finished",
+		slog.Int("scanned", summary.Scanned),
+		slog.Int("filled", summary.Filled),
+		slog.Int("not_found", summary.NotFound),
+		slog.Int("failed", summary.Failed),
+	)
+
+	return summary, nil
+}
+
+func (s *SongRep) enrichOne(ctx context.Context, song *models.Song, dryRun bool) enrichResult {
+	ctx, span := tracer.Start(ctx, "service.enrich.enrichOne", trace.WithAttributes(attribute.Int64("song.id", song.Id)))
+	defer span.End()
+
+	res := enrichResult{songID: song.Id}
+
+	info, err := s.enricher.FetchTrackInfo(ctx, song.SongName, song.GroupName.GroupName)
+	if err != nil {
+		if errors.Is(err, enrichment.ErrTrackNotFound) {
+			res.notFound = true
+			return res
+		}
+		res.err = err
+		return res
+	}
+
+	details := models.SongDetails{
+		ReleaseDate:  info.ReleaseDate,
+		Link:         info.SpotifyURL,
+		Lyrics:       enrichment.LyricsText(info),
+		LyricsSource: info.LyricsSource,
+		Links:        enrichment.Links(info),
+	}
+
+	res.filled = (song.ReleaseDate == "" && details.ReleaseDate != "") ||
+		(song.Link == "" && details.Link != "") ||
+		(song.Lyrics == "" && details.Lyrics != "")
+
+	if !res.filled || dryRun {
+		return res
+	}
+
+	if err := s.songRep.FillDetails(ctx, song.Id, details); err != nil {
+		res.err = err
+	}
+
+	return res
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/lib/logger/sl"
+)
+
+var ErrInvalidMerge = errors.New("invalid merge")
+
+// MergeGroups folds the from groups into the into group. Songs whose title
+// already exists in the target are reported as conflicts and left alone.
+func (s *SongRep) MergeGroups(
+	ctx context.Context, from []string, into string, dryRun bool) (models.GroupMergeResult, error) {
+
+	const op = "service.group.MergeGroups"
+
+	ctx, span := tracer.Start(ctx, op)
+	defer span.End()
+
+	log := s.logger(ctx).With(
+		slog.String("op", op),
+	)
+
+	into = strings.TrimSpace(into)
+	if into == "" {
+		return models.GroupMergeResult{}, fmt.Errorf("%s: %w: target group is required", op, ErrInvalidMerge)
+	}
+	if len(from) == 0 {
+		return models.GroupMergeResult{}, fmt.Errorf("%s: %w: no groups to merge", op, ErrInvalidMerge)
+	}
+	seen := make(map[string]bool, len(from))
+	for _, name := range from {
+		if name == into {
+			return models.GroupMergeResult{}, fmt.Errorf("%s: %w: cannot merge %q into itself", op, ErrInvalidMerge, name)
+		}
+		if seen[name] {
+			return models.GroupMergeResult{}, fmt.Errorf("%s: %w: %q listed twice", op, ErrInvalidMerge, name)
+		}
+		seen[name] = true
+	}
+
+	result, err := s.groupRep.MergeGroups(ctx, from, into, dryRun)
+	if err != nil {
+		log.Error("failed to merge groups", sl.Err(err))
+		return models.GroupMergeResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("groups merged",
+		slog.String("into", into),
+		slog.Int("moved", len(result.Moved)),
+		slog.Int("conflicts", len(result.Conflicts)),
+		slog.Bool("dry_run", dryRun),
+	)
+
+	return result, nil
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/lib/logger/sl"
+	"github.com/2pizzzza/TestTask/internal/utils"
+)
+
+var ErrInvalidLink = errors.New("invalid link")
+
+func validateLink(req models.SongLinkReq) error {
+	if !models.ValidPlatform(req.Platform) {
+		return fmt.Errorf("%w: unknown platform %q", ErrInvalidLink, req.Platform)
+	}
+	if len(req.URL) > 2048 || !utils.IsHTTPURL(req.URL) {
+		return fmt.Errorf("%w: url must be an absolute http(s) url", ErrInvalidLink)
+	}
+	return nil
+}
+
+func (s *SongRep) AddSongLink(
+	ctx context.Context, songId int64, req models.SongLinkReq) (models.SongLink, error) {
+
+	const op = "service.link.AddSongLink"
+
+	ctx, span := tracer.Start(ctx, op)
+	defer span.End()
+
+	log := s.logger(ctx).With(
+		slog.String("op", op),
+	)
+
+	if err := validateLink(req); err != nil {
+		return models.SongLink{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	link, err := s.linkRep.AddLink(ctx, songId, req.Platform, req.URL)
+	if err != nil {
+		log.Error("failed to add link", sl.Err(err))
+		return models.SongLink{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("the link was added")
+
+	return link, nil
+}
+
+func (s *SongRep) GetSongLinks(ctx context.Context, songId int64) ([]models.SongLink, error) {
+	const op = "service.link.GetSongLinks"
+
+	ctx, span := tracer.Start(ctx, op)
+	defer span.End()
+
+	log := s.logger(ctx).With(
+		slog.String("op", op),
+	)
+
+	links, err := s.linkRep.GetLinks(ctx, songId)
+	if err != nil {
+		log.Error("failed to get links", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return links, nil
+}
+
+func (s *SongRep) UpdateSongLink(
+	ctx context.Context, songId, linkId int64, req models.SongLinkReq) (models.SongLink, error) {
+
+	const op = "service.link.UpdateSongLink"
+
+	ctx, span := tracer.Start(ctx, op)
+	defer span.End()
+
+	log := s.logger(ctx).With(
+		slog.String("op", op),
+	)
+
+	if err := validateLink(req); err != nil {
+		return models.SongLink{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	link, err := s.linkRep.UpdateLink(ctx, songId, linkId, req.Platform, req.URL)
+	if err != nil {
+		log.Error("failed to update link", sl.Err(err))
+		return models.SongLink{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("the link was updated")
+
+	return link, nil
+}
+
+func (s *SongRep) DeleteSongLink(ctx context.Context, songId, linkId int64) error {
+	const op = "service.link.DeleteSongLink"
+
+	ctx, span := tracer.Start(ctx, op)
+	defer span.End()
+
+	log := s.logger(ctx).With(
+		slog.String("op", op),
+	)
+
+	if err := s.linkRep.RemoveLink(ctx, songId, linkId); err != nil {
+		log.Error("failed to delete link", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("the link was removed")
+
+	return nil
+}
+
+func (s *SongRep) GetBrokenLinks(ctx context.Context, limit, offset int) ([]models.SongLink, error) {
+	const op = "service.link.GetBrokenLinks"
+
+	ctx, span := tracer.Start(ctx, op)
+	defer span.End()
+
+	log := s.logger(ctx).With(
+		slog.String("op", op),
+	)
+
+	links, err := s.linkRep.GetBrokenLinks(ctx, limit, offset)
+	if err != nil {
+		log.Error("failed to get broken links", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return links, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/enrichment"
 	"github.com/2pizzzza/TestTask/internal/lib/logger/sl"
 	"github.com/2pizzzza/TestTask/internal/utils"
 	"log/slog"
@@ -15,16 +16,31 @@ func (s *SongRep) CreateSong(
 
 	const op = "service.song.Create"
 
-	log := s.log.With(
+	ctx, span := tracer.Start(ctx, op)
+	defer span.End()
+
+	log := s.logger(ctx).With(
 		slog.String("op: ", op),
 	)
-	trcInfo, err := utils.FetchTrackInfo(req.SongName, req.GroupName)
+	trcInfo, err := s.enricher.FetchTrackInfo(ctx, req.SongName, req.GroupName)
 	if err != nil {
 		log.Error("Failed get data about song", sl.Err(err))
-		trcInfo.SpotifyURL = ""
-		trcInfo.ReleaseDate = ""
+		trcInfo = &utils.TrackInfo{}
+	}
+	details := models.SongDetails{
+		ReleaseDate: trcInfo.ReleaseDate,
+		Link:        trcInfo.SpotifyURL,
+		Links:       enrichment.Links(trcInfo),
 	}
-	msg, err := s.songRep.Save(ctx, req.GroupName, req.SongName, trcInfo.ReleaseDate, trcInfo.SpotifyURL)
+	if lyrics := enrichment.LyricsText(trcInfo); lyrics != "" {
+		details.Lyrics = lyrics
+		details.LyricsSource = trcInfo.LyricsSource
+	}
+
+	storeCtx, cancel := s.storeContext(ctx)
+	defer cancel()
+
+	msg, err := s.songRep.Save(storeCtx, req.GroupName, req.SongName, details)
 
 	if err != nil {
 		log.Error(msg, sl.Err(err))
@@ -37,17 +53,40 @@ func (s *SongRep) CreateSong(
 	return msg, nil
 }
 
+// UpdateSong keeps the current group or title when the request leaves it
+// empty. Reading the song and updating it run as one unit of work.
 func (s *SongRep) UpdateSong(
 	ctx context.Context, req models.SongUpdateReq) (models.Song, error) {
 
 	const op = "service.song.UpdateSong"
 
-	log := s.log.With(
+	ctx, span := tracer.Start(ctx, op)
+	defer span.End()
+
+	log := s.logger(ctx).With(
 		slog.String("op: ", op),
 	)
 
-	song, err := s.songRep.Update(ctx, req.Id, req.NewGroupName, req.NewSongName)
-
+	var song models.Song
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		groupName, songName := req.NewGroupName, req.NewSongName
+		if groupName == "" || songName == "" {
+			current, err := s.songRep.GetById(ctx, req.Id)
+			if err != nil {
+				return err
+			}
+			if groupName == "" {
+				groupName = current.GroupName.GroupName
+			}
+			if songName == "" {
+				songName = current.SongName
+			}
+		}
+
+		var err error
+		song, err = s.songRep.Update(ctx, req.Id, groupName, songName)
+		return err
+	})
 	if err != nil {
 		log.Error("failed update song", sl.Err(err))
 
@@ -64,7 +103,10 @@ func (s *SongRep) GetSongByID(
 
 	const op = "service.song.GetSongById"
 
-	log := s.log.With(
+	ctx, span := tracer.Start(ctx, op)
+	defer span.End()
+
+	log := s.logger(ctx).With(
 		slog.String("op: ", op),
 	)
 	song, err := s.songRep.GetById(ctx, id)
@@ -84,7 +126,10 @@ func (s *SongRep) DeleteSong(
 
 	const op = "service.song.DeleteSong"
 
-	log := s.log.With(
+	ctx, span := tracer.Start(ctx, op)
+	defer span.End()
+
+	log := s.logger(ctx).With(
 		slog.String("op: ", op),
 	)
 
@@ -102,7 +147,10 @@ func (s *SongRep) DeleteSong(
 func (s *SongRep) GetAllSong(ctx context.Context, filter models.SongFilter, limit, offset int) (songs []*models.Song, err error) {
 	const op = "service.song.GetAllSong"
 
-	log := s.log.With(
+	ctx, span := tracer.Start(ctx, op)
+	defer span.End()
+
+	log := s.logger(ctx).With(
 		slog.String("op: ", op),
 	)
 
@@ -121,7 +169,10 @@ func (s *SongRep) GetLyricsByIDWithPagination(
 
 	const op = "service.song.GetLyricsByIDWithPagination"
 
-	log := s.log.With(
+	ctx, span := tracer.Start(ctx, op)
+	defer span.End()
+
+	log := s.logger(ctx).With(
 		slog.String("op", op),
 	)
 
@@ -146,7 +197,7 @@ func (s *SongRep) GetLyricsByIDWithPagination(
 	resp := models.LyricsResponse{
 		SongID:   song.Id,
 		Title:    song.SongName,
-		Group:    song.GroupName,
+		Group:    song.GroupName.GroupName,
 		Page:     page,
 		Limit:    limit,
 		Total:    totalCouplets,
@@ -157,3 +208,84 @@ func (s *SongRep) GetLyricsByIDWithPagination(
 
 	return resp, nil
 }
+
+// SetLyrics replaces the lyrics by hand; enrichment never overwrites them.
+func (s *SongRep) SetLyrics(ctx context.Context, id int64, lyrics string) (models.Song, error) {
+	const op = "service.song.SetLyrics"
+
+	ctx, span := tracer.Start(ctx, op)
+	defer span.End()
+
+	log := s.logger(ctx).With(
+		slog.String("op", op),
+	)
+
+	source := models.LyricsSourceManual
+	if lyrics == "" {
+		source = ""
+	}
+
+	song, err := s.songRep.SetLyrics(ctx, id, lyrics, source)
+	if err != nil {
+		log.Error("failed to set lyrics", sl.Err(err))
+		return models.Song{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("the lyrics were set")
+
+	return song, nil
+}
+
+// LyricsRevisions lists the lyrics a song had before each change, newest
+// first.
+func (s *SongRep) LyricsRevisions(ctx context.Context, id int64) ([]models.LyricsRevision, error) {
+	const op = "service.song.LyricsRevisions"
+
+	ctx, span := tracer.Start(ctx, op)
+	defer span.End()
+
+	log := s.logger(ctx).With(
+		slog.String("op", op),
+	)
+
+	revisions, err := s.songRep.LyricsRevisions(ctx, id)
+	if err != nil {
+		log.Error("failed to get lyrics revisions", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return revisions, nil
+}
+
+// RollbackLyrics restores the lyrics of a revision. The lyrics it replaces
+// become a revision of their own, so a rollback can itself be undone.
+func (s *SongRep) RollbackLyrics(ctx context.Context, id, revisionId int64) (models.Song, error) {
+	const op = "service.song.RollbackLyrics"
+
+	ctx, span := tracer.Start(ctx, op)
+	defer span.End()
+
+	log := s.logger(ctx).With(
+		slog.String("op", op),
+		slog.Int64("revision_id", revisionId),
+	)
+
+	var song models.Song
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		revision, err := s.songRep.LyricsRevision(ctx, id, revisionId)
+		if err != nil {
+			return err
+		}
+
+		song, err = s.songRep.SetLyrics(ctx, id, revision.Lyrics, revision.LyricsSource)
+		return err
+	})
+	if err != nil {
+		log.Error("failed to roll back lyrics", sl.Err(err))
+		return models.Song{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("the lyrics were rolled back")
+
+	return song, nil
+}
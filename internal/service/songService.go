@@ -3,12 +3,26 @@ package service
 import (
 	"context"
 	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/enrichment"
+	"github.com/2pizzzza/TestTask/internal/lib/logger/sl"
+	"go.opentelemetry.io/otel"
 	"log/slog"
+	"time"
 )
 
+var tracer = otel.Tracer("github.com/2pizzzza/TestTask/internal/service")
+
 type SongRep struct {
-	log     *slog.Logger
-	songRep SongRepository
+	log      *slog.Logger
+	songRep  SongRepository
+	linkRep  LinkRepository
+	groupRep GroupRepository
+	tx       TxManager
+	enricher enrichment.Provider
+
+	// storeTimeout bounds the storage calls of CreateSong, which may also
+	// wait on the enrichment API; that call is bounded by its own client.
+	storeTimeout time.Duration
 }
 
 type SongService interface {
@@ -18,21 +32,82 @@ type SongService interface {
 	DeleteSong(ctx context.Context, id int64) (string, error)
 	GetAllSong(ctx context.Context, filter models.SongFilter, limit, offset int) (songs []*models.Song, err error)
 	GetLyricsByIDWithPagination(ctx context.Context, id int64, page, limit int) (models.LyricsResponse, error)
+	SetLyrics(ctx context.Context, id int64, lyrics string) (models.Song, error)
+	LyricsRevisions(ctx context.Context, id int64) ([]models.LyricsRevision, error)
+	RollbackLyrics(ctx context.Context, id, revisionId int64) (models.Song, error)
+}
+
+type LinkService interface {
+	AddSongLink(ctx context.Context, songId int64, req models.SongLinkReq) (models.SongLink, error)
+	GetSongLinks(ctx context.Context, songId int64) ([]models.SongLink, error)
+	UpdateSongLink(ctx context.Context, songId, linkId int64, req models.SongLinkReq) (models.SongLink, error)
+	DeleteSongLink(ctx context.Context, songId, linkId int64) error
+	GetBrokenLinks(ctx context.Context, limit, offset int) ([]models.SongLink, error)
 }
 
 type SongRepository interface {
-	Save(ctx context.Context, groupName, songName, releaseDate, link string) (string, error)
+	Save(ctx context.Context, groupName, songName string, details models.SongDetails) (string, error)
 	GetById(ctx context.Context, id int64) (models.Song, error)
 	Update(ctx context.Context, id int64, newGroupName, newSongName string) (models.Song, error)
 	Remove(ctx context.Context, id int64) (string, error)
 	GetAll(ctx context.Context, filter models.SongFilter, limit, offset int) (songs []*models.Song, err error)
+	ListIncomplete(ctx context.Context, afterId int64, limit int) (songs []*models.Song, err error)
+	FillDetails(ctx context.Context, id int64, details models.SongDetails) error
+	SetLyrics(ctx context.Context, id int64, lyrics, source string) (models.Song, error)
+	LyricsRevisions(ctx context.Context, songId int64) ([]models.LyricsRevision, error)
+	LyricsRevision(ctx context.Context, songId, revisionId int64) (models.LyricsRevision, error)
+}
+
+type GroupRepository interface {
+	MergeGroups(ctx context.Context, from []string, into string, dryRun bool) (models.GroupMergeResult, error)
+}
+
+// TxManager runs several repository calls as one unit of work. Calls made
+// with the context passed to fn commit together when fn returns nil and roll
+// back otherwise; fn may run again after a serialization failure, so it must
+// not have side effects outside the storage.
+type TxManager interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type LinkRepository interface {
+	AddLink(ctx context.Context, songId int64, platform, url string) (models.SongLink, error)
+	GetLinks(ctx context.Context, songId int64) ([]models.SongLink, error)
+	UpdateLink(ctx context.Context, songId, linkId int64, platform, url string) (models.SongLink, error)
+	RemoveLink(ctx context.Context, songId, linkId int64) error
+	GetBrokenLinks(ctx context.Context, limit, offset int) ([]models.SongLink, error)
 }
 
 func New(
 	log slog.Logger,
-	song SongRepository) *SongRep {
+	song SongRepository,
+	links LinkRepository,
+	groups GroupRepository,
+	tx TxManager,
+	enricher enrichment.Provider,
+	storeTimeout time.Duration) *SongRep {
 	return &SongRep{
-		log:     &log,
-		songRep: song,
+		log:      &log,
+		songRep:  song,
+		linkRep:  links,
+		groupRep: groups,
+		tx:       tx,
+		enricher: enricher,
+
+		storeTimeout: storeTimeout,
+	}
+}
+
+// storeContext derives the context for storage calls from ctx, applying the
+// store timeout when one is set.
+func (s *SongRep) storeContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.storeTimeout <= 0 {
+		return context.WithCancel(ctx)
 	}
+	return context.WithTimeout(ctx, s.storeTimeout)
+}
+
+// logger prefers the request-scoped logger carried by ctx.
+func (s *SongRep) logger(ctx context.Context) *slog.Logger {
+	return sl.FromContext(ctx, s.log)
 }
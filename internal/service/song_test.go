@@ -0,0 +1,175 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/enrichment"
+	"github.com/2pizzzza/TestTask/internal/enrichstub"
+	"github.com/2pizzzza/TestTask/internal/service"
+	"github.com/2pizzzza/TestTask/internal/storage"
+	"github.com/2pizzzza/TestTask/internal/storage/memory"
+	"github.com/2pizzzza/TestTask/internal/utils"
+)
+
+// storeTimeout is far below the stub latency used by the tests that need it.
+const storeTimeout = 50 * time.Millisecond
+
+// newSongService wires the service to an empty memory store and a stub
+// enrichment API serving fixtures.
+func newSongService(t *testing.T, fixtures []enrichstub.Fixture, opts enrichstub.Options) (*service.SongRep, *memory.Storage) {
+	t.Helper()
+
+	stub := enrichstub.NewServer(fixtures, opts)
+	t.Cleanup(stub.Close)
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	db := memory.New()
+	return service.New(*log, db, db, db, db, enrichment.NewClient(stub.URL+"/search"), storeTimeout), db
+}
+
+func onlySong(t *testing.T, s *service.SongRep) *models.Song {
+	t.Helper()
+
+	songs, err := s.GetAllSong(context.Background(), models.SongFilter{}, 10, 0)
+	if err != nil {
+		t.Fatalf("GetAllSong: %v", err)
+	}
+	if len(songs) != 1 {
+		t.Fatalf("GetAllSong returned %d songs, want 1", len(songs))
+	}
+	return songs[0]
+}
+
+func TestCreateSongStoresEnrichment(t *testing.T) {
+	s, db := newSongService(t, []enrichstub.Fixture{{
+		Artist: "Muse",
+		Song:   "Supermassive Black Hole",
+		Response: utils.TrackInfo{
+			ReleaseDate:  "16.07.2006",
+			SpotifyURL:   "https://open.spotify.com/track/1",
+			LyricsSource: "stub",
+			LyricsSections: []utils.LyricsSection{
+				{Type: "verse", Text: "Ooh baby, don't you know I suffer?"},
+				{Type: "chorus", Text: "Glaciers melting in the dead of night"},
+			},
+			Links: map[string]string{
+				models.PlatformYoutube: "https://www.youtube.com/watch?v=Xsp3_a-PMTw",
+				"myspace":              "https://myspace.example/muse",
+			},
+		},
+	}}, enrichstub.Options{Seed: 1})
+	ctx := context.Background()
+
+	if _, err := s.CreateSong(ctx, models.SongCreateReq{GroupName: "Muse", SongName: "Supermassive Black Hole"}); err != nil {
+		t.Fatalf("CreateSong: %v", err)
+	}
+
+	song := onlySong(t, s)
+	if song.ReleaseDate != "16.07.2006" {
+		t.Errorf("ReleaseDate = %q, want %q", song.ReleaseDate, "16.07.2006")
+	}
+	if song.Link != "https://open.spotify.com/track/1" {
+		t.Errorf("Link = %q, want the spotify url", song.Link)
+	}
+	wantLyrics := "Ooh baby, don't you know I suffer?\n\nGlaciers melting in the dead of night"
+	if song.Lyrics != wantLyrics || song.LyricsSource != "stub" {
+		t.Errorf("lyrics = %q from %q, want %q from %q", song.Lyrics, song.LyricsSource, wantLyrics, "stub")
+	}
+
+	links, err := db.GetLinks(ctx, song.Id)
+	if err != nil {
+		t.Fatalf("GetLinks: %v", err)
+	}
+	got := make(map[string]string, len(links))
+	for _, l := range links {
+		got[l.Platform] = l.URL
+	}
+	want := map[string]string{
+		models.PlatformSpotify: "https://open.spotify.com/track/1",
+		models.PlatformYoutube: "https://www.youtube.com/watch?v=Xsp3_a-PMTw",
+	}
+	if len(got) != len(want) || got[models.PlatformSpotify] != want[models.PlatformSpotify] ||
+		got[models.PlatformYoutube] != want[models.PlatformYoutube] {
+		t.Errorf("links = %v, want %v without the unknown platform", got, want)
+	}
+}
+
+func TestCreateSongWithoutEnrichment(t *testing.T) {
+	s, _ := newSongService(t, nil, enrichstub.Options{Seed: 1})
+
+	if _, err := s.CreateSong(context.Background(), models.SongCreateReq{GroupName: "Nobody", SongName: "Unknown"}); err != nil {
+		t.Fatalf("CreateSong: %v", err)
+	}
+
+	song := onlySong(t, s)
+	if song.ReleaseDate != "" || song.Lyrics != "" || song.Link != "" {
+		t.Errorf("song = %+v, want it stored without details when the provider has none", song)
+	}
+}
+
+func TestCreateSongStoreTimeoutExcludesEnrichment(t *testing.T) {
+	s, _ := newSongService(t, []enrichstub.Fixture{{
+		Artist:   "Muse",
+		Song:     "Uprising",
+		Response: utils.TrackInfo{ReleaseDate: "07.09.2009"},
+	}}, enrichstub.Options{Seed: 1, Latency: 4 * storeTimeout})
+
+	if _, err := s.CreateSong(context.Background(), models.SongCreateReq{GroupName: "Muse", SongName: "Uprising"}); err != nil {
+		t.Fatalf("CreateSong with a slow enrichment API: %v", err)
+	}
+	if song := onlySong(t, s); song.ReleaseDate != "07.09.2009" {
+		t.Errorf("ReleaseDate = %q, want the enriched date", song.ReleaseDate)
+	}
+}
+
+func TestRollbackLyrics(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newSongService(t, []enrichstub.Fixture{{
+		Artist: "Muse",
+		Song:   "Uprising",
+		Response: utils.TrackInfo{
+			LyricsSource:   "stub",
+			LyricsSections: []utils.LyricsSection{{Type: "verse", Text: "Paranoia is in bloom"}},
+		},
+	}}, enrichstub.Options{Seed: 1})
+
+	if _, err := s.CreateSong(ctx, models.SongCreateReq{GroupName: "Muse", SongName: "Uprising"}); err != nil {
+		t.Fatalf("CreateSong: %v", err)
+	}
+	id := onlySong(t, s).Id
+	if _, err := s.SetLyrics(ctx, id, "vandalised"); err != nil {
+		t.Fatalf("SetLyrics: %v", err)
+	}
+
+	revisions, err := s.LyricsRevisions(ctx, id)
+	if err != nil || len(revisions) != 1 {
+		t.Fatalf("LyricsRevisions = %+v, %v, want the enriched lyrics", revisions, err)
+	}
+
+	song, err := s.RollbackLyrics(ctx, id, revisions[0].Id)
+	if err != nil {
+		t.Fatalf("RollbackLyrics: %v", err)
+	}
+	if song.Lyrics != "Paranoia is in bloom" || song.LyricsSource != "stub" {
+		t.Errorf("song after rollback = %+v, want the enriched lyrics and source", song)
+	}
+
+	// The replaced lyrics are kept, so the rollback can be undone.
+	revisions, err = s.LyricsRevisions(ctx, id)
+	if err != nil {
+		t.Fatalf("LyricsRevisions: %v", err)
+	}
+	if len(revisions) != 2 || revisions[0].Lyrics != "vandalised" {
+		t.Errorf("LyricsRevisions after rollback = %+v, want the replaced lyrics first", revisions)
+	}
+
+	if _, err := s.RollbackLyrics(ctx, id, 999); !errors.Is(err, storage.ErrRevisionNotFound) {
+		t.Errorf("RollbackLyrics to a missing revision: err = %v, want ErrRevisionNotFound", err)
+	}
+}
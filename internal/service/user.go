@@ -0,0 +1,320 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/2pizzzza/TestTask/internal/config"
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/lib/logger/sl"
+	"github.com/2pizzzza/TestTask/internal/storage"
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	tokenIssuer       = "songLibraries"
+	minPasswordLength = 8
+	// bcrypt ignores everything after 72 bytes.
+	maxPasswordLength = 72
+)
+
+var (
+	ErrInvalidUser = errors.New("invalid user")
+	// ErrInvalidCredentials is returned for an unknown user and a wrong
+	// password alike.
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrInvalidToken       = errors.New("invalid or expired refresh token")
+	ErrRegistrationClosed = errors.New("registration is closed")
+)
+
+type AuthService interface {
+	Register(ctx context.Context, req models.CredentialsReq) (models.User, error)
+	Login(ctx context.Context, req models.CredentialsReq) (models.TokenResponse, error)
+	Refresh(ctx context.Context, refreshToken string) (models.TokenResponse, error)
+	Logout(ctx context.Context, refreshToken string) error
+}
+
+type UserRepository interface {
+	CreateUser(ctx context.Context, user models.User, passwordHash string) (models.User, error)
+	UserByName(ctx context.Context, username string) (models.User, string, error)
+	GetUser(ctx context.Context, id int64) (models.User, error)
+	ListUsers(ctx context.Context) ([]models.User, error)
+	SetUserRole(ctx context.Context, id int64, role string) (models.User, error)
+	SaveRefreshToken(ctx context.Context, userId int64, hash string, expiresAt time.Time) error
+	UseRefreshToken(ctx context.Context, hash string, at time.Time) (int64, error)
+}
+
+type UserRep struct {
+	log  *slog.Logger
+	repo UserRepository
+	tx   TxManager
+	cfg  config.AuthConfig
+}
+
+func NewUsers(log *slog.Logger, repo UserRepository, tx TxManager, cfg config.AuthConfig) *UserRep {
+	return &UserRep{
+		log:  log,
+		repo: repo,
+		tx:   tx,
+		cfg:  cfg,
+	}
+}
+
+// logger prefers the request-scoped logger carried by ctx.
+func (s *UserRep) logger(ctx context.Context) *slog.Logger {
+	return sl.FromContext(ctx, s.log)
+}
+
+// accessClaims are the claims of an access token. The role is read again
+// from the database only when the token is refreshed, so a role change
+// takes effect within the access token lifetime.
+type accessClaims struct {
+	Name string `json:"name"`
+	Role string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+func normalizeUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+func validateCredentials(username, password string) error {
+	if n := utf8.RuneCountInString(username); n < 3 || n > 64 {
+		return fmt.Errorf("%w: username must be 3 to 64 characters long", ErrInvalidUser)
+	}
+	if len(password) < minPasswordLength || len(password) > maxPasswordLength {
+		return fmt.Errorf("%w: password must be %d to %d bytes long", ErrInvalidUser, minPasswordLength, maxPasswordLength)
+	}
+	return nil
+}
+
+// Register creates a viewer account when registration is open.
+func (s *UserRep) Register(ctx context.Context, req models.CredentialsReq) (models.User, error) {
+	const op = "service.user.Register"
+
+	if !s.cfg.Registration {
+		return models.User{}, fmt.Errorf("%s: %w", op, ErrRegistrationClosed)
+	}
+
+	return s.CreateUser(ctx, req.Username, req.Password, models.RoleViewer)
+}
+
+// CreateUser creates an account with any role; it is meant for admins.
+func (s *UserRep) CreateUser(ctx context.Context, username, password, role string) (models.User, error) {
+	const op = "service.user.CreateUser"
+
+	log := s.logger(ctx).With(
+		slog.String("op", op),
+	)
+
+	username = normalizeUsername(username)
+	if err := validateCredentials(username, password); err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if !slices.Contains(models.Roles, role) {
+		return models.User{}, fmt.Errorf("%s: %w: unknown role %q, use one of %v", op, ErrInvalidUser, role, models.Roles)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	user, err := s.repo.CreateUser(ctx, models.User{Username: username, Role: role}, string(hash))
+	if err != nil {
+		if !errors.Is(err, storage.ErrUserExists) {
+			log.Error("failed to create user", sl.Err(err))
+		}
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("the user was created", slog.Int64("user_id", user.Id), slog.String("role", role))
+
+	return user, nil
+}
+
+// Login checks the password and issues an access and a refresh token.
+func (s *UserRep) Login(ctx context.Context, req models.CredentialsReq) (models.TokenResponse, error) {
+	const op = "service.user.Login"
+
+	user, hash, err := s.repo.UserByName(ctx, normalizeUsername(req.Username))
+	if errors.Is(err, storage.ErrUserNotFound) {
+		// Compare anyway so unknown names take as long as wrong passwords.
+		_ = bcrypt.CompareHashAndPassword(dummyHash(), []byte(req.Password))
+		return models.TokenResponse{}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+	}
+	if err != nil {
+		return models.TokenResponse{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(req.Password)); err != nil {
+		s.logger(ctx).Info("failed login", slog.String("op", op), slog.Int64("user_id", user.Id))
+		return models.TokenResponse{}, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
+	}
+
+	tokens, err := s.issue(ctx, user)
+	if err != nil {
+		return models.TokenResponse{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tokens, nil
+}
+
+// dummyHash is compared against when a login names no user.
+var dummyHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("songlib-dummy-password"), bcrypt.DefaultCost)
+	return hash
+})
+
+// Refresh exchanges a refresh token for a new pair. The old token is spent,
+// and the new access token carries the current role of the user.
+func (s *UserRep) Refresh(ctx context.Context, refreshToken string) (models.TokenResponse, error) {
+	const op = "service.user.Refresh"
+
+	var tokens models.TokenResponse
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		userId, err := s.repo.UseRefreshToken(ctx, hashToken(refreshToken), time.Now())
+		if err != nil {
+			return err
+		}
+
+		user, err := s.repo.GetUser(ctx, userId)
+		if err != nil {
+			return err
+		}
+
+		tokens, err = s.issue(ctx, user)
+		return err
+	})
+	if errors.Is(err, storage.ErrTokenNotFound) || errors.Is(err, storage.ErrUserNotFound) {
+		return models.TokenResponse{}, fmt.Errorf("%s: %w", op, ErrInvalidToken)
+	}
+	if err != nil {
+		return models.TokenResponse{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tokens, nil
+}
+
+// Logout spends the refresh token. The access token stays valid until it
+// expires.
+func (s *UserRep) Logout(ctx context.Context, refreshToken string) error {
+	const op = "service.user.Logout"
+
+	if _, err := s.repo.UseRefreshToken(ctx, hashToken(refreshToken), time.Now()); err != nil {
+		if errors.Is(err, storage.ErrTokenNotFound) {
+			return fmt.Errorf("%s: %w", op, ErrInvalidToken)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *UserRep) issue(ctx context.Context, user models.User) (models.TokenResponse, error) {
+	now := time.Now()
+
+	access, err := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims{
+		Name: user.Username,
+		Role: user.Role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    tokenIssuer,
+			Subject:   strconv.FormatInt(user.Id, 10),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.cfg.AccessTTL)),
+		},
+	}).SignedString([]byte(s.cfg.JWTSecret))
+	if err != nil {
+		return models.TokenResponse{}, err
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return models.TokenResponse{}, err
+	}
+	refresh := base64.RawURLEncoding.EncodeToString(b)
+	if err := s.repo.SaveRefreshToken(ctx, user.Id, hashToken(refresh), now.Add(s.cfg.RefreshTTL)); err != nil {
+		return models.TokenResponse{}, err
+	}
+
+	return models.TokenResponse{
+		AccessToken:  access,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(s.cfg.AccessTTL / time.Second),
+		RefreshToken: refresh,
+	}, nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Authenticate verifies an access token and returns the user it was issued
+// to with the scopes of their role.
+func (s *UserRep) Authenticate(_ context.Context, token string) (models.Principal, error) {
+	const op = "service.user.Authenticate"
+
+	var claims accessClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (any, error) {
+		return []byte(s.cfg.JWTSecret), nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(tokenIssuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return models.Principal{}, fmt.Errorf("%s: %w: %w", op, ErrUnauthenticated, err)
+	}
+
+	id, err := strconv.ParseInt(claims.Subject, 10, 64)
+	if err != nil {
+		return models.Principal{}, fmt.Errorf("%s: %w: bad subject", op, ErrUnauthenticated)
+	}
+
+	return models.Principal{UserId: id, Name: claims.Name, Scopes: models.RoleScopes(claims.Role)}, nil
+}
+
+func (s *UserRep) ListUsers(ctx context.Context) ([]models.User, error) {
+	const op = "service.user.ListUsers"
+
+	users, err := s.repo.ListUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return users, nil
+}
+
+// SetUserRole changes the role of a user; it applies to access tokens
+// issued from then on.
+func (s *UserRep) SetUserRole(ctx context.Context, id int64, role string) (models.User, error) {
+	const op = "service.user.SetUserRole"
+
+	if !slices.Contains(models.Roles, role) {
+		return models.User{}, fmt.Errorf("%s: %w: unknown role %q, use one of %v", op, ErrInvalidUser, role, models.Roles)
+	}
+
+	user, err := s.repo.SetUserRole(ctx, id, role)
+	if err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.logger(ctx).Info("the user role was changed", slog.String("op", op),
+		slog.Int64("user_id", id), slog.String("role", role))
+
+	return user, nil
+}
@@ -0,0 +1,168 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/2pizzzza/TestTask/internal/config"
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/service"
+	"github.com/2pizzzza/TestTask/internal/storage/sqlite"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const jwtSecret = "0123456789abcdef0123456789abcdef"
+
+func authConfig() config.AuthConfig {
+	return config.AuthConfig{
+		Enabled:      true,
+		JWTSecret:    jwtSecret,
+		AccessTTL:    time.Minute,
+		RefreshTTL:   time.Hour,
+		Registration: true,
+	}
+}
+
+// newUsers returns the user service on a fresh sqlite database, which is
+// also returned so tests can build services with other settings on it.
+func newUsers(t *testing.T, cfg config.AuthConfig) (*service.UserRep, *sqlite.Storage) {
+	t.Helper()
+
+	db, err := sqlite.New(context.Background(), config.SQLiteConfig{
+		Path:        filepath.Join(t.TempDir(), "users.db"),
+		BusyTimeout: time.Second,
+	})
+	if err != nil {
+		t.Fatalf("sqlite.New: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.MigrateUp(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil))); err != nil {
+		t.Fatalf("MigrateUp: %v", err)
+	}
+
+	return service.NewUsers(slog.New(slog.NewTextHandler(io.Discard, nil)), db, db, cfg), db
+}
+
+func login(t *testing.T, users *service.UserRep, username, password string) models.TokenResponse {
+	t.Helper()
+
+	tokens, err := users.Login(context.Background(), models.CredentialsReq{Username: username, Password: password})
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	return tokens
+}
+
+func TestUserAuthenticate(t *testing.T) {
+	users, db := newUsers(t, authConfig())
+	ctx := context.Background()
+
+	user, err := users.CreateUser(ctx, "alice", "correct horse", models.RoleEditor)
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if _, err := users.Login(ctx, models.CredentialsReq{Username: "alice", Password: "wrong horse"}); !errors.Is(err, service.ErrInvalidCredentials) {
+		t.Errorf("Login with a wrong password: err = %v, want ErrInvalidCredentials", err)
+	}
+
+	access := login(t, users, "alice", "correct horse").AccessToken
+	p, err := users.Authenticate(ctx, access)
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if p.UserId != user.Id || p.Name != "alice" || !slices.Equal(p.Scopes, models.RoleScopes(models.RoleEditor)) {
+		t.Errorf("Authenticate = %+v, want alice with the editor scopes", p)
+	}
+
+	otherSecret := authConfig()
+	otherSecret.JWTSecret = strings.Repeat("x", 32)
+	forged := login(t, service.NewUsers(slog.New(slog.NewTextHandler(io.Discard, nil)), db, db, otherSecret), "alice", "correct horse")
+
+	expiredCfg := authConfig()
+	expiredCfg.AccessTTL = -time.Minute
+	expired := login(t, service.NewUsers(slog.New(slog.NewTextHandler(io.Discard, nil)), db, db, expiredCfg), "alice", "correct horse")
+
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.RegisteredClaims{
+		Issuer:    "songLibraries",
+		Subject:   "1",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatalf("SignedString: %v", err)
+	}
+
+	rejected := map[string]string{
+		"garbage":       "not-a-token",
+		"tampered":      access[:strings.LastIndex(access, ".")+1] + "c2lnbmF0dXJl",
+		"other secret":  forged.AccessToken,
+		"expired":       expired.AccessToken,
+		"unsigned":      unsigned,
+		"refresh token": forged.RefreshToken,
+		"empty":         "",
+	}
+	for name, token := range rejected {
+		if _, err := users.Authenticate(ctx, token); !errors.Is(err, service.ErrUnauthenticated) {
+			t.Errorf("Authenticate(%s): err = %v, want ErrUnauthenticated", name, err)
+		}
+	}
+}
+
+func TestUserRefreshIsSingleUse(t *testing.T) {
+	users, _ := newUsers(t, authConfig())
+	ctx := context.Background()
+
+	user, err := users.CreateUser(ctx, "bob", "correct horse", models.RoleViewer)
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	first := login(t, users, "bob", "correct horse")
+
+	// A role change shows up in the tokens of the next refresh.
+	if _, err := users.SetUserRole(ctx, user.Id, models.RoleModerator); err != nil {
+		t.Fatalf("SetUserRole: %v", err)
+	}
+
+	second, err := users.Refresh(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if second.RefreshToken == first.RefreshToken {
+		t.Error("Refresh returned the same refresh token")
+	}
+	p, err := users.Authenticate(ctx, second.AccessToken)
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if !p.Allows(models.ScopeSongsModerate) {
+		t.Errorf("refreshed principal scopes = %v, want the moderator scopes", p.Scopes)
+	}
+
+	if _, err := users.Refresh(ctx, first.RefreshToken); !errors.Is(err, service.ErrInvalidToken) {
+		t.Errorf("reusing a refresh token: err = %v, want ErrInvalidToken", err)
+	}
+
+	if err := users.Logout(ctx, second.RefreshToken); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+	if _, err := users.Refresh(ctx, second.RefreshToken); !errors.Is(err, service.ErrInvalidToken) {
+		t.Errorf("refreshing after logout: err = %v, want ErrInvalidToken", err)
+	}
+
+	expiredCfg := authConfig()
+	expiredCfg.RefreshTTL = -time.Minute
+	short, _ := newUsers(t, expiredCfg)
+	if _, err := short.CreateUser(ctx, "carol", "correct horse", models.RoleViewer); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	stale := login(t, short, "carol", "correct horse")
+	if _, err := short.Refresh(ctx, stale.RefreshToken); !errors.Is(err, service.ErrInvalidToken) {
+		t.Errorf("refreshing an expired token: err = %v, want ErrInvalidToken", err)
+	}
+}
@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/events"
+	"github.com/2pizzzza/TestTask/internal/lib/logger/sl"
+	"github.com/2pizzzza/TestTask/internal/utils"
+)
+
+const EventWebhookTest = "webhook.test"
+
+var ErrInvalidWebhook = errors.New("invalid webhook")
+
+type WebhookService interface {
+	CreateWebhook(ctx context.Context, req models.WebhookReq) (models.Webhook, error)
+	GetWebhook(ctx context.Context, id int64) (models.Webhook, error)
+	ListWebhooks(ctx context.Context) ([]models.Webhook, error)
+	UpdateWebhook(ctx context.Context, id int64, req models.WebhookReq) (models.Webhook, error)
+	DeleteWebhook(ctx context.Context, id int64) error
+	ListDeliveries(ctx context.Context, id int64, limit int) ([]models.WebhookDelivery, error)
+	SendTestEvent(ctx context.Context, id int64) (models.WebhookDelivery, error)
+}
+
+type WebhookRepository interface {
+	CreateWebhook(ctx context.Context, hook models.Webhook) (models.Webhook, error)
+	GetWebhook(ctx context.Context, id int64) (models.Webhook, error)
+	ListWebhooks(ctx context.Context) ([]models.Webhook, error)
+	UpdateWebhook(ctx context.Context, hook models.Webhook) (models.Webhook, error)
+	DeleteWebhook(ctx context.Context, id int64) error
+	ListDeliveries(ctx context.Context, webhookId int64, limit int) ([]models.WebhookDelivery, error)
+}
+
+// WebhookSender performs a single delivery attempt.
+type WebhookSender interface {
+	Deliver(ctx context.Context, hook models.Webhook, event events.Event, attempt int) models.WebhookDelivery
+}
+
+type WebhookRep struct {
+	log    *slog.Logger
+	repo   WebhookRepository
+	sender WebhookSender
+}
+
+func NewWebhooks(log *slog.Logger, repo WebhookRepository, sender WebhookSender) *WebhookRep {
+	return &WebhookRep{
+		log:    log,
+		repo:   repo,
+		sender: sender,
+	}
+}
+
+// logger prefers the request-scoped logger carried by ctx.
+func (s *WebhookRep) logger(ctx context.Context) *slog.Logger {
+	return sl.FromContext(ctx, s.log)
+}
+
+func validateWebhook(req models.WebhookReq) error {
+	if !utils.IsHTTPURL(req.URL) {
+		return fmt.Errorf("%w: url must be an absolute http(s) url", ErrInvalidWebhook)
+	}
+	for _, e := range req.Events {
+		switch e {
+		case "*", events.SongCreated, events.SongUpdated, events.SongDeleted, events.LyricsChanged:
+		default:
+			return fmt.Errorf("%w: unknown event %q", ErrInvalidWebhook, e)
+		}
+	}
+	return nil
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// CreateWebhook registers a webhook. A secret is generated when none is
+// given; it is returned only in this response.
+func (s *WebhookRep) CreateWebhook(ctx context.Context, req models.WebhookReq) (models.Webhook, error) {
+	const op = "service.webhook.CreateWebhook"
+
+	log := s.logger(ctx).With(
+		slog.String("op", op),
+	)
+
+	if err := validateWebhook(req); err != nil {
+		return models.Webhook{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	hook := models.Webhook{URL: req.URL, Events: req.Events, Secret: req.Secret, Active: true}
+	if hook.Events == nil {
+		hook.Events = []string{}
+	}
+	if req.Active != nil {
+		hook.Active = *req.Active
+	}
+	if hook.Secret == "" {
+		secret, err := newSecret()
+		if err != nil {
+			return models.Webhook{}, fmt.Errorf("%s: %w", op, err)
+		}
+		hook.Secret = secret
+	}
+
+	created, err := s.repo.CreateWebhook(ctx, hook)
+	if err != nil {
+		log.Error("failed to create webhook", sl.Err(err))
+		return models.Webhook{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("the webhook was created", slog.Int64("webhook_id", created.Id))
+
+	return created, nil
+}
+
+func (s *WebhookRep) GetWebhook(ctx context.Context, id int64) (models.Webhook, error) {
+	const op = "service.webhook.GetWebhook"
+
+	hook, err := s.repo.GetWebhook(ctx, id)
+	if err != nil {
+		return models.Webhook{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	hook.Secret = ""
+	return hook, nil
+}
+
+func (s *WebhookRep) ListWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	const op = "service.webhook.ListWebhooks"
+
+	hooks, err := s.repo.ListWebhooks(ctx)
+	if err != nil {
+		s.logger(ctx).Error("failed to list webhooks", slog.String("op", op), sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for i := range hooks {
+		hooks[i].Secret = ""
+	}
+	return hooks, nil
+}
+
+// UpdateWebhook replaces the webhook settings; an empty secret keeps the current one.
+func (s *WebhookRep) UpdateWebhook(ctx context.Context, id int64, req models.WebhookReq) (models.Webhook, error) {
+	const op = "service.webhook.UpdateWebhook"
+
+	log := s.logger(ctx).With(
+		slog.String("op", op),
+	)
+
+	if err := validateWebhook(req); err != nil {
+		return models.Webhook{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	hook, err := s.repo.GetWebhook(ctx, id)
+	if err != nil {
+		return models.Webhook{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	hook.URL = req.URL
+	hook.Events = req.Events
+	if hook.Events == nil {
+		hook.Events = []string{}
+	}
+	if req.Secret != "" {
+		hook.Secret = req.Secret
+	}
+	if req.Active != nil {
+		hook.Active = *req.Active
+	}
+
+	updated, err := s.repo.UpdateWebhook(ctx, hook)
+	if err != nil {
+		log.Error("failed to update webhook", sl.Err(err))
+		return models.Webhook{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("the webhook was updated", slog.Int64("webhook_id", id))
+
+	updated.Secret = ""
+	return updated, nil
+}
+
+func (s *WebhookRep) DeleteWebhook(ctx context.Context, id int64) error {
+	const op = "service.webhook.DeleteWebhook"
+
+	if err := s.repo.DeleteWebhook(ctx, id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.logger(ctx).Info("the webhook was removed", slog.String("op", op), slog.Int64("webhook_id", id))
+
+	return nil
+}
+
+func (s *WebhookRep) ListDeliveries(ctx context.Context, id int64, limit int) ([]models.WebhookDelivery, error) {
+	const op = "service.webhook.ListDeliveries"
+
+	deliveries, err := s.repo.ListDeliveries(ctx, id, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return deliveries, nil
+}
+
+// SendTestEvent delivers a synthetic event once, synchronously, and returns
+// the recorded attempt. It works for disabled webhooks too.
+func (s *WebhookRep) SendTestEvent(ctx context.Context, id int64) (models.WebhookDelivery, error) {
+	const op = "service.webhook.SendTestEvent"
+
+	hook, err := s.repo.GetWebhook(ctx, id)
+	if err != nil {
+		return models.WebhookDelivery{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	payload, _ := json.Marshal(map[string]string{"message": "This is a test event"})
+	event := events.Event{
+		Type:      EventWebhookTest,
+		Payload:   payload,
+		CreatedAt: time.Now(),
+	}
+
+	return s.sender.Deliver(ctx, hook, event, 1), nil
+}
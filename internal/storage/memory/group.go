@@ -0,0 +1,97 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/events"
+	"github.com/2pizzzza/TestTask/internal/storage"
+)
+
+// MergeGroups moves the songs of the from groups into the into group,
+// creating it if needed, and deletes the groups left empty. A song whose
+// title already exists in the target stays where it is. With dryRun nothing
+// is changed and the result only reports what would happen.
+func (s *Storage) MergeGroups(
+	ctx context.Context, from []string, into string, dryRun bool) (models.GroupMergeResult, error) {
+
+	const op = "memory.group.MergeGroups"
+
+	defer s.lock(ctx)()
+
+	fromIds := make([]int64, len(from))
+	for i, name := range from {
+		id, ok := s.groupIds[name]
+		if !ok {
+			return models.GroupMergeResult{}, fmt.Errorf("%s: %q: %w", op, name, storage.ErrGroupNotFound)
+		}
+		fromIds[i] = id
+	}
+
+	intoId, intoExists := s.groupIds[into]
+	titles := make(map[string]bool)
+	if intoExists {
+		for _, sg := range s.songs {
+			if sg.groupId == intoId {
+				titles[sg.title] = true
+			}
+		}
+	}
+
+	result := models.GroupMergeResult{Into: into, DryRun: dryRun}
+	var moved []*song
+	for i, fromId := range fromIds {
+		var groupSongs []*song
+		for _, sg := range s.songs {
+			if sg.groupId == fromId {
+				groupSongs = append(groupSongs, sg)
+			}
+		}
+		slices.SortFunc(groupSongs, func(a, b *song) int {
+			return cmp.Compare(a.id, b.id)
+		})
+
+		// Like the single UPDATE in postgres, songs of one group are checked
+		// against the target as it was before that group was moved.
+		var movedTitles []string
+		conflicts := 0
+		for _, sg := range groupSongs {
+			if titles[sg.title] {
+				result.Conflicts = append(result.Conflicts, sg.id)
+				conflicts++
+				continue
+			}
+			result.Moved = append(result.Moved, sg.id)
+			moved = append(moved, sg)
+			movedTitles = append(movedTitles, sg.title)
+		}
+		for _, title := range movedTitles {
+			titles[title] = true
+		}
+
+		if conflicts == 0 {
+			result.RemovedGroups = append(result.RemovedGroups, from[i])
+		}
+	}
+
+	if dryRun {
+		return result, nil
+	}
+
+	intoId = s.groupID(into)
+	for _, sg := range moved {
+		sg.groupId = intoId
+	}
+	for _, name := range result.RemovedGroups {
+		delete(s.groups, s.groupIds[name])
+		delete(s.groupIds, name)
+	}
+	for _, sg := range moved {
+		s.addEvent(events.SongUpdated, s.toModel(sg))
+	}
+
+	return result, nil
+}
@@ -0,0 +1,262 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/storage"
+)
+
+func copyLink(link *models.SongLink) models.SongLink {
+	c := *link
+	if link.LastCheckedAt != nil {
+		checked := *link.LastCheckedAt
+		c.LastCheckedAt = &checked
+	}
+	return c
+}
+
+func (s *Storage) AddLink(ctx context.Context, songId int64, platform, url string) (models.SongLink, error) {
+	const op = "memory.link.AddLink"
+
+	defer s.lock(ctx)()
+
+	if _, ok := s.songs[songId]; !ok {
+		return models.SongLink{}, fmt.Errorf("%s: %w", op, storage.ErrSongNotFound)
+	}
+	for _, link := range s.links {
+		if link.SongId == songId && link.URL == url {
+			return models.SongLink{}, fmt.Errorf("%s: %w", op, storage.ErrLinkExists)
+		}
+	}
+
+	link := s.insertLink(songId, platform, url, models.LinkSourceManual)
+
+	return copyLink(link), nil
+}
+
+func (s *Storage) insertLink(songId int64, platform, url, source string) *models.SongLink {
+	s.lastLinkId++
+	link := &models.SongLink{
+		Id:        s.lastLinkId,
+		SongId:    songId,
+		Platform:  platform,
+		URL:       url,
+		Source:    source,
+		CreatedAt: time.Now(),
+	}
+	s.links[link.Id] = link
+	return link
+}
+
+func (s *Storage) GetLinks(ctx context.Context, songId int64) ([]models.SongLink, error) {
+	const op = "memory.link.GetLinks"
+
+	defer s.rlock(ctx)()
+
+	if _, ok := s.songs[songId]; !ok {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrSongNotFound)
+	}
+
+	links := s.filterLinks(func(link *models.SongLink) bool {
+		return link.SongId == songId
+	}, func(a, b *models.SongLink) int {
+		return cmp.Or(cmp.Compare(a.Platform, b.Platform), cmp.Compare(a.Id, b.Id))
+	})
+
+	return links, nil
+}
+
+// UpdateLink replaces a link; the edited link becomes a manual one.
+func (s *Storage) UpdateLink(ctx context.Context, songId, linkId int64, platform, url string) (models.SongLink, error) {
+	defer s.lock(ctx)()
+
+	link, ok := s.links[linkId]
+	if !ok || link.SongId != songId {
+		return models.SongLink{}, storage.ErrLinkNotFound
+	}
+
+	link.Platform = platform
+	link.URL = url
+	link.Source = models.LinkSourceManual
+	resetCheck(link)
+
+	return copyLink(link), nil
+}
+
+func (s *Storage) RemoveLink(ctx context.Context, songId, linkId int64) error {
+	defer s.lock(ctx)()
+
+	link, ok := s.links[linkId]
+	if !ok || link.SongId != songId {
+		return storage.ErrLinkNotFound
+	}
+	delete(s.links, linkId)
+
+	return nil
+}
+
+// saveProviderLinks stores links found by enrichment. A platform that already
+// has a manually entered link is left alone; an older provider link for the
+// platform is replaced.
+func (s *Storage) saveProviderLinks(songId int64, links map[string]string) {
+	platforms := make([]string, 0, len(links))
+	for platform := range links {
+		platforms = append(platforms, platform)
+	}
+	slices.Sort(platforms)
+
+	for _, platform := range platforms {
+		url := links[platform]
+		if url == "" || !models.ValidPlatform(platform) {
+			continue
+		}
+
+		var manual, provider *models.SongLink
+		for _, link := range s.links {
+			if link.SongId != songId || link.Platform != platform {
+				continue
+			}
+			if link.Source == models.LinkSourceManual {
+				manual = link
+			} else {
+				provider = link
+			}
+		}
+
+		switch {
+		case manual != nil:
+		case provider != nil:
+			provider.URL = url
+		default:
+			s.insertLink(songId, platform, url, models.LinkSourceEnrichment)
+		}
+	}
+}
+
+func (s *Storage) GetBrokenLinks(ctx context.Context, limit, offset int) ([]models.SongLink, error) {
+	const op = "memory.link.GetBrokenLinks"
+
+	defer s.rlock(ctx)()
+
+	links := s.filterLinks(func(link *models.SongLink) bool {
+		return link.Broken
+	}, func(a, b *models.SongLink) int {
+		// ORDER BY last_checked_at DESC puts never checked links first too.
+		byChecked := compareChecked(b.LastCheckedAt, a.LastCheckedAt)
+		if a.LastCheckedAt == nil || b.LastCheckedAt == nil {
+			byChecked = -byChecked
+		}
+		return cmp.Or(byChecked, cmp.Compare(a.Id, b.Id))
+	})
+
+	links, err := page(links, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return links, nil
+}
+
+// LinksToCheck returns links never checked or last checked before the given time.
+func (s *Storage) LinksToCheck(ctx context.Context, checkedBefore time.Time, limit int) ([]models.SongLink, error) {
+	const op = "memory.link.LinksToCheck"
+
+	defer s.rlock(ctx)()
+
+	links := s.filterLinks(func(link *models.SongLink) bool {
+		return link.LastCheckedAt == nil || link.LastCheckedAt.Before(checkedBefore)
+	}, func(a, b *models.SongLink) int {
+		// NULLS FIRST: never checked links come before checked ones.
+		return cmp.Or(compareChecked(a.LastCheckedAt, b.LastCheckedAt), cmp.Compare(a.Id, b.Id))
+	})
+
+	links, err := page(links, limit, 0)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return links, nil
+}
+
+func (s *Storage) SaveLinkCheck(ctx context.Context, linkId int64, check models.LinkCheck) error {
+	defer s.lock(ctx)()
+
+	link, ok := s.links[linkId]
+	if !ok {
+		return nil
+	}
+
+	checkedAt := check.CheckedAt
+	link.StatusCode = check.StatusCode
+	link.CheckError = check.Error
+	link.RedirectURL = check.RedirectURL
+	link.Broken = check.Broken
+	link.Failures = check.Failures
+	link.LastCheckedAt = &checkedAt
+
+	return nil
+}
+
+// ReplaceLinkURL points a link at a new URL, e.g. the target of a permanent redirect.
+func (s *Storage) ReplaceLinkURL(ctx context.Context, linkId int64, url string) error {
+	defer s.lock(ctx)()
+
+	link, ok := s.links[linkId]
+	if !ok {
+		return storage.ErrLinkNotFound
+	}
+
+	link.URL = url
+	link.RedirectURL = ""
+	link.Broken = false
+	link.Failures = 0
+	link.LastCheckedAt = nil
+
+	return nil
+}
+
+func resetCheck(link *models.SongLink) {
+	link.StatusCode = 0
+	link.CheckError = ""
+	link.RedirectURL = ""
+	link.Broken = false
+	link.Failures = 0
+	link.LastCheckedAt = nil
+}
+
+func (s *Storage) filterLinks(
+	keep func(*models.SongLink) bool, compare func(a, b *models.SongLink) int) []models.SongLink {
+
+	var matched []*models.SongLink
+	for _, link := range s.links {
+		if keep(link) {
+			matched = append(matched, link)
+		}
+	}
+	slices.SortFunc(matched, compare)
+
+	links := make([]models.SongLink, 0, len(matched))
+	for _, link := range matched {
+		links = append(links, copyLink(link))
+	}
+	return links
+}
+
+// compareChecked orders never checked links before checked ones.
+func compareChecked(a, b *time.Time) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	default:
+		return a.Compare(*b)
+	}
+}
@@ -0,0 +1,156 @@
+// Package memory keeps the catalog in process memory. It mirrors the
+// semantics of the postgres package so the service behaves the same on both,
+// which makes it suitable for demos, tests and running without a database.
+// Nothing survives a restart.
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/events"
+)
+
+var errNegativePage = errors.New("limit and offset must not be negative")
+
+type song struct {
+	id           int64
+	groupId      int64
+	title        string
+	releaseDate  string
+	lyrics       string
+	lyricsSource string
+	link         string
+}
+
+type outboxEntry struct {
+	event     events.Event
+	published bool
+}
+
+// Storage is safe for concurrent use. Every method holds the lock for its
+// whole duration, so each call is atomic like a postgres transaction;
+// WithinTx extends that to several calls.
+type Storage struct {
+	mu sync.RWMutex
+	data
+}
+
+// data is everything a unit of work may have to roll back.
+type data struct {
+	groups    map[int64]string
+	groupIds  map[string]int64
+	songs     map[int64]*song
+	links     map[int64]*models.SongLink
+	outbox    []outboxEntry
+	webhooks  map[int64]*models.Webhook
+	delivered []models.WebhookDelivery
+	jobs      map[int64]*models.WebhookJob
+	revisions []models.LyricsRevision
+
+	lastGroupId    int64
+	lastSongId     int64
+	lastLinkId     int64
+	lastEventId    int64
+	lastWebhookId  int64
+	lastDeliveryId int64
+	lastJobId      int64
+	lastRevisionId int64
+}
+
+func New() *Storage {
+	return &Storage{data: data{
+		groups:   make(map[int64]string),
+		groupIds: make(map[string]int64),
+		songs:    make(map[int64]*song),
+		links:    make(map[int64]*models.SongLink),
+		webhooks: make(map[int64]*models.Webhook),
+		jobs:     make(map[int64]*models.WebhookJob),
+	}}
+}
+
+// Ping always succeeds; it exists so health checks treat both backends alike.
+func (s *Storage) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
+
+// CheckSchema always succeeds, there is no schema to migrate.
+func (s *Storage) CheckSchema(ctx context.Context) error {
+	return ctx.Err()
+}
+
+func (s *Storage) Close() error {
+	return nil
+}
+
+// CatalogStats counts songs, groups and songs that have no lyrics yet.
+func (s *Storage) CatalogStats(ctx context.Context) (models.CatalogStats, error) {
+	defer s.rlock(ctx)()
+
+	stats := models.CatalogStats{
+		Songs:  int64(len(s.songs)),
+		Groups: int64(len(s.groups)),
+	}
+	for _, sg := range s.songs {
+		if sg.lyrics == "" {
+			stats.MissingLyrics++
+		}
+	}
+
+	return stats, nil
+}
+
+// groupID returns the id of the group with the given name, creating it if needed.
+func (s *Storage) groupID(name string) int64 {
+	if id, ok := s.groupIds[name]; ok {
+		return id
+	}
+	s.lastGroupId++
+	s.groups[s.lastGroupId] = name
+	s.groupIds[name] = s.lastGroupId
+	return s.lastGroupId
+}
+
+func (s *Storage) toModel(sg *song) models.Song {
+	return models.Song{
+		Id:           sg.id,
+		GroupName:    models.Group{Id: sg.groupId, GroupName: s.groups[sg.groupId]},
+		SongName:     sg.title,
+		ReleaseDate:  sg.releaseDate,
+		Lyrics:       sg.lyrics,
+		LyricsSource: sg.lyricsSource,
+		Link:         sg.link,
+	}
+}
+
+// addEvent appends an event to the outbox; callers hold the write lock.
+func (s *Storage) addEvent(eventType string, sg models.Song) {
+	payload, _ := json.Marshal(events.NewPayload(eventType, sg))
+	s.lastEventId++
+	s.outbox = append(s.outbox, outboxEntry{event: events.Event{
+		Id:        s.lastEventId,
+		Type:      eventType,
+		SongId:    sg.Id,
+		Payload:   payload,
+		CreatedAt: time.Now(),
+	}})
+}
+
+// page applies limit and offset the way SQL LIMIT and OFFSET do.
+func page[T any](items []T, limit, offset int) ([]T, error) {
+	if limit < 0 || offset < 0 {
+		return nil, errNegativePage
+	}
+	if offset >= len(items) {
+		return items[:0], nil
+	}
+	items = items[offset:]
+	if limit < len(items) {
+		items = items[:limit]
+	}
+	return items, nil
+}
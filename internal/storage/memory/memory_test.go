@@ -0,0 +1,20 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/2pizzzza/TestTask/internal/storage/memory"
+	"github.com/2pizzzza/TestTask/internal/storage/storagetest"
+)
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storagetest.Repository {
+		return memory.New()
+	})
+}
+
+func TestWebhookJobs(t *testing.T) {
+	storagetest.RunWebhookJobs(t, func(t *testing.T) storagetest.WebhookRepository {
+		return memory.New()
+	})
+}
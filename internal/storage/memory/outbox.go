@@ -0,0 +1,53 @@
+package memory
+
+import (
+	"context"
+	"slices"
+
+	"github.com/2pizzzza/TestTask/internal/events"
+)
+
+func (s *Storage) FetchUnpublished(ctx context.Context, limit int) ([]events.Event, error) {
+	defer s.rlock(ctx)()
+
+	var result []events.Event
+	for _, entry := range s.outbox {
+		if len(result) == limit {
+			break
+		}
+		if !entry.published {
+			result = append(result, entry.event)
+		}
+	}
+
+	return result, nil
+}
+
+func (s *Storage) MarkPublished(ctx context.Context, ids []int64) error {
+	defer s.lock(ctx)()
+
+	for i := range s.outbox {
+		if slices.Contains(ids, s.outbox[i].event.Id) {
+			s.outbox[i].published = true
+		}
+	}
+
+	return nil
+}
+
+// PublishedSince returns already published events with an id greater than lastId.
+func (s *Storage) PublishedSince(ctx context.Context, lastId int64, limit int) ([]events.Event, error) {
+	defer s.rlock(ctx)()
+
+	var result []events.Event
+	for _, entry := range s.outbox {
+		if len(result) == limit {
+			break
+		}
+		if entry.event.Id > lastId && entry.published {
+			result = append(result, entry.event)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,269 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/events"
+	"github.com/2pizzzza/TestTask/internal/lib/ilike"
+	"github.com/2pizzzza/TestTask/internal/storage"
+)
+
+func (s *Storage) Save(
+	ctx context.Context, groupName, songName string, details models.SongDetails) (string, error) {
+
+	const op = "memory.song.Save"
+
+	defer s.lock(ctx)()
+
+	if groupId, ok := s.groupIds[groupName]; ok {
+		for _, sg := range s.songs {
+			if sg.groupId == groupId && sg.title == songName {
+				return "Song already exists", fmt.Errorf("%s: %w", op, storage.ErrSongExists)
+			}
+		}
+	}
+
+	s.lastSongId++
+	sg := &song{
+		id:           s.lastSongId,
+		groupId:      s.groupID(groupName),
+		title:        songName,
+		releaseDate:  details.ReleaseDate,
+		lyrics:       details.Lyrics,
+		lyricsSource: details.LyricsSource,
+		link:         details.Link,
+	}
+	s.songs[sg.id] = sg
+	s.saveProviderLinks(sg.id, details.Links)
+
+	created := s.toModel(sg)
+	s.addEvent(events.SongCreated, created)
+	if created.Lyrics != "" {
+		s.addEvent(events.LyricsChanged, created)
+	}
+
+	return "Success create song", nil
+}
+
+func (s *Storage) GetById(ctx context.Context, id int64) (models.Song, error) {
+	defer s.rlock(ctx)()
+
+	sg, ok := s.songs[id]
+	if !ok {
+		return models.Song{}, storage.ErrSongNotFound
+	}
+
+	return s.toModel(sg), nil
+}
+
+func (s *Storage) Update(
+	ctx context.Context, id int64, newGroupName, newSongName string) (models.Song, error) {
+
+	const op = "memory.song.Update"
+
+	defer s.lock(ctx)()
+
+	sg, ok := s.songs[id]
+	if !ok {
+		return models.Song{}, fmt.Errorf("%s: %w", op, storage.ErrSongNotFound)
+	}
+
+	sg.groupId = s.groupID(newGroupName)
+	sg.title = newSongName
+
+	updated := s.toModel(sg)
+	s.addEvent(events.SongUpdated, updated)
+
+	return updated, nil
+}
+
+func (s *Storage) Remove(ctx context.Context, id int64) (string, error) {
+	defer s.lock(ctx)()
+
+	sg, ok := s.songs[id]
+	if !ok {
+		return "", storage.ErrSongNotFound
+	}
+
+	removed := s.toModel(sg)
+	delete(s.songs, id)
+	for linkId, link := range s.links {
+		if link.SongId == id {
+			delete(s.links, linkId)
+		}
+	}
+	s.revisions = slices.DeleteFunc(s.revisions, func(r models.LyricsRevision) bool {
+		return r.SongId == id
+	})
+	s.addEvent(events.SongDeleted, removed)
+
+	return fmt.Sprintf("Successfully deleted song id: %d", id), nil
+}
+
+// GetAll matches the group and title filters like ILIKE '%value%' and
+// returns the newest songs first.
+func (s *Storage) GetAll(ctx context.Context, filter models.SongFilter, limit, offset int) (songs []*models.Song, err error) {
+	const op = "memory.song.GetAll"
+
+	defer s.rlock(ctx)()
+
+	var matched []*song
+	for _, sg := range s.songs {
+		if filter.GroupName != "" && !ilike.Match(s.groups[sg.groupId], "%"+filter.GroupName+"%") {
+			continue
+		}
+		if filter.SongName != "" && !ilike.Match(sg.title, "%"+filter.SongName+"%") {
+			continue
+		}
+		if filter.ReleaseDate != "" && sg.releaseDate != filter.ReleaseDate {
+			continue
+		}
+		matched = append(matched, sg)
+	}
+	slices.SortFunc(matched, func(a, b *song) int {
+		return cmp.Compare(b.id, a.id)
+	})
+
+	matched, err = page(matched, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, sg := range matched {
+		model := s.toModel(sg)
+		songs = append(songs, &model)
+	}
+
+	return songs, nil
+}
+
+// ListIncomplete returns songs after the given id that lack a release date,
+// link or lyrics, in ascending id order.
+func (s *Storage) ListIncomplete(ctx context.Context, afterId int64, limit int) (songs []*models.Song, err error) {
+	const op = "memory.song.ListIncomplete"
+
+	defer s.rlock(ctx)()
+
+	var matched []*song
+	for _, sg := range s.songs {
+		if sg.id > afterId && (sg.releaseDate == "" || sg.link == "" || sg.lyrics == "") {
+			matched = append(matched, sg)
+		}
+	}
+	slices.SortFunc(matched, func(a, b *song) int {
+		return cmp.Compare(a.id, b.id)
+	})
+
+	matched, err = page(matched, limit, 0)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, sg := range matched {
+		model := s.toModel(sg)
+		songs = append(songs, &model)
+	}
+
+	return songs, nil
+}
+
+// FillDetails sets only the fields that are still empty, so values entered
+// by hand are never overwritten.
+func (s *Storage) FillDetails(ctx context.Context, id int64, details models.SongDetails) error {
+	defer s.lock(ctx)()
+
+	sg, ok := s.songs[id]
+	if !ok {
+		return storage.ErrSongNotFound
+	}
+	before := s.toModel(sg)
+
+	if sg.releaseDate == "" {
+		sg.releaseDate = details.ReleaseDate
+	}
+	if sg.link == "" {
+		sg.link = details.Link
+	}
+	if sg.lyrics == "" && details.Lyrics != "" {
+		sg.lyricsSource = details.LyricsSource
+		sg.lyrics = details.Lyrics
+	}
+	s.saveProviderLinks(id, details.Links)
+
+	after := s.toModel(sg)
+	if after != before {
+		s.addEvent(events.SongUpdated, after)
+	}
+	if after.Lyrics != before.Lyrics {
+		s.addEvent(events.LyricsChanged, after)
+	}
+
+	return nil
+}
+
+// SetLyrics replaces the lyrics of a song and records where they came from.
+func (s *Storage) SetLyrics(ctx context.Context, id int64, lyrics, source string) (models.Song, error) {
+	defer s.lock(ctx)()
+
+	sg, ok := s.songs[id]
+	if !ok {
+		return models.Song{}, storage.ErrSongNotFound
+	}
+
+	changed := sg.lyrics != lyrics
+	if changed {
+		s.lastRevisionId++
+		s.revisions = append(s.revisions, models.LyricsRevision{
+			Id:           s.lastRevisionId,
+			SongId:       id,
+			Lyrics:       sg.lyrics,
+			LyricsSource: sg.lyricsSource,
+			CreatedAt:    time.Now(),
+		})
+	}
+	sg.lyrics = lyrics
+	sg.lyricsSource = source
+
+	after := s.toModel(sg)
+	if changed {
+		s.addEvent(events.LyricsChanged, after)
+	}
+
+	return after, nil
+}
+
+// LyricsRevisions lists the earlier lyrics of a song, newest first.
+func (s *Storage) LyricsRevisions(ctx context.Context, songId int64) ([]models.LyricsRevision, error) {
+	defer s.rlock(ctx)()
+
+	if _, ok := s.songs[songId]; !ok {
+		return nil, storage.ErrSongNotFound
+	}
+
+	revisions := []models.LyricsRevision{}
+	for i := len(s.revisions) - 1; i >= 0; i-- {
+		if s.revisions[i].SongId == songId {
+			revisions = append(revisions, s.revisions[i])
+		}
+	}
+
+	return revisions, nil
+}
+
+// LyricsRevision returns one earlier version of the lyrics of a song.
+func (s *Storage) LyricsRevision(ctx context.Context, songId, revisionId int64) (models.LyricsRevision, error) {
+	defer s.rlock(ctx)()
+
+	for _, r := range s.revisions {
+		if r.Id == revisionId && r.SongId == songId {
+			return r, nil
+		}
+	}
+
+	return models.LyricsRevision{}, storage.ErrRevisionNotFound
+}
@@ -0,0 +1,91 @@
+package memory
+
+import (
+	"context"
+	"maps"
+	"slices"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+)
+
+type txKey struct{}
+
+// WithinTx runs fn as one unit of work. It holds the write lock until fn
+// returns, so calls made with the context passed to fn see no concurrent
+// changes, and it restores the previous state when fn fails. A nested call
+// joins the outer unit of work.
+func (s *Storage) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.inTx(ctx) {
+		return fn(ctx)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	saved := s.data.clone()
+	if err := fn(context.WithValue(ctx, txKey{}, s)); err != nil {
+		s.data = saved
+		return err
+	}
+
+	return nil
+}
+
+func (s *Storage) inTx(ctx context.Context) bool {
+	owner, _ := ctx.Value(txKey{}).(*Storage)
+	return owner == s
+}
+
+// lock takes the write lock unless the unit of work in ctx already holds it
+// and returns the matching unlock.
+func (s *Storage) lock(ctx context.Context) func() {
+	if s.inTx(ctx) {
+		return func() {}
+	}
+	s.mu.Lock()
+	return s.mu.Unlock
+}
+
+func (s *Storage) rlock(ctx context.Context) func() {
+	if s.inTx(ctx) {
+		return func() {}
+	}
+	s.mu.RLock()
+	return s.mu.RUnlock
+}
+
+func (d *data) clone() data {
+	c := *d
+	c.groups = maps.Clone(d.groups)
+	c.groupIds = maps.Clone(d.groupIds)
+	c.outbox = slices.Clone(d.outbox)
+	c.delivered = slices.Clone(d.delivered)
+	c.revisions = slices.Clone(d.revisions)
+
+	c.songs = make(map[int64]*song, len(d.songs))
+	for id, sg := range d.songs {
+		copied := *sg
+		c.songs[id] = &copied
+	}
+	c.links = make(map[int64]*models.SongLink, len(d.links))
+	for id, link := range d.links {
+		copied := *link
+		if link.LastCheckedAt != nil {
+			checked := *link.LastCheckedAt
+			copied.LastCheckedAt = &checked
+		}
+		c.links[id] = &copied
+	}
+	c.webhooks = make(map[int64]*models.Webhook, len(d.webhooks))
+	for id, hook := range d.webhooks {
+		copied := copyWebhook(hook)
+		c.webhooks[id] = &copied
+	}
+	c.jobs = make(map[int64]*models.WebhookJob, len(d.jobs))
+	for id, job := range d.jobs {
+		copied := *job
+		c.jobs[id] = &copied
+	}
+
+	return c
+}
@@ -0,0 +1,231 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/storage"
+)
+
+func copyWebhook(hook *models.Webhook) models.Webhook {
+	c := *hook
+	c.Events = slices.Clone(hook.Events)
+	if hook.DisabledAt != nil {
+		disabled := *hook.DisabledAt
+		c.DisabledAt = &disabled
+	}
+	return c
+}
+
+func (s *Storage) CreateWebhook(ctx context.Context, hook models.Webhook) (models.Webhook, error) {
+	defer s.lock(ctx)()
+
+	s.lastWebhookId++
+	created := &models.Webhook{
+		Id:        s.lastWebhookId,
+		URL:       hook.URL,
+		Events:    slices.Clone(hook.Events),
+		Secret:    hook.Secret,
+		Active:    hook.Active,
+		CreatedAt: time.Now(),
+	}
+	s.webhooks[created.Id] = created
+
+	return copyWebhook(created), nil
+}
+
+func (s *Storage) GetWebhook(ctx context.Context, id int64) (models.Webhook, error) {
+	defer s.rlock(ctx)()
+
+	hook, ok := s.webhooks[id]
+	if !ok {
+		return models.Webhook{}, storage.ErrWebhookNotFound
+	}
+
+	return copyWebhook(hook), nil
+}
+
+func (s *Storage) ListWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	return s.listWebhooks(ctx, false), nil
+}
+
+// ActiveWebhooks returns enabled webhooks; filtering by event type is left to the caller.
+func (s *Storage) ActiveWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	return s.listWebhooks(ctx, true), nil
+}
+
+func (s *Storage) listWebhooks(ctx context.Context, activeOnly bool) []models.Webhook {
+	defer s.rlock(ctx)()
+
+	hooks := []models.Webhook{}
+	for _, hook := range s.webhooks {
+		if !activeOnly || hook.Active {
+			hooks = append(hooks, copyWebhook(hook))
+		}
+	}
+	slices.SortFunc(hooks, func(a, b models.Webhook) int {
+		return cmp.Compare(a.Id, b.Id)
+	})
+
+	return hooks
+}
+
+// UpdateWebhook replaces the url, filter, secret and active flag. Re-enabling
+// a webhook resets its failure counter.
+func (s *Storage) UpdateWebhook(ctx context.Context, hook models.Webhook) (models.Webhook, error) {
+	defer s.lock(ctx)()
+
+	stored, ok := s.webhooks[hook.Id]
+	if !ok {
+		return models.Webhook{}, storage.ErrWebhookNotFound
+	}
+
+	if hook.Active && !stored.Active {
+		stored.FailureCount = 0
+	}
+	if hook.Active {
+		stored.DisabledAt = nil
+	}
+	stored.URL = hook.URL
+	stored.Events = slices.Clone(hook.Events)
+	stored.Secret = hook.Secret
+	stored.Active = hook.Active
+
+	return copyWebhook(stored), nil
+}
+
+func (s *Storage) DeleteWebhook(ctx context.Context, id int64) error {
+	defer s.lock(ctx)()
+
+	if _, ok := s.webhooks[id]; !ok {
+		return storage.ErrWebhookNotFound
+	}
+	delete(s.webhooks, id)
+	s.delivered = slices.DeleteFunc(s.delivered, func(d models.WebhookDelivery) bool {
+		return d.WebhookId == id
+	})
+	maps.DeleteFunc(s.jobs, func(_ int64, job *models.WebhookJob) bool {
+		return job.WebhookId == id
+	})
+
+	return nil
+}
+
+func (s *Storage) SaveDelivery(ctx context.Context, d models.WebhookDelivery) error {
+	defer s.lock(ctx)()
+
+	s.lastDeliveryId++
+	d.Id = s.lastDeliveryId
+	d.CreatedAt = time.Now()
+	s.delivered = append(s.delivered, d)
+
+	return nil
+}
+
+func (s *Storage) ListDeliveries(ctx context.Context, webhookId int64, limit int) ([]models.WebhookDelivery, error) {
+	defer s.rlock(ctx)()
+
+	if _, ok := s.webhooks[webhookId]; !ok {
+		return nil, storage.ErrWebhookNotFound
+	}
+
+	deliveries := []models.WebhookDelivery{}
+	for i := len(s.delivered) - 1; i >= 0 && len(deliveries) < limit; i-- {
+		if s.delivered[i].WebhookId == webhookId {
+			deliveries = append(deliveries, s.delivered[i])
+		}
+	}
+
+	return deliveries, nil
+}
+
+// RecordWebhookResult resets the failure counter on success; on failure it
+// increments it and disables the webhook once maxFailures is reached.
+func (s *Storage) RecordWebhookResult(ctx context.Context, id int64, success bool, maxFailures int) (bool, error) {
+	defer s.lock(ctx)()
+
+	hook, ok := s.webhooks[id]
+	if !ok {
+		if success {
+			return false, nil
+		}
+		return false, storage.ErrWebhookNotFound
+	}
+
+	if success {
+		hook.FailureCount = 0
+		return false, nil
+	}
+
+	hook.FailureCount++
+	if hook.Active && hook.FailureCount >= maxFailures {
+		now := time.Now()
+		hook.Active = false
+		hook.DisabledAt = &now
+	}
+
+	return !hook.Active, nil
+}
+
+// EnqueueWebhookJobs stores pending deliveries. Called inside the relay
+// transaction, the jobs become visible together with the published event.
+func (s *Storage) EnqueueWebhookJobs(ctx context.Context, jobs []models.WebhookJob) error {
+	defer s.lock(ctx)()
+
+	for _, job := range jobs {
+		s.lastJobId++
+		job.Id = s.lastJobId
+		job.Attempts = 0
+		job.CreatedAt = time.Now()
+		s.jobs[job.Id] = &job
+	}
+
+	return nil
+}
+
+// ClaimWebhookJobs takes up to limit jobs that are due at now, counts the
+// attempt and hides them from other workers until leaseUntil.
+func (s *Storage) ClaimWebhookJobs(ctx context.Context, now, leaseUntil time.Time, limit int) ([]models.WebhookJob, error) {
+	defer s.lock(ctx)()
+
+	due := []*models.WebhookJob{}
+	for _, job := range s.jobs {
+		if !job.NextAttemptAt.After(now) {
+			due = append(due, job)
+		}
+	}
+	slices.SortFunc(due, func(a, b *models.WebhookJob) int {
+		return cmp.Or(a.NextAttemptAt.Compare(b.NextAttemptAt), cmp.Compare(a.Id, b.Id))
+	})
+
+	claimed := []models.WebhookJob{}
+	for _, job := range due[:min(limit, len(due))] {
+		job.Attempts++
+		job.NextAttemptAt = leaseUntil
+		claimed = append(claimed, *job)
+	}
+
+	return claimed, nil
+}
+
+func (s *Storage) RescheduleWebhookJob(ctx context.Context, id int64, at time.Time) error {
+	defer s.lock(ctx)()
+
+	if job, ok := s.jobs[id]; ok {
+		job.NextAttemptAt = at
+	}
+
+	return nil
+}
+
+func (s *Storage) DeleteWebhookJob(ctx context.Context, id int64) error {
+	defer s.lock(ctx)()
+
+	delete(s.jobs, id)
+
+	return nil
+}
@@ -0,0 +1,213 @@
+// Package migration runs the migrations embedded in the binary, whichever
+// backend they belong to.
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/source"
+)
+
+// Migration is one migration shipped with the binary.
+type Migration struct {
+	Version uint
+	Name    string
+}
+
+// Status compares the database with the embedded migrations.
+type Status struct {
+	Version uint
+	Dirty   bool
+	Latest  uint
+	Applied []Migration
+	Pending []Migration
+}
+
+// Migrator applies embedded migrations to one database.
+type Migrator struct {
+	m     *migrate.Migrate
+	open  func() (source.Driver, error)
+	close func() error
+}
+
+// New wraps m, which reads the migrations that open returns. close releases
+// what m holds; nil closes m with its source and database.
+func New(m *migrate.Migrate, open func() (source.Driver, error), log *slog.Logger, close func() error) *Migrator {
+	m.Log = logger{log: log}
+	if close == nil {
+		close = func() error {
+			srcErr, dbErr := m.Close()
+			return errors.Join(srcErr, dbErr)
+		}
+	}
+
+	return &Migrator{m: m, open: open, close: close}
+}
+
+func (m *Migrator) Close() error {
+	return m.close()
+}
+
+// Up applies every pending migration.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.run(ctx, "migration.Up", m.m.Up)
+}
+
+// Steps applies n migrations, or rolls back -n of them when n is negative.
+func (m *Migrator) Steps(ctx context.Context, n int) error {
+	return m.run(ctx, "migration.Steps", func() error {
+		return m.m.Steps(n)
+	})
+}
+
+// Down rolls back every applied migration.
+func (m *Migrator) Down(ctx context.Context) error {
+	return m.run(ctx, "migration.Down", m.m.Down)
+}
+
+// Goto migrates up or down to version.
+func (m *Migrator) Goto(ctx context.Context, version uint) error {
+	return m.run(ctx, "migration.Goto", func() error {
+		return m.m.Migrate(version)
+	})
+}
+
+// Force records version as applied and clears the dirty flag without
+// running anything; -1 means no version.
+func (m *Migrator) Force(version int) error {
+	const op = "migration.Force"
+
+	if err := m.m.Force(version); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Version returns the applied version; zero means nothing is applied.
+func (m *Migrator) Version() (uint, bool, error) {
+	const op = "migration.Version"
+
+	version, dirty, err := m.m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return version, dirty, nil
+}
+
+// Status lists which embedded migrations are applied and which are pending.
+func (m *Migrator) Status() (Status, error) {
+	const op = "migration.Status"
+
+	version, dirty, err := m.Version()
+	if err != nil {
+		return Status{}, err
+	}
+
+	all, err := List(m.open)
+	if err != nil {
+		return Status{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	status := Status{Version: version, Dirty: dirty}
+	for _, mig := range all {
+		status.Latest = mig.Version
+		if mig.Version <= version {
+			status.Applied = append(status.Applied, mig)
+		} else {
+			status.Pending = append(status.Pending, mig)
+		}
+	}
+
+	return status, nil
+}
+
+// run stops golang-migrate after the current migration when ctx is done.
+func (m *Migrator) run(ctx context.Context, op string, fn func() error) error {
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			m.m.GracefulStop <- true
+		case <-done:
+		}
+	}()
+
+	err := fn()
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if ctx.Err() != nil {
+		return fmt.Errorf("%s: %w", op, ctx.Err())
+	}
+
+	return nil
+}
+
+// List returns the migrations of the source that open returns, in order.
+func List(open func() (source.Driver, error)) ([]Migration, error) {
+	src, err := open()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	var result []Migration
+	version, err := src.First()
+	for err == nil {
+		r, name, readErr := src.ReadUp(version)
+		if readErr != nil {
+			return nil, readErr
+		}
+		r.Close()
+		result = append(result, Migration{Version: version, Name: name})
+
+		version, err = src.Next(version)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Latest returns the newest version of the source that open returns, zero
+// when it has none.
+func Latest(open func() (source.Driver, error)) (uint, error) {
+	all, err := List(open)
+	if err != nil {
+		return 0, err
+	}
+	if len(all) == 0 {
+		return 0, nil
+	}
+
+	return all[len(all)-1].Version, nil
+}
+
+// logger forwards golang-migrate progress to slog.
+type logger struct {
+	log *slog.Logger
+}
+
+func (l logger) Printf(format string, v ...any) {
+	l.log.Info(strings.TrimSpace(fmt.Sprintf(format, v...)))
+}
+
+func (l logger) Verbose() bool {
+	return false
+}
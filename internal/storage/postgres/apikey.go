@@ -0,0 +1,119 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/storage"
+	"github.com/lib/pq"
+)
+
+const apiKeyColumns = "id, name, prefix, scopes, created_at, last_used_at, revoked_at"
+
+func scanAPIKey(row scanner, key *models.APIKey) error {
+	var lastUsed, revoked sql.NullTime
+	err := row.Scan(&key.Id, &key.Name, &key.Prefix, pq.Array(&key.Scopes), &key.CreatedAt, &lastUsed, &revoked)
+	if err != nil {
+		return err
+	}
+	if lastUsed.Valid {
+		key.LastUsedAt = &lastUsed.Time
+	}
+	if revoked.Valid {
+		key.RevokedAt = &revoked.Time
+	}
+	return nil
+}
+
+// CreateAPIKey stores a key under the hash of its secret.
+func (s *Storage) CreateAPIKey(ctx context.Context, key models.APIKey, hash string) (models.APIKey, error) {
+	const op = "postgres.apikey.CreateAPIKey"
+
+	var created models.APIKey
+	err := scanAPIKey(s.conn(ctx).QueryRowContext(ctx,
+		`INSERT INTO api_keys (name, prefix, key_hash, scopes) VALUES ($1, $2, $3, $4) RETURNING `+apiKeyColumns,
+		key.Name, key.Prefix, hash, pq.Array(key.Scopes)), &created)
+	if err != nil {
+		return models.APIKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return created, nil
+}
+
+func (s *Storage) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	const op = "postgres.apikey.ListAPIKeys"
+
+	rows, err := s.conn(ctx).QueryContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer closeRows(ctx, rows)
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		var key models.APIKey
+		if err := scanAPIKey(rows, &key); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey marks the key revoked; revoking it again keeps the first
+// revocation time.
+func (s *Storage) RevokeAPIKey(ctx context.Context, id int64) (models.APIKey, error) {
+	const op = "postgres.apikey.RevokeAPIKey"
+
+	var key models.APIKey
+	err := scanAPIKey(s.conn(ctx).QueryRowContext(ctx,
+		`UPDATE api_keys SET revoked_at = COALESCE(revoked_at, NOW()) WHERE id = $1 RETURNING `+apiKeyColumns,
+		id), &key)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.APIKey{}, storage.ErrAPIKeyNotFound
+		}
+		return models.APIKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return key, nil
+}
+
+// APIKeyByHash returns the key with the given hash, revoked or not.
+func (s *Storage) APIKeyByHash(ctx context.Context, hash string) (models.APIKey, error) {
+	const op = "postgres.apikey.APIKeyByHash"
+
+	var key models.APIKey
+	err := scanAPIKey(s.conn(ctx).QueryRowContext(ctx,
+		"SELECT "+apiKeyColumns+" FROM api_keys WHERE key_hash = $1", hash), &key)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.APIKey{}, storage.ErrAPIKeyNotFound
+		}
+		return models.APIKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return key, nil
+}
+
+// TouchAPIKey records that the key was used at the given time.
+func (s *Storage) TouchAPIKey(ctx context.Context, id int64, at time.Time) error {
+	const op = "postgres.apikey.TouchAPIKey"
+
+	_, err := s.conn(ctx).ExecContext(ctx,
+		"UPDATE api_keys SET last_used_at = $2 WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < $2)", id, at)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/2pizzzza/TestTask/internal/enrichment"
+	"github.com/2pizzzza/TestTask/internal/storage"
+	"github.com/2pizzzza/TestTask/internal/utils"
+)
+
+func (s *Storage) GetCacheEntry(ctx context.Context, key string) (enrichment.Entry, error) {
+	const op = "postgres.enrichment.GetCacheEntry"
+
+	var (
+		entry   enrichment.Entry
+		payload []byte
+	)
+
+	err := s.conn(ctx).QueryRowContext(ctx,
+		"SELECT payload, not_found, expires_at FROM enrichment_cache WHERE cache_key = $1",
+		key).Scan(&payload, &entry.NotFound, &entry.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return enrichment.Entry{}, storage.ErrCacheEntryNotFound
+		}
+		return enrichment.Entry{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(payload) > 0 {
+		var info utils.TrackInfo
+		if err := json.Unmarshal(payload, &info); err != nil {
+			return enrichment.Entry{}, fmt.Errorf("%s: %w", op, err)
+		}
+		entry.Info = &info
+	}
+
+	return entry, nil
+}
+
+func (s *Storage) SaveCacheEntry(ctx context.Context, key string, entry enrichment.Entry) error {
+	const op = "postgres.enrichment.SaveCacheEntry"
+
+	var payload []byte
+	if entry.Info != nil {
+		var err error
+		payload, err = json.Marshal(entry.Info)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	_, err := s.conn(ctx).ExecContext(ctx,
+		`INSERT INTO enrichment_cache (cache_key, payload, not_found, expires_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (cache_key) DO UPDATE SET payload = EXCLUDED.payload, not_found = EXCLUDED.not_found, expires_at = EXCLUDED.expires_at`,
+		key, payload, entry.NotFound, entry.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) DeleteCacheEntry(ctx context.Context, key string) error {
+	const op = "postgres.enrichment.DeleteCacheEntry"
+
+	if _, err := s.conn(ctx).ExecContext(ctx, "DELETE FROM enrichment_cache WHERE cache_key = $1", key); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) PurgeCache(ctx context.Context) error {
+	const op = "postgres.enrichment.PurgeCache"
+
+	if _, err := s.conn(ctx).ExecContext(ctx, "DELETE FROM enrichment_cache"); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
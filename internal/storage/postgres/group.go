@@ -0,0 +1,132 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/events"
+	"github.com/2pizzzza/TestTask/internal/storage"
+)
+
+// MergeGroups moves the songs of the from groups into the into group,
+// creating it if needed, and deletes the groups left empty. A song whose
+// title already exists in the target stays where it is. With dryRun the
+// changes are rolled back to a savepoint, so the result only reports what
+// would happen, also inside a larger unit of work.
+func (s *Storage) MergeGroups(
+	ctx context.Context, from []string, into string, dryRun bool) (models.GroupMergeResult, error) {
+
+	const op = "postgres.group.MergeGroups"
+
+	var result models.GroupMergeResult
+	err := s.WithinTx(ctx, func(ctx context.Context) error {
+		q := s.conn(ctx)
+
+		if dryRun {
+			if _, err := q.ExecContext(ctx, "SAVEPOINT merge_dry_run"); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		result, err = mergeGroups(ctx, q, from, into)
+		if err != nil {
+			return err
+		}
+		result.DryRun = dryRun
+
+		if dryRun {
+			_, err = q.ExecContext(ctx, "ROLLBACK TO SAVEPOINT merge_dry_run")
+		}
+		return err
+	})
+	if err != nil {
+		return models.GroupMergeResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return result, nil
+}
+
+func mergeGroups(ctx context.Context, q dbtx, from []string, into string) (models.GroupMergeResult, error) {
+	intoId, err := groupID(ctx, q, into)
+	if err != nil {
+		return models.GroupMergeResult{}, err
+	}
+
+	result := models.GroupMergeResult{Into: into}
+	for _, name := range from {
+		var fromId int64
+		err := q.QueryRowContext(ctx, "SELECT id FROM groups WHERE group_name = $1", name).Scan(&fromId)
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.GroupMergeResult{}, fmt.Errorf("%q: %w", name, storage.ErrGroupNotFound)
+		}
+		if err != nil {
+			return models.GroupMergeResult{}, err
+		}
+
+		moved, err := moveSongs(ctx, q, fromId, intoId)
+		if err != nil {
+			return models.GroupMergeResult{}, err
+		}
+		slices.Sort(moved)
+		result.Moved = append(result.Moved, moved...)
+
+		conflicts, err := songIDs(ctx, q, "SELECT id FROM songs WHERE group_id = $1 ORDER BY id", fromId)
+		if err != nil {
+			return models.GroupMergeResult{}, err
+		}
+		if len(conflicts) > 0 {
+			result.Conflicts = append(result.Conflicts, conflicts...)
+			continue
+		}
+
+		if _, err := q.ExecContext(ctx, "DELETE FROM groups WHERE id = $1", fromId); err != nil {
+			return models.GroupMergeResult{}, err
+		}
+		result.RemovedGroups = append(result.RemovedGroups, name)
+	}
+
+	for _, id := range result.Moved {
+		song, err := getSong(ctx, q, id)
+		if err != nil {
+			return models.GroupMergeResult{}, err
+		}
+		if err := addEvent(ctx, q, events.SongUpdated, song); err != nil {
+			return models.GroupMergeResult{}, err
+		}
+	}
+
+	return result, nil
+}
+
+// moveSongs moves every song of group from into group into unless a song
+// with the same title is already there, and returns the moved ids.
+func moveSongs(ctx context.Context, q dbtx, from, into int64) ([]int64, error) {
+	return songIDs(ctx, q,
+		`UPDATE songs s SET group_id = $2 WHERE s.group_id = $1
+		AND NOT EXISTS (SELECT 1 FROM songs t WHERE t.group_id = $2 AND t.song_title = s.song_title)
+		RETURNING s.id`, from, into)
+}
+
+func songIDs(ctx context.Context, q dbtx, query string, args ...any) ([]int64, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows(ctx, rows)
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
@@ -0,0 +1,45 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+
+	"github.com/2pizzzza/TestTask/db"
+	"github.com/2pizzzza/TestTask/internal/config"
+	"github.com/2pizzzza/TestTask/internal/storage/migration"
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+// NewMigrator opens its own connection, separate from the Storage pool.
+func NewMigrator(cfg config.DatabaseConfig, log *slog.Logger) (*migration.Migrator, error) {
+	const op = "postgres.migrate.NewMigrator"
+
+	src, err := openMigrations()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Migrations may legitimately run longer than a regular query.
+	cfg.StatementTimeout = 0
+	dsn := cfg.DSN()
+	m, err := migrate.NewWithSourceInstance("iofs", src, dsn)
+	if err != nil {
+		// golang-migrate quotes the url, password included.
+		if u, parseErr := url.Parse(dsn); parseErr == nil {
+			err = errors.New(strings.ReplaceAll(err.Error(), dsn, u.Redacted()))
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return migration.New(m, openMigrations, log, nil), nil
+}
+
+func openMigrations() (source.Driver, error) {
+	return iofs.New(db.Migrations, "migrations")
+}
@@ -0,0 +1,92 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/events"
+	"github.com/lib/pq"
+)
+
+// addEvent writes an event to the outbox; call it inside the transaction
+// that makes the change so both commit or roll back together.
+func addEvent(ctx context.Context, q dbtx, eventType string, song models.Song) error {
+	payload, err := json.Marshal(events.NewPayload(eventType, song))
+	if err != nil {
+		return err
+	}
+
+	_, err = q.ExecContext(ctx, "INSERT INTO outbox (event_type, song_id, payload) VALUES ($1, $2, $3)",
+		eventType, song.Id, payload)
+	return err
+}
+
+// FetchUnpublished locks the returned rows for the rest of the transaction
+// in ctx and skips rows another relay has locked.
+func (s *Storage) FetchUnpublished(ctx context.Context, limit int) ([]events.Event, error) {
+	const op = "postgres.outbox.FetchUnpublished"
+
+	rows, err := s.conn(ctx).QueryContext(ctx,
+		`SELECT id, event_type, song_id, payload, created_at FROM outbox
+		WHERE published_at IS NULL ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer closeRows(ctx, rows)
+
+	var result []events.Event
+	for rows.Next() {
+		var event events.Event
+		if err := rows.Scan(&event.Id, &event.Type, &event.SongId, &event.Payload, &event.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		result = append(result, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return result, nil
+}
+
+func (s *Storage) MarkPublished(ctx context.Context, ids []int64) error {
+	const op = "postgres.outbox.MarkPublished"
+
+	if _, err := s.conn(ctx).ExecContext(ctx,
+		"UPDATE outbox SET published_at = NOW() WHERE id = ANY($1)", pq.Array(ids)); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// PublishedSince returns already published events with an id greater than lastId.
+func (s *Storage) PublishedSince(ctx context.Context, lastId int64, limit int) ([]events.Event, error) {
+	const op = "postgres.outbox.PublishedSince"
+
+	rows, err := s.conn(ctx).QueryContext(ctx,
+		`SELECT id, event_type, song_id, payload, created_at FROM outbox
+		WHERE id > $1 AND published_at IS NOT NULL ORDER BY id LIMIT $2`, lastId, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer closeRows(ctx, rows)
+
+	var result []events.Event
+	for rows.Next() {
+		var event events.Event
+		if err := rows.Scan(&event.Id, &event.Type, &event.SongId, &event.Payload, &event.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		result = append(result, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return result, nil
+}
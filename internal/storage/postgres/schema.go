@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/2pizzzza/TestTask/internal/storage/migration"
+)
+
+// Ping checks that the database accepts connections.
+func (s *Storage) Ping(ctx context.Context) error {
+	const op = "postgres.schema.Ping"
+
+	if err := s.Db.PingContext(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SchemaVersion returns the applied migration version and whether the last
+// migration failed half way.
+func (s *Storage) SchemaVersion(ctx context.Context) (uint, bool, error) {
+	const op = "postgres.schema.SchemaVersion"
+
+	var (
+		version int64
+		dirty   bool
+	)
+	err := s.Db.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, false, nil
+		}
+		return 0, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return uint(version), dirty, nil
+}
+
+// LatestMigration returns the newest migration version shipped with the service.
+func LatestMigration() (uint, error) {
+	const op = "postgres.schema.LatestMigration"
+
+	latest, err := migration.Latest(openMigrations)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return latest, nil
+}
+
+// CheckSchema fails when a migration is dirty or the database is behind the
+// migrations shipped with the service. A newer schema is accepted so that an
+// older binary keeps working during a rollout.
+func (s *Storage) CheckSchema(ctx context.Context) error {
+	const op = "postgres.schema.CheckSchema"
+
+	latest, err := LatestMigration()
+	if err != nil {
+		return err
+	}
+
+	version, dirty, err := s.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	if dirty {
+		return fmt.Errorf("%s: migration %d is dirty", op, version)
+	}
+	if version < latest {
+		return fmt.Errorf("%s: schema version is %d, expected %d", op, version, latest)
+	}
+
+	return nil
+}
@@ -6,40 +6,113 @@ import (
 	"errors"
 	"fmt"
 	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/events"
+	"github.com/2pizzzza/TestTask/internal/lib/logger/sl"
 	"github.com/2pizzzza/TestTask/internal/storage"
-	"log"
+	"log/slog"
 )
 
+const selectSong = `SELECT s.id, g.id, g.group_name, s.song_title, COALESCE(s.release_date, ''),
+	COALESCE(s.lyrics, ''), COALESCE(s.lyrics_source, ''), COALESCE(s.link, '')
+	FROM songs s JOIN groups g ON g.id = s.group_id`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSong(row scanner, song *models.Song) error {
+	return row.Scan(&song.Id, &song.GroupName.Id, &song.GroupName.GroupName, &song.SongName,
+		&song.ReleaseDate, &song.Lyrics, &song.LyricsSource, &song.Link)
+}
+
+// groupID returns the id of the group with the given name, creating it if needed.
+func groupID(ctx context.Context, q dbtx, groupName string) (int64, error) {
+	var id int64
+	err := q.QueryRowContext(ctx,
+		`INSERT INTO groups (group_name) VALUES ($1)
+		ON CONFLICT (group_name) DO UPDATE SET group_name = EXCLUDED.group_name RETURNING id`,
+		groupName).Scan(&id)
+	return id, err
+}
+
+func getSong(ctx context.Context, q dbtx, id int64) (models.Song, error) {
+	var song models.Song
+	err := scanSong(q.QueryRowContext(ctx, selectSong+" WHERE s.id = $1", id), &song)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Song{}, storage.ErrSongNotFound
+	}
+	return song, err
+}
+
+// Save runs as a unit of work: the existence check, the group lookup, the
+// insert and the outbox events commit together.
 func (s *Storage) Save(
-	ctx context.Context, groupName, songName, releaseDate, link string) (string, error) {
+	ctx context.Context, groupName, songName string, details models.SongDetails) (string, error) {
 
 	const op = "postgres.song.Save"
 
-	var exists bool
-	err := s.Db.QueryRow(
-		"SELECT EXISTS(SELECT 1 FROM songs WHERE group_name = $1 AND song_title = $2)",
-		groupName, songName).Scan(&exists)
+	err := s.WithinTx(ctx, func(ctx context.Context) error {
+		q := s.conn(ctx)
+
+		var exists bool
+		err := q.QueryRowContext(ctx,
+			`SELECT EXISTS(SELECT 1 FROM songs s JOIN groups g ON g.id = s.group_id
+			WHERE g.group_name = $1 AND s.song_title = $2)`,
+			groupName, songName).Scan(&exists)
+		if err != nil {
+			logger(ctx).Error("failed to check existence", slog.String("op", op), sl.Err(err))
+			return err
+		}
 
-	if err != nil {
-		log.Printf("failed to check existence: %v op: %s", err, op)
-		return "", fmt.Errorf("%s, %w", op, err)
-	}
+		if exists {
+			return storage.ErrSongExists
+		}
 
-	if exists {
-		log.Printf("Song already exists: group %s, song %s", groupName, songName)
-		return "Song already exists", fmt.Errorf("the song '%s' by group '%s' already exists", songName, groupName)
-	}
+		groupId, err := groupID(ctx, q, groupName)
+		if err != nil {
+			logger(ctx).Error("failed to resolve group", slog.String("op", op), sl.Err(err))
+			return err
+		}
+
+		var songId int64
+		err = q.QueryRowContext(ctx,
+			`INSERT INTO songs (group_id, song_title, release_date, link, lyrics, lyrics_source)
+			VALUES($1, $2, $3, $4, $5, $6) RETURNING id`,
+			groupId, songName, details.ReleaseDate, details.Link, details.Lyrics, details.LyricsSource).Scan(&songId)
+		if err != nil {
+			logger(ctx).Error("failed to create song", slog.String("op", op), sl.Err(err))
+			return err
+		}
+
+		if err := saveProviderLinks(ctx, q, songId, details.Links); err != nil {
+			logger(ctx).Error("failed to save song links", slog.String("op", op), sl.Err(err))
+			return err
+		}
 
-	_, err = s.Db.Exec(
-		"INSERT INTO songs (group_name, song_title, release_date, link) VALUES($1, $2, $3 ,$4)",
-		groupName, songName, releaseDate, link)
+		song, err := getSong(ctx, q, songId)
+		if err != nil {
+			return err
+		}
 
+		if err := addEvent(ctx, q, events.SongCreated, song); err != nil {
+			return err
+		}
+		if song.Lyrics != "" {
+			return addEvent(ctx, q, events.LyricsChanged, song)
+		}
+		return nil
+	})
+	if errors.Is(err, storage.ErrSongExists) {
+		logger(ctx).Info("song already exists", slog.String("op", op),
+			slog.String("group", groupName), slog.String("song", songName))
+		return "Song already exists", fmt.Errorf("%s: %w", op, storage.ErrSongExists)
+	}
 	if err != nil {
-		log.Printf("failed to create song: %v op: %s", err, op)
 		return "", fmt.Errorf("%s, %w", op, err)
 	}
 
-	log.Println("Song created successfully: group ", groupName, "song", songName)
+	logger(ctx).Info("song created", slog.String("op", op),
+		slog.String("group", groupName), slog.String("song", songName))
 	return "Success create song", nil
 }
 
@@ -48,21 +121,9 @@ func (s *Storage) GetById(
 
 	const op = "postgres.song.GetById"
 
-	var song models.Song
-
-	stmt, err := s.Db.Prepare("SELECT id, group_name, song_title, release_date, lyrics, link FROM songs WHERE id = $1")
-	if err != nil {
-		return models.Song{}, fmt.Errorf("%s, %w", op, err)
-	}
-	defer func() {
-		if closeErr := stmt.Close(); closeErr != nil {
-			log.Printf("failed to close statement: %v", closeErr)
-		}
-	}()
-
-	err = stmt.QueryRow(id).Scan(&song.Id, &song.GroupName, &song.SongName, &song.ReleaseDate, &song.Lyrics, &song.Link)
+	song, err := getSong(ctx, s.conn(ctx), id)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
+		if errors.Is(err, storage.ErrSongNotFound) {
 			return models.Song{}, storage.ErrSongNotFound
 		}
 		return models.Song{}, fmt.Errorf("%s, %w", op, err)
@@ -76,33 +137,38 @@ func (s *Storage) Update(
 
 	const op = "postgres.song.Update"
 
-	stmt, err := s.Db.Prepare("UPDATE songs SET group_name = $2, song_title = $3 WHERE id = $1")
-	if err != nil {
-		return models.Song{}, fmt.Errorf("%s, %w", op, err)
-	}
-	defer func() {
-		if closeErr := stmt.Close(); closeErr != nil {
-			log.Printf("failed to close statement: %v", closeErr)
+	var song models.Song
+	err := s.WithinTx(ctx, func(ctx context.Context) error {
+		q := s.conn(ctx)
+
+		groupId, err := groupID(ctx, q, newGroupName)
+		if err != nil {
+			return err
 		}
-	}()
 
-	res, err := stmt.Exec(id, newGroupName, newSongName)
-	if err != nil {
-		return models.Song{}, fmt.Errorf("%s, %w", op, err)
-	}
+		res, err := q.ExecContext(ctx, "UPDATE songs SET group_id = $2, song_title = $3 WHERE id = $1", id, groupId, newSongName)
+		if err != nil {
+			return err
+		}
 
-	rowsAffected, err := res.RowsAffected()
-	if err != nil {
-		return models.Song{}, fmt.Errorf("%s, %w", op, err)
-	}
+		rowsAffected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
 
-	if rowsAffected == 0 {
-		return models.Song{}, fmt.Errorf("%s: song with id %d not found", op, id)
-	}
+		if rowsAffected == 0 {
+			return storage.ErrSongNotFound
+		}
+
+		song, err = getSong(ctx, q, id)
+		if err != nil {
+			return err
+		}
 
-	song, err := s.GetById(ctx, id)
+		return addEvent(ctx, q, events.SongUpdated, song)
+	})
 	if err != nil {
-		return models.Song{}, fmt.Errorf("%s, %w", op, err)
+		return models.Song{}, fmt.Errorf("%s: %w", op, err)
 	}
 
 	return song, nil
@@ -111,73 +177,94 @@ func (s *Storage) Update(
 func (s *Storage) Remove(ctx context.Context, id int64) (string, error) {
 	const op = "postgres.song.Remove"
 
-	stmt, err := s.Db.Prepare("DELETE FROM songs WHERE id = $1")
-	if err != nil {
-		return "", fmt.Errorf("%s: %w", op, err)
-	}
-	defer func() {
-		if closeErr := stmt.Close(); closeErr != nil {
-			log.Printf("failed to close statement: %v", closeErr)
+	err := s.WithinTx(ctx, func(ctx context.Context) error {
+		q := s.conn(ctx)
+
+		song, err := getSong(ctx, q, id)
+		if err != nil {
+			return err
 		}
-	}()
 
-	res, err := stmt.Exec(id)
-	if err != nil {
-		return "", fmt.Errorf("%s: %w", op, err)
-	}
+		if _, err := q.ExecContext(ctx, "DELETE FROM songs WHERE id = $1", id); err != nil {
+			return err
+		}
 
-	rowsAffected, err := res.RowsAffected()
+		return addEvent(ctx, q, events.SongDeleted, song)
+	})
 	if err != nil {
+		if errors.Is(err, storage.ErrSongNotFound) {
+			return "", storage.ErrSongNotFound
+		}
 		return "", fmt.Errorf("%s: %w", op, err)
 	}
 
-	if rowsAffected == 0 {
-		return "", storage.ErrSongNotFound
-	}
-
 	return fmt.Sprintf("Successfully deleted song id: %d", id), nil
 }
 
 func (s *Storage) GetAll(ctx context.Context, filter models.SongFilter, limit, offset int) (songs []*models.Song, err error) {
 	const op = "postgres.song.GetAll"
 
-	query := "SELECT * FROM songs WHERE TRUE"
+	query := selectSong + " WHERE TRUE"
 	args := []interface{}{}
 	argCount := 1
 
 	if filter.GroupName != "" {
-		query += fmt.Sprintf(" AND group_name ILIKE $%d", argCount)
+		query += fmt.Sprintf(" AND g.group_name ILIKE $%d", argCount)
 		args = append(args, "%"+filter.GroupName+"%")
 		argCount++
 	}
 	if filter.SongName != "" {
-		query += fmt.Sprintf(" AND song_title ILIKE $%d", argCount)
+		query += fmt.Sprintf(" AND s.song_title ILIKE $%d", argCount)
 		args = append(args, "%"+filter.SongName+"%")
 		argCount++
 	}
 	if filter.ReleaseDate != "" {
-		query += fmt.Sprintf(" AND release_date = $%d", argCount)
+		query += fmt.Sprintf(" AND s.release_date = $%d", argCount)
 		args = append(args, filter.ReleaseDate)
 		argCount++
 	}
 
-	query += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d OFFSET $%d", argCount, argCount+1)
+	query += fmt.Sprintf(" ORDER BY s.id DESC LIMIT $%d OFFSET $%d", argCount, argCount+1)
 	args = append(args, limit, offset)
 
-	rows, err := s.Db.Query(query, args...)
+	rows, err := s.conn(ctx).QueryContext(ctx, query, args...)
 	if err != nil {
-		log.Printf("failed to query songs: %v", err)
+		logger(ctx).Error("failed to query songs", slog.String("op", op), sl.Err(err))
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
-	defer func() {
-		if closeErr := rows.Close(); closeErr != nil {
-			log.Printf("failed to close rows: %v", closeErr)
+	defer closeRows(ctx, rows)
+
+	for rows.Next() {
+		var song models.Song
+		if err := scanSong(rows, &song); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
 		}
-	}()
+		songs = append(songs, &song)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return songs, nil
+}
+
+// ListIncomplete returns songs after the given id that lack a release date,
+// link or lyrics, in ascending id order.
+func (s *Storage) ListIncomplete(ctx context.Context, afterId int64, limit int) (songs []*models.Song, err error) {
+	const op = "postgres.song.ListIncomplete"
+
+	rows, err := s.conn(ctx).QueryContext(ctx, selectSong+` WHERE s.id > $1
+		AND (COALESCE(s.release_date, '') = '' OR COALESCE(s.link, '') = '' OR COALESCE(s.lyrics, '') = '')
+		ORDER BY s.id LIMIT $2`, afterId, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer closeRows(ctx, rows)
 
 	for rows.Next() {
 		var song models.Song
-		if err := rows.Scan(&song.Id, &song.GroupName, &song.SongName, &song.ReleaseDate, &song.Lyrics, &song.Link); err != nil {
+		if err := scanSong(rows, &song); err != nil {
 			return nil, fmt.Errorf("%s: %w", op, err)
 		}
 		songs = append(songs, &song)
@@ -189,3 +276,113 @@ func (s *Storage) GetAll(ctx context.Context, filter models.SongFilter, limit, o
 
 	return songs, nil
 }
+
+// FillDetails sets only the fields that are still empty, so values entered
+// by hand are never overwritten.
+func (s *Storage) FillDetails(ctx context.Context, id int64, details models.SongDetails) error {
+	const op = "postgres.song.FillDetails"
+
+	err := s.WithinTx(ctx, func(ctx context.Context) error {
+		q := s.conn(ctx)
+
+		before, err := getSong(ctx, q, id)
+		if err != nil {
+			return err
+		}
+
+		_, err = q.ExecContext(ctx, `UPDATE songs SET
+			release_date = CASE WHEN COALESCE(release_date, '') = '' THEN $2 ELSE release_date END,
+			link = CASE WHEN COALESCE(link, '') = '' THEN $3 ELSE link END,
+			lyrics_source = CASE WHEN COALESCE(lyrics, '') = '' AND $4 <> '' THEN $5 ELSE lyrics_source END,
+			lyrics = CASE WHEN COALESCE(lyrics, '') = '' THEN $4 ELSE lyrics END
+			WHERE id = $1`,
+			id, details.ReleaseDate, details.Link, details.Lyrics, details.LyricsSource)
+		if err != nil {
+			return err
+		}
+
+		if err := saveProviderLinks(ctx, q, id, details.Links); err != nil {
+			return err
+		}
+
+		after, err := getSong(ctx, q, id)
+		if err != nil {
+			return err
+		}
+
+		if after != before {
+			if err := addEvent(ctx, q, events.SongUpdated, after); err != nil {
+				return err
+			}
+		}
+		if after.Lyrics != before.Lyrics {
+			return addEvent(ctx, q, events.LyricsChanged, after)
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrSongNotFound) {
+			return storage.ErrSongNotFound
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SetLyrics replaces the lyrics of a song and records where they came from.
+func (s *Storage) SetLyrics(ctx context.Context, id int64, lyrics, source string) (models.Song, error) {
+	const op = "postgres.song.SetLyrics"
+
+	var after models.Song
+	err := s.WithinTx(ctx, func(ctx context.Context) error {
+		q := s.conn(ctx)
+
+		before, err := getSong(ctx, q, id)
+		if err != nil {
+			return err
+		}
+
+		_, err = q.ExecContext(ctx, "UPDATE songs SET lyrics = $2, lyrics_source = $3 WHERE id = $1", id, lyrics, source)
+		if err != nil {
+			return err
+		}
+
+		after, err = getSong(ctx, q, id)
+		if err != nil {
+			return err
+		}
+
+		if after.Lyrics != before.Lyrics {
+			if err := addRevision(ctx, q, before); err != nil {
+				return err
+			}
+			return addEvent(ctx, q, events.LyricsChanged, after)
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrSongNotFound) {
+			return models.Song{}, storage.ErrSongNotFound
+		}
+		return models.Song{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return after, nil
+}
+
+// CatalogStats counts songs, groups and songs that have no lyrics yet.
+func (s *Storage) CatalogStats(ctx context.Context) (models.CatalogStats, error) {
+	const op = "postgres.song.CatalogStats"
+
+	var stats models.CatalogStats
+	err := s.conn(ctx).QueryRowContext(ctx,
+		`SELECT (SELECT COUNT(*) FROM songs), (SELECT COUNT(*) FROM groups),
+		(SELECT COUNT(*) FROM songs WHERE COALESCE(lyrics, '') = '')`).
+		Scan(&stats.Songs, &stats.Groups, &stats.MissingLyrics)
+	if err != nil {
+		return models.CatalogStats{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return stats, nil
+}
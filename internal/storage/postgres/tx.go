@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/2pizzzza/TestTask/internal/lib/logger/sl"
+	"github.com/2pizzzza/TestTask/internal/storage"
+	"github.com/lib/pq"
+)
+
+// dbtx is implemented by both *sql.DB and *sql.Tx.
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type txKey struct{}
+
+type txValue struct {
+	owner *Storage
+	tx    *sql.Tx
+}
+
+// WithinTx runs fn as one unit of work: repository calls made with the
+// context passed to fn share a serializable transaction that commits when fn
+// returns nil and rolls back otherwise. When postgres aborts the transaction
+// with a serialization failure or a deadlock, fn runs again from the start,
+// so it must not have side effects outside the database. A nested call joins
+// the outer transaction.
+func (s *Storage) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	const op = "postgres.tx.WithinTx"
+
+	if s.txFrom(ctx) != nil {
+		return fn(ctx)
+	}
+
+	attempt := 0
+	return storage.RetryTx(ctx, isRetryable, func() error {
+		attempt++
+		if attempt > 1 {
+			logger(ctx).Warn("retrying transaction", slog.String("op", op), slog.Int("attempt", attempt))
+		}
+
+		tx, err := s.Db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		defer rollback(ctx, tx)
+
+		if err := fn(context.WithValue(ctx, txKey{}, txValue{owner: s, tx: tx})); err != nil {
+			return err
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		return nil
+	})
+}
+
+func (s *Storage) txFrom(ctx context.Context) *sql.Tx {
+	if v, ok := ctx.Value(txKey{}).(txValue); ok && v.owner == s {
+		return v.tx
+	}
+	return nil
+}
+
+// conn returns the transaction of the unit of work in ctx, or the pool when
+// there is none.
+func (s *Storage) conn(ctx context.Context) dbtx {
+	if tx := s.txFrom(ctx); tx != nil {
+		return tx
+	}
+	return s.Db
+}
+
+// isRetryable reports whether err aborted the transaction only because of
+// concurrent ones: serialization_failure or deadlock_detected.
+func isRetryable(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && (pqErr.Code == "40001" || pqErr.Code == "40P01")
+}
+
+func rollback(ctx context.Context, tx *sql.Tx) {
+	if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+		logger(ctx).Error("failed to rollback transaction", sl.Err(err))
+	}
+}
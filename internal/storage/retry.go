@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+const (
+	// TxAttempts bounds how often a unit of work runs before the last
+	// retryable error is returned.
+	TxAttempts     = 5
+	txBackoffBase  = 10 * time.Millisecond
+	txBackoffLimit = 200 * time.Millisecond
+)
+
+// RetryTx runs attempt until it succeeds, fails with an error retryable
+// rejects, TxAttempts runs are used up or ctx is done. Between runs it waits
+// a doubling, jittered delay so competing transactions do not collide again.
+func RetryTx(ctx context.Context, retryable func(error) bool, attempt func() error) error {
+	backoff := txBackoffBase
+	for i := 1; ; i++ {
+		err := attempt()
+		if err == nil || i == TxAttempts || !retryable(err) {
+			return err
+		}
+
+		timer := time.NewTimer(backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1)))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		backoff = min(2*backoff, txBackoffLimit)
+	}
+}
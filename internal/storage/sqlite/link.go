@@ -0,0 +1,231 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/storage"
+)
+
+const linkColumns = `id, song_id, platform, url, source, created_at,
+	COALESCE(status_code, 0), check_error, redirect_url, broken, failures, last_checked_at`
+
+const selectLink = "SELECT " + linkColumns + " FROM song_links"
+
+func scanLink(row scanner, link *models.SongLink) error {
+	var lastChecked sql.NullTime
+	err := row.Scan(&link.Id, &link.SongId, &link.Platform, &link.URL, &link.Source, &link.CreatedAt,
+		&link.StatusCode, &link.CheckError, &link.RedirectURL, &link.Broken, &link.Failures, &lastChecked)
+	if err != nil {
+		return err
+	}
+	if lastChecked.Valid {
+		link.LastCheckedAt = &lastChecked.Time
+	}
+	return nil
+}
+
+func (s *Storage) songExists(ctx context.Context, songId int64) error {
+	var exists bool
+	if err := s.conn(ctx).QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM songs WHERE id = ?1)", songId).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return storage.ErrSongNotFound
+	}
+	return nil
+}
+
+func (s *Storage) AddLink(ctx context.Context, songId int64, platform, url string) (models.SongLink, error) {
+	const op = "sqlite.link.AddLink"
+
+	if err := s.songExists(ctx, songId); err != nil {
+		return models.SongLink{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var exists bool
+	err := s.conn(ctx).QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM song_links WHERE song_id = ?1 AND url = ?2)",
+		songId, url).Scan(&exists)
+	if err != nil {
+		return models.SongLink{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if exists {
+		return models.SongLink{}, fmt.Errorf("%s: %w", op, storage.ErrLinkExists)
+	}
+
+	var link models.SongLink
+	err = scanLink(s.conn(ctx).QueryRowContext(ctx,
+		`INSERT INTO song_links (song_id, platform, url, source, created_at) VALUES (?1, ?2, ?3, ?4, ?5)
+		RETURNING `+linkColumns,
+		songId, platform, url, models.LinkSourceManual, time.Now().UTC()), &link)
+	if err != nil {
+		return models.SongLink{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return link, nil
+}
+
+func (s *Storage) GetLinks(ctx context.Context, songId int64) ([]models.SongLink, error) {
+	const op = "sqlite.link.GetLinks"
+
+	if err := s.songExists(ctx, songId); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := s.conn(ctx).QueryContext(ctx, selectLink+" WHERE song_id = ?1 ORDER BY platform, id", songId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return collectLinks(ctx, op, rows)
+}
+
+// UpdateLink replaces a link; the edited link becomes a manual one.
+func (s *Storage) UpdateLink(ctx context.Context, songId, linkId int64, platform, url string) (models.SongLink, error) {
+	const op = "sqlite.link.UpdateLink"
+
+	var link models.SongLink
+	err := scanLink(s.conn(ctx).QueryRowContext(ctx,
+		`UPDATE song_links SET platform = ?3, url = ?4, source = ?5,
+		status_code = NULL, check_error = '', redirect_url = '', broken = FALSE, failures = 0, last_checked_at = NULL
+		WHERE id = ?1 AND song_id = ?2
+		RETURNING `+linkColumns,
+		linkId, songId, platform, url, models.LinkSourceManual), &link)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.SongLink{}, storage.ErrLinkNotFound
+		}
+		return models.SongLink{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return link, nil
+}
+
+func (s *Storage) RemoveLink(ctx context.Context, songId, linkId int64) error {
+	const op = "sqlite.link.RemoveLink"
+
+	res, err := s.conn(ctx).ExecContext(ctx, "DELETE FROM song_links WHERE id = ?1 AND song_id = ?2", linkId, songId)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return storage.ErrLinkNotFound
+	}
+
+	return nil
+}
+
+// saveProviderLinks stores links found by enrichment. A platform that already
+// has a manually entered link is left alone; an older provider link for the
+// platform is replaced.
+func saveProviderLinks(ctx context.Context, q dbtx, songId int64, links map[string]string) error {
+	for platform, url := range links {
+		if url == "" || !models.ValidPlatform(platform) {
+			continue
+		}
+		_, err := q.ExecContext(ctx,
+			`INSERT INTO song_links (song_id, platform, url, source, created_at)
+			SELECT ?1, ?2, ?3, ?4, ?6
+			WHERE NOT EXISTS (SELECT 1 FROM song_links WHERE song_id = ?1 AND platform = ?2 AND source = ?5)
+			ON CONFLICT (song_id, platform) WHERE source <> 'manual' DO UPDATE SET url = excluded.url`,
+			songId, platform, url, models.LinkSourceEnrichment, models.LinkSourceManual, time.Now().UTC())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Storage) GetBrokenLinks(ctx context.Context, limit, offset int) ([]models.SongLink, error) {
+	const op = "sqlite.link.GetBrokenLinks"
+
+	if limit < 0 || offset < 0 {
+		return nil, fmt.Errorf("%s: %w", op, errNegativePage)
+	}
+
+	rows, err := s.conn(ctx).QueryContext(ctx,
+		selectLink+" WHERE broken ORDER BY last_checked_at DESC NULLS FIRST, id LIMIT ?1 OFFSET ?2", limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return collectLinks(ctx, op, rows)
+}
+
+// LinksToCheck returns links never checked or last checked before the given time.
+func (s *Storage) LinksToCheck(ctx context.Context, checkedBefore time.Time, limit int) ([]models.SongLink, error) {
+	const op = "sqlite.link.LinksToCheck"
+
+	rows, err := s.conn(ctx).QueryContext(ctx, selectLink+` WHERE last_checked_at IS NULL OR last_checked_at < ?1
+		ORDER BY last_checked_at NULLS FIRST, id LIMIT ?2`, checkedBefore.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return collectLinks(ctx, op, rows)
+}
+
+func (s *Storage) SaveLinkCheck(ctx context.Context, linkId int64, check models.LinkCheck) error {
+	const op = "sqlite.link.SaveLinkCheck"
+
+	_, err := s.conn(ctx).ExecContext(ctx, `UPDATE song_links SET status_code = NULLIF(?2, 0), check_error = ?3, redirect_url = ?4,
+		broken = ?5, failures = ?6, last_checked_at = ?7 WHERE id = ?1`,
+		linkId, check.StatusCode, check.Error, check.RedirectURL, check.Broken, check.Failures, check.CheckedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ReplaceLinkURL points a link at a new URL, e.g. the target of a permanent redirect.
+func (s *Storage) ReplaceLinkURL(ctx context.Context, linkId int64, url string) error {
+	const op = "sqlite.link.ReplaceLinkURL"
+
+	res, err := s.conn(ctx).ExecContext(ctx, `UPDATE song_links SET url = ?2, redirect_url = '', broken = FALSE, failures = 0,
+		last_checked_at = NULL WHERE id = ?1`, linkId, url)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return storage.ErrLinkNotFound
+	}
+
+	return nil
+}
+
+func collectLinks(ctx context.Context, op string, rows *sql.Rows) ([]models.SongLink, error) {
+	defer closeRows(ctx, rows)
+
+	links := []models.SongLink{}
+	for rows.Next() {
+		var link models.SongLink
+		if err := scanLink(rows, &link); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		links = append(links, link)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return links, nil
+}
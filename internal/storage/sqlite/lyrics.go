@@ -0,0 +1,73 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/storage"
+)
+
+const revisionColumns = "id, song_id, lyrics, lyrics_source, created_at"
+
+func scanRevision(row scanner, r *models.LyricsRevision) error {
+	return row.Scan(&r.Id, &r.SongId, &r.Lyrics, &r.LyricsSource, &r.CreatedAt)
+}
+
+// addRevision keeps the lyrics a song had before a change; call it inside
+// the transaction that makes the change.
+func addRevision(ctx context.Context, q dbtx, song models.Song) error {
+	_, err := q.ExecContext(ctx, "INSERT INTO lyrics_revisions (song_id, lyrics, lyrics_source, created_at) VALUES (?1, ?2, ?3, ?4)",
+		song.Id, song.Lyrics, song.LyricsSource, time.Now().UTC())
+	return err
+}
+
+// LyricsRevisions lists the earlier lyrics of a song, newest first.
+func (s *Storage) LyricsRevisions(ctx context.Context, songId int64) ([]models.LyricsRevision, error) {
+	const op = "sqlite.lyrics.LyricsRevisions"
+
+	if err := s.songExists(ctx, songId); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := s.conn(ctx).QueryContext(ctx,
+		"SELECT "+revisionColumns+" FROM lyrics_revisions WHERE song_id = ?1 ORDER BY id DESC", songId)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer closeRows(ctx, rows)
+
+	revisions := []models.LyricsRevision{}
+	for rows.Next() {
+		var r models.LyricsRevision
+		if err := scanRevision(rows, &r); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		revisions = append(revisions, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return revisions, nil
+}
+
+// LyricsRevision returns one earlier version of the lyrics of a song.
+func (s *Storage) LyricsRevision(ctx context.Context, songId, revisionId int64) (models.LyricsRevision, error) {
+	const op = "sqlite.lyrics.LyricsRevision"
+
+	var r models.LyricsRevision
+	err := scanRevision(s.conn(ctx).QueryRowContext(ctx,
+		"SELECT "+revisionColumns+" FROM lyrics_revisions WHERE id = ?1 AND song_id = ?2", revisionId, songId), &r)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.LyricsRevision{}, storage.ErrRevisionNotFound
+		}
+		return models.LyricsRevision{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return r, nil
+}
@@ -0,0 +1,101 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/events"
+)
+
+// addEvent writes an event to the outbox; call it inside the transaction
+// that makes the change so both commit or roll back together.
+func addEvent(ctx context.Context, q dbtx, eventType string, song models.Song) error {
+	payload, err := json.Marshal(events.NewPayload(eventType, song))
+	if err != nil {
+		return err
+	}
+
+	_, err = q.ExecContext(ctx, "INSERT INTO outbox (event_type, song_id, payload, created_at) VALUES (?1, ?2, ?3, ?4)",
+		eventType, song.Id, string(payload), time.Now().UTC())
+	return err
+}
+
+// FetchUnpublished needs no row locks: SQLite runs one write transaction
+// at a time, so a relay transaction holds the whole outbox.
+func (s *Storage) FetchUnpublished(ctx context.Context, limit int) ([]events.Event, error) {
+	const op = "sqlite.outbox.FetchUnpublished"
+
+	rows, err := s.conn(ctx).QueryContext(ctx,
+		`SELECT id, event_type, song_id, payload, created_at FROM outbox
+		WHERE published_at IS NULL ORDER BY id LIMIT ?1`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return collectEvents(ctx, op, rows)
+}
+
+func (s *Storage) MarkPublished(ctx context.Context, ids []int64) error {
+	const op = "sqlite.outbox.MarkPublished"
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, len(ids))
+	args := []any{time.Now().UTC()}
+	for i, id := range ids {
+		placeholders[i] = "?" + strconv.Itoa(i+2)
+		args = append(args, id)
+	}
+
+	if _, err := s.conn(ctx).ExecContext(ctx,
+		"UPDATE outbox SET published_at = ?1 WHERE id IN ("+strings.Join(placeholders, ", ")+")", args...); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// PublishedSince returns already published events with an id greater than lastId.
+func (s *Storage) PublishedSince(ctx context.Context, lastId int64, limit int) ([]events.Event, error) {
+	const op = "sqlite.outbox.PublishedSince"
+
+	rows, err := s.conn(ctx).QueryContext(ctx,
+		`SELECT id, event_type, song_id, payload, created_at FROM outbox
+		WHERE id > ?1 AND published_at IS NOT NULL ORDER BY id LIMIT ?2`, lastId, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return collectEvents(ctx, op, rows)
+}
+
+func collectEvents(ctx context.Context, op string, rows *sql.Rows) ([]events.Event, error) {
+	defer closeRows(ctx, rows)
+
+	var result []events.Event
+	for rows.Next() {
+		var (
+			event   events.Event
+			payload string
+		)
+		if err := rows.Scan(&event.Id, &event.Type, &event.SongId, &payload, &event.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		event.Payload = json.RawMessage(payload)
+		result = append(result, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return result, nil
+}
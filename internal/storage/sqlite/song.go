@@ -0,0 +1,405 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/events"
+	"github.com/2pizzzza/TestTask/internal/lib/logger/sl"
+	"github.com/2pizzzza/TestTask/internal/storage"
+)
+
+const selectSong = `SELECT s.id, g.id, g.group_name, s.song_title, s.release_date,
+	s.lyrics, s.lyrics_source, s.link
+	FROM songs s JOIN groups g ON g.id = s.group_id`
+
+var errNegativePage = errors.New("limit and offset must not be negative")
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSong(row scanner, song *models.Song) error {
+	return row.Scan(&song.Id, &song.GroupName.Id, &song.GroupName.GroupName, &song.SongName,
+		&song.ReleaseDate, &song.Lyrics, &song.LyricsSource, &song.Link)
+}
+
+// groupID returns the id of the group with the given name, creating it if needed.
+func groupID(ctx context.Context, q dbtx, groupName string) (int64, error) {
+	var id int64
+	err := q.QueryRowContext(ctx,
+		`INSERT INTO groups (group_name) VALUES (?1)
+		ON CONFLICT (group_name) DO UPDATE SET group_name = excluded.group_name RETURNING id`,
+		groupName).Scan(&id)
+	return id, err
+}
+
+func getSong(ctx context.Context, q dbtx, id int64) (models.Song, error) {
+	var song models.Song
+	err := scanSong(q.QueryRowContext(ctx, selectSong+" WHERE s.id = ?1", id), &song)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Song{}, storage.ErrSongNotFound
+	}
+	return song, err
+}
+
+// Save runs as a unit of work: the existence check, the group lookup, the
+// insert and the outbox events commit together.
+func (s *Storage) Save(
+	ctx context.Context, groupName, songName string, details models.SongDetails) (string, error) {
+
+	const op = "sqlite.song.Save"
+
+	err := s.WithinTx(ctx, func(ctx context.Context) error {
+		q := s.conn(ctx)
+
+		var exists bool
+		err := q.QueryRowContext(ctx,
+			`SELECT EXISTS(SELECT 1 FROM songs s JOIN groups g ON g.id = s.group_id
+			WHERE g.group_name = ?1 AND s.song_title = ?2)`,
+			groupName, songName).Scan(&exists)
+		if err != nil {
+			logger(ctx).Error("failed to check existence", slog.String("op", op), sl.Err(err))
+			return err
+		}
+
+		if exists {
+			return storage.ErrSongExists
+		}
+
+		groupId, err := groupID(ctx, q, groupName)
+		if err != nil {
+			return err
+		}
+
+		var songId int64
+		err = q.QueryRowContext(ctx,
+			`INSERT INTO songs (group_id, song_title, release_date, link, lyrics, lyrics_source)
+			VALUES (?1, ?2, ?3, ?4, ?5, ?6) RETURNING id`,
+			groupId, songName, details.ReleaseDate, details.Link, details.Lyrics, details.LyricsSource).Scan(&songId)
+		if err != nil {
+			logger(ctx).Error("failed to create song", slog.String("op", op), sl.Err(err))
+			return err
+		}
+
+		if err := saveProviderLinks(ctx, q, songId, details.Links); err != nil {
+			return err
+		}
+
+		song, err := getSong(ctx, q, songId)
+		if err != nil {
+			return err
+		}
+
+		if err := addEvent(ctx, q, events.SongCreated, song); err != nil {
+			return err
+		}
+		if song.Lyrics != "" {
+			return addEvent(ctx, q, events.LyricsChanged, song)
+		}
+		return nil
+	})
+	if errors.Is(err, storage.ErrSongExists) {
+		logger(ctx).Info("song already exists", slog.String("op", op),
+			slog.String("group", groupName), slog.String("song", songName))
+		return "Song already exists", fmt.Errorf("%s: %w", op, storage.ErrSongExists)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	logger(ctx).Info("song created", slog.String("op", op),
+		slog.String("group", groupName), slog.String("song", songName))
+	return "Success create song", nil
+}
+
+func (s *Storage) GetById(ctx context.Context, id int64) (models.Song, error) {
+	const op = "sqlite.song.GetById"
+
+	song, err := getSong(ctx, s.conn(ctx), id)
+	if err != nil {
+		if errors.Is(err, storage.ErrSongNotFound) {
+			return models.Song{}, storage.ErrSongNotFound
+		}
+		return models.Song{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return song, nil
+}
+
+func (s *Storage) Update(
+	ctx context.Context, id int64, newGroupName, newSongName string) (models.Song, error) {
+
+	const op = "sqlite.song.Update"
+
+	var song models.Song
+	err := s.WithinTx(ctx, func(ctx context.Context) error {
+		q := s.conn(ctx)
+
+		groupId, err := groupID(ctx, q, newGroupName)
+		if err != nil {
+			return err
+		}
+
+		res, err := q.ExecContext(ctx, "UPDATE songs SET group_id = ?2, song_title = ?3 WHERE id = ?1", id, groupId, newSongName)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected == 0 {
+			return storage.ErrSongNotFound
+		}
+
+		song, err = getSong(ctx, q, id)
+		if err != nil {
+			return err
+		}
+
+		return addEvent(ctx, q, events.SongUpdated, song)
+	})
+	if err != nil {
+		return models.Song{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return song, nil
+}
+
+func (s *Storage) Remove(ctx context.Context, id int64) (string, error) {
+	const op = "sqlite.song.Remove"
+
+	err := s.WithinTx(ctx, func(ctx context.Context) error {
+		q := s.conn(ctx)
+
+		song, err := getSong(ctx, q, id)
+		if err != nil {
+			return err
+		}
+
+		if _, err := q.ExecContext(ctx, "DELETE FROM songs WHERE id = ?1", id); err != nil {
+			return err
+		}
+
+		return addEvent(ctx, q, events.SongDeleted, song)
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrSongNotFound) {
+			return "", storage.ErrSongNotFound
+		}
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return fmt.Sprintf("Successfully deleted song id: %d", id), nil
+}
+
+// GetAll matches the group and song filters as case-insensitive substrings
+// with ILIKE semantics, so % and _ in a filter are wildcards just as they are
+// on postgres. The trigram index picks the candidate rows and pg_ilike makes
+// the exact decision.
+func (s *Storage) GetAll(ctx context.Context, filter models.SongFilter, limit, offset int) (songs []*models.Song, err error) {
+	const op = "sqlite.song.GetAll"
+
+	if limit < 0 || offset < 0 {
+		return nil, fmt.Errorf("%s: %w", op, errNegativePage)
+	}
+
+	var (
+		where []string
+		match []string
+		args  []any
+	)
+	arg := func(value any) string {
+		args = append(args, value)
+		return "?" + strconv.Itoa(len(args))
+	}
+
+	if filter.GroupName != "" {
+		pattern := "%" + filter.GroupName + "%"
+		where = append(where, "pg_ilike(g.group_name, "+arg(pattern)+")")
+		if q, ok := ftsQuery("group_name", pattern); ok {
+			match = append(match, q)
+		}
+	}
+	if filter.SongName != "" {
+		pattern := "%" + filter.SongName + "%"
+		where = append(where, "pg_ilike(s.song_title, "+arg(pattern)+")")
+		if q, ok := ftsQuery("song_title", pattern); ok {
+			match = append(match, q)
+		}
+	}
+	if filter.ReleaseDate != "" {
+		where = append(where, "s.release_date = "+arg(filter.ReleaseDate))
+	}
+	if len(match) > 0 {
+		where = append(where, "s.id IN (SELECT rowid FROM songs_fts WHERE songs_fts MATCH "+
+			arg(strings.Join(match, " AND "))+")")
+	}
+
+	query := selectSong
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY s.id DESC LIMIT " + arg(limit) + " OFFSET " + arg(offset)
+
+	rows, err := s.conn(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		logger(ctx).Error("failed to query songs", slog.String("op", op), sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return collectSongs(ctx, op, rows)
+}
+
+// ListIncomplete returns songs after the given id that lack a release date,
+// link or lyrics, in ascending id order.
+func (s *Storage) ListIncomplete(ctx context.Context, afterId int64, limit int) (songs []*models.Song, err error) {
+	const op = "sqlite.song.ListIncomplete"
+
+	rows, err := s.conn(ctx).QueryContext(ctx, selectSong+` WHERE s.id > ?1
+		AND (s.release_date = '' OR s.link = '' OR s.lyrics = '')
+		ORDER BY s.id LIMIT ?2`, afterId, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return collectSongs(ctx, op, rows)
+}
+
+func collectSongs(ctx context.Context, op string, rows *sql.Rows) ([]*models.Song, error) {
+	defer closeRows(ctx, rows)
+
+	var songs []*models.Song
+	for rows.Next() {
+		var song models.Song
+		if err := scanSong(rows, &song); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		songs = append(songs, &song)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return songs, nil
+}
+
+// FillDetails sets only the fields that are still empty, so values entered
+// by hand are never overwritten.
+func (s *Storage) FillDetails(ctx context.Context, id int64, details models.SongDetails) error {
+	const op = "sqlite.song.FillDetails"
+
+	err := s.WithinTx(ctx, func(ctx context.Context) error {
+		q := s.conn(ctx)
+
+		before, err := getSong(ctx, q, id)
+		if err != nil {
+			return err
+		}
+
+		_, err = q.ExecContext(ctx, `UPDATE songs SET
+			release_date = CASE WHEN release_date = '' THEN ?2 ELSE release_date END,
+			link = CASE WHEN link = '' THEN ?3 ELSE link END,
+			lyrics_source = CASE WHEN lyrics = '' AND ?4 <> '' THEN ?5 ELSE lyrics_source END,
+			lyrics = CASE WHEN lyrics = '' THEN ?4 ELSE lyrics END
+			WHERE id = ?1`,
+			id, details.ReleaseDate, details.Link, details.Lyrics, details.LyricsSource)
+		if err != nil {
+			return err
+		}
+
+		if err := saveProviderLinks(ctx, q, id, details.Links); err != nil {
+			return err
+		}
+
+		after, err := getSong(ctx, q, id)
+		if err != nil {
+			return err
+		}
+
+		if after != before {
+			if err := addEvent(ctx, q, events.SongUpdated, after); err != nil {
+				return err
+			}
+		}
+		if after.Lyrics != before.Lyrics {
+			return addEvent(ctx, q, events.LyricsChanged, after)
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrSongNotFound) {
+			return storage.ErrSongNotFound
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SetLyrics replaces the lyrics of a song and records where they came from.
+func (s *Storage) SetLyrics(ctx context.Context, id int64, lyrics, source string) (models.Song, error) {
+	const op = "sqlite.song.SetLyrics"
+
+	var after models.Song
+	err := s.WithinTx(ctx, func(ctx context.Context) error {
+		q := s.conn(ctx)
+
+		before, err := getSong(ctx, q, id)
+		if err != nil {
+			return err
+		}
+
+		_, err = q.ExecContext(ctx, "UPDATE songs SET lyrics = ?2, lyrics_source = ?3 WHERE id = ?1", id, lyrics, source)
+		if err != nil {
+			return err
+		}
+
+		after, err = getSong(ctx, q, id)
+		if err != nil {
+			return err
+		}
+
+		if after.Lyrics != before.Lyrics {
+			if err := addRevision(ctx, q, before); err != nil {
+				return err
+			}
+			return addEvent(ctx, q, events.LyricsChanged, after)
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrSongNotFound) {
+			return models.Song{}, storage.ErrSongNotFound
+		}
+		return models.Song{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return after, nil
+}
+
+// CatalogStats counts songs, groups and songs that have no lyrics yet.
+func (s *Storage) CatalogStats(ctx context.Context) (models.CatalogStats, error) {
+	const op = "sqlite.song.CatalogStats"
+
+	var stats models.CatalogStats
+	err := s.conn(ctx).QueryRowContext(ctx,
+		`SELECT (SELECT COUNT(*) FROM songs), (SELECT COUNT(*) FROM groups),
+		(SELECT COUNT(*) FROM songs WHERE lyrics = '')`).
+		Scan(&stats.Songs, &stats.Groups, &stats.MissingLyrics)
+	if err != nil {
+		return models.CatalogStats{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,271 @@
+// Package sqlite stores the catalog in a single SQLite file for deployments
+// that cannot run postgres. It uses a pure-Go driver, ships its own
+// migrations and reproduces the postgres search semantics.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/2pizzzza/TestTask/db"
+	"github.com/2pizzzza/TestTask/internal/config"
+	"github.com/2pizzzza/TestTask/internal/lib/ilike"
+	"github.com/2pizzzza/TestTask/internal/lib/logger/sl"
+	"github.com/2pizzzza/TestTask/internal/storage/migration"
+	"github.com/XSAM/otelsql"
+	"github.com/golang-migrate/migrate/v4"
+	sqlitemigrate "github.com/golang-migrate/migrate/v4/database/sqlite"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"modernc.org/sqlite"
+)
+
+func init() {
+	// pg_ilike(value, pattern) behaves like value ILIKE pattern in postgres.
+	sqlite.MustRegisterDeterministicScalarFunction("pg_ilike", 2,
+		func(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+			value, _ := args[0].(string)
+			pattern, _ := args[1].(string)
+			return ilike.Match(value, pattern), nil
+		})
+}
+
+type Storage struct {
+	Db *sql.DB
+}
+
+// New opens the database file, creating it if needed. Migrations are not
+// applied here; see Migrator. SQLite allows one writer at a time, so the pool
+// holds a single connection and writes never wait on each other's locks.
+func New(ctx context.Context, cfg config.SQLiteConfig) (*Storage, error) {
+	const op = "sqlite.New"
+
+	conn, err := otelsql.Open("sqlite", dsn(cfg), otelsql.WithAttributes(semconv.DBSystemSqlite))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	conn.SetMaxOpenConns(1)
+	conn.SetConnMaxLifetime(0)
+	conn.SetConnMaxIdleTime(0)
+
+	if err := conn.PingContext(ctx); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	logger(ctx).Info("opened sqlite database", slog.String("path", cfg.Path))
+
+	return &Storage{Db: conn}, nil
+}
+
+func dsn(cfg config.SQLiteConfig) string {
+	query := url.Values{}
+	query.Add("_pragma", "foreign_keys(1)")
+	query.Add("_pragma", "busy_timeout("+strconv.FormatInt(cfg.BusyTimeout.Milliseconds(), 10)+")")
+	if cfg.Path != ":memory:" {
+		query.Add("_pragma", "journal_mode(WAL)")
+	}
+	query.Set("_time_format", "sqlite")
+	query.Set("_txlock", "immediate")
+
+	return "file:" + cfg.Path + "?" + query.Encode()
+}
+
+// NewMigrator opens its own connection, separate from any Storage pool, and
+// closes it with the migrator.
+func NewMigrator(ctx context.Context, cfg config.SQLiteConfig, log *slog.Logger) (*migration.Migrator, error) {
+	s, err := New(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	migrator, err := s.newMigrator(log, s.Close)
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	return migrator, nil
+}
+
+// Migrator runs the embedded migrations on the pool's own connection, so it
+// also works for in-memory databases. Closing the migrator leaves the pool
+// open.
+func (s *Storage) Migrator(log *slog.Logger) (*migration.Migrator, error) {
+	return s.newMigrator(log, nil)
+}
+
+// newMigrator builds a migrator on the pool; closeDb, if set, runs after the
+// migrator is closed.
+func (s *Storage) newMigrator(log *slog.Logger, closeDb func() error) (*migration.Migrator, error) {
+	const op = "sqlite.Migrator"
+
+	src, err := openMigrations()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	driver, err := sqlitemigrate.WithInstance(s.Db, &sqlitemigrate.Config{})
+	if err != nil {
+		src.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", src, "sqlite", driver)
+	if err != nil {
+		src.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Closing m would close the driver and with it the pool, so only the
+	// source is closed here.
+	return migration.New(m, openMigrations, log, func() error {
+		err := src.Close()
+		if closeDb != nil {
+			err = errors.Join(err, closeDb())
+		}
+		return err
+	}), nil
+}
+
+// MigrateUp applies every pending migration.
+func (s *Storage) MigrateUp(ctx context.Context, log *slog.Logger) error {
+	migrator, err := s.Migrator(log)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	return migrator.Up(ctx)
+}
+
+func openMigrations() (source.Driver, error) {
+	return iofs.New(db.SQLiteMigrations, "sqlite")
+}
+
+func (s *Storage) Close() error {
+	return s.Db.Close()
+}
+
+// Ping checks that the database accepts connections.
+func (s *Storage) Ping(ctx context.Context) error {
+	const op = "sqlite.Ping"
+
+	if err := s.Db.PingContext(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SchemaVersion returns the applied migration version and whether the last
+// migration failed half way; zero means the database was never migrated.
+func (s *Storage) SchemaVersion(ctx context.Context) (uint, bool, error) {
+	const op = "sqlite.SchemaVersion"
+
+	var migrated bool
+	err := s.Db.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations')").Scan(&migrated)
+	if err != nil {
+		return 0, false, fmt.Errorf("%s: %w", op, err)
+	}
+	if !migrated {
+		return 0, false, nil
+	}
+
+	var (
+		version int64
+		dirty   bool
+	)
+	err = s.Db.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, false, nil
+		}
+		return 0, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return uint(version), dirty, nil
+}
+
+// CheckSchema fails when a migration is dirty or the database is behind the
+// migrations shipped with the service.
+func (s *Storage) CheckSchema(ctx context.Context) error {
+	const op = "sqlite.CheckSchema"
+
+	latest, err := migration.Latest(openMigrations)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	version, dirty, err := s.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	if dirty {
+		return fmt.Errorf("%s: migration %d is dirty", op, version)
+	}
+	if version < latest {
+		return fmt.Errorf("%s: schema version is %d, expected %d", op, version, latest)
+	}
+
+	return nil
+}
+
+// logger returns the request-scoped logger carried by ctx, or the default one.
+func logger(ctx context.Context) *slog.Logger {
+	return sl.FromContext(ctx, slog.Default())
+}
+
+func closeRows(ctx context.Context, rows *sql.Rows) {
+	if err := rows.Close(); err != nil {
+		logger(ctx).Error("failed to close rows", sl.Err(err))
+	}
+}
+
+// ftsQuery turns the literal parts of a LIKE pattern into an FTS5 trigram
+// query on column, e.g. "%abc%def_%" becomes `song_title : ("abc" AND "def")`.
+// Parts shorter than three characters cannot use the trigram index and are
+// left to the exact pg_ilike check. ok is false when nothing is indexable.
+func ftsQuery(column, pattern string) (query string, ok bool) {
+	var (
+		parts   []string
+		current []rune
+	)
+	flush := func() {
+		if len(current) >= 3 {
+			parts = append(parts, `"`+strings.ReplaceAll(string(current), `"`, `""`)+`"`)
+		}
+		current = current[:0]
+	}
+
+	runes := []rune(pattern)
+	for i := 0; i < len(runes); i++ {
+		switch runes[i] {
+		case '%', '_':
+			flush()
+		case '\\':
+			if i+1 < len(runes) {
+				i++
+			}
+			current = append(current, runes[i])
+		default:
+			current = append(current, runes[i])
+		}
+	}
+	flush()
+
+	if len(parts) == 0 {
+		return "", false
+	}
+	return column + " : (" + strings.Join(parts, " AND ") + ")", true
+}
@@ -0,0 +1,93 @@
+package sqlite_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/2pizzzza/TestTask/internal/config"
+	"github.com/2pizzzza/TestTask/internal/service"
+	"github.com/2pizzzza/TestTask/internal/storage/sqlite"
+	"github.com/2pizzzza/TestTask/internal/storage/storagetest"
+)
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storagetest.Repository {
+		return open(t)
+	})
+}
+
+func TestAPIKeys(t *testing.T) {
+	storagetest.RunAPIKeys(t, func(t *testing.T) service.APIKeyRepository {
+		return open(t)
+	})
+}
+
+func TestUsers(t *testing.T) {
+	storagetest.RunUsers(t, func(t *testing.T) service.UserRepository {
+		return open(t)
+	})
+}
+
+func TestWebhookJobs(t *testing.T) {
+	storagetest.RunWebhookJobs(t, func(t *testing.T) storagetest.WebhookRepository {
+		return open(t)
+	})
+}
+
+func open(t *testing.T) *sqlite.Storage {
+	t.Helper()
+
+	db, err := sqlite.New(context.Background(), config.SQLiteConfig{
+		Path:        filepath.Join(t.TempDir(), "songlib.db"),
+		BusyTimeout: time.Second,
+	})
+	if err != nil {
+		t.Fatalf("sqlite.New: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.MigrateUp(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil))); err != nil {
+		t.Fatalf("MigrateUp: %v", err)
+	}
+	return db
+}
+
+func TestSchemaNeedsMigration(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sqlite.New(ctx, config.SQLiteConfig{Path: filepath.Join(t.TempDir(), "songlib.db"), BusyTimeout: time.Second})
+	if err != nil {
+		t.Fatalf("sqlite.New: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err := db.CheckSchema(ctx); err == nil {
+		t.Fatal("CheckSchema accepted a database that was never migrated")
+	}
+
+	migrator, err := db.Migrator(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("Migrator: %v", err)
+	}
+	if err := migrator.Up(ctx); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	status, err := migrator.Status()
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	if status.Version != status.Latest || len(status.Pending) != 0 {
+		t.Errorf("status = %+v, want every migration applied", status)
+	}
+	if err := migrator.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// The pool outlives the migrator.
+	if err := db.CheckSchema(ctx); err != nil {
+		t.Errorf("CheckSchema after Up: %v", err)
+	}
+}
@@ -0,0 +1,159 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/storage"
+)
+
+const userColumns = "id, username, role, created_at"
+
+func scanUser(row scanner, user *models.User) error {
+	return row.Scan(&user.Id, &user.Username, &user.Role, &user.CreatedAt)
+}
+
+// CreateUser stores a user with the hash of their password.
+func (s *Storage) CreateUser(ctx context.Context, user models.User, passwordHash string) (models.User, error) {
+	const op = "sqlite.user.CreateUser"
+
+	var created models.User
+	err := s.WithinTx(ctx, func(ctx context.Context) error {
+		q := s.conn(ctx)
+
+		var exists bool
+		err := q.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM users WHERE username = ?1)", user.Username).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return storage.ErrUserExists
+		}
+
+		return scanUser(q.QueryRowContext(ctx,
+			`INSERT INTO users (username, password_hash, role, created_at) VALUES (?1, ?2, ?3, ?4)
+			RETURNING `+userColumns,
+			user.Username, passwordHash, user.Role, time.Now().UTC()), &created)
+	})
+	if err != nil {
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return created, nil
+}
+
+// UserByName returns the user and their password hash.
+func (s *Storage) UserByName(ctx context.Context, username string) (models.User, string, error) {
+	const op = "sqlite.user.UserByName"
+
+	var (
+		user models.User
+		hash string
+	)
+	err := s.conn(ctx).QueryRowContext(ctx,
+		"SELECT "+userColumns+", password_hash FROM users WHERE username = ?1", username).
+		Scan(&user.Id, &user.Username, &user.Role, &user.CreatedAt, &hash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.User{}, "", storage.ErrUserNotFound
+		}
+		return models.User{}, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user, hash, nil
+}
+
+func (s *Storage) GetUser(ctx context.Context, id int64) (models.User, error) {
+	const op = "sqlite.user.GetUser"
+
+	var user models.User
+	err := scanUser(s.conn(ctx).QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE id = ?1", id), &user)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.User{}, storage.ErrUserNotFound
+		}
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user, nil
+}
+
+func (s *Storage) ListUsers(ctx context.Context) ([]models.User, error) {
+	const op = "sqlite.user.ListUsers"
+
+	rows, err := s.conn(ctx).QueryContext(ctx, "SELECT "+userColumns+" FROM users ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer closeRows(ctx, rows)
+
+	users := []models.User{}
+	for rows.Next() {
+		var user models.User
+		if err := scanUser(rows, &user); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return users, nil
+}
+
+func (s *Storage) SetUserRole(ctx context.Context, id int64, role string) (models.User, error) {
+	const op = "sqlite.user.SetUserRole"
+
+	var user models.User
+	err := scanUser(s.conn(ctx).QueryRowContext(ctx,
+		"UPDATE users SET role = ?2 WHERE id = ?1 RETURNING "+userColumns, id, role), &user)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.User{}, storage.ErrUserNotFound
+		}
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user, nil
+}
+
+// SaveRefreshToken stores the hash of a refresh token issued to the user.
+func (s *Storage) SaveRefreshToken(ctx context.Context, userId int64, hash string, expiresAt time.Time) error {
+	const op = "sqlite.user.SaveRefreshToken"
+
+	_, err := s.conn(ctx).ExecContext(ctx,
+		`INSERT INTO refresh_tokens (user_id, token_hash, expires_at, created_at) VALUES (?1, ?2, ?3, ?4)`,
+		userId, hash, expiresAt.UTC(), time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// UseRefreshToken revokes the refresh token with the given hash and returns
+// its user, so every token is accepted once. Unknown, expired and already
+// used tokens are reported as storage.ErrTokenNotFound.
+func (s *Storage) UseRefreshToken(ctx context.Context, hash string, at time.Time) (int64, error) {
+	const op = "sqlite.user.UseRefreshToken"
+
+	var userId int64
+	err := s.conn(ctx).QueryRowContext(ctx,
+		`UPDATE refresh_tokens SET revoked_at = ?2
+		WHERE token_hash = ?1 AND revoked_at IS NULL AND expires_at > ?2 RETURNING user_id`,
+		hash, at.UTC()).Scan(&userId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, storage.ErrTokenNotFound
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return userId, nil
+}
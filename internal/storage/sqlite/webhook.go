@@ -0,0 +1,317 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/storage"
+)
+
+const webhookColumns = "id, url, events, secret, active, failure_count, disabled_at, created_at"
+
+// The event filter is stored as a JSON array.
+func scanWebhook(row scanner, hook *models.Webhook) error {
+	var (
+		events     string
+		disabledAt sql.NullTime
+	)
+	err := row.Scan(&hook.Id, &hook.URL, &events, &hook.Secret, &hook.Active,
+		&hook.FailureCount, &disabledAt, &hook.CreatedAt)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(events), &hook.Events); err != nil {
+		return err
+	}
+	if disabledAt.Valid {
+		hook.DisabledAt = &disabledAt.Time
+	}
+	return nil
+}
+
+// jsonArray encodes a string list column; nil is stored as [].
+func jsonArray(values []string) (string, error) {
+	if values == nil {
+		values = []string{}
+	}
+	b, err := json.Marshal(values)
+	return string(b), err
+}
+
+func collectWebhooks(ctx context.Context, op string, rows *sql.Rows) ([]models.Webhook, error) {
+	defer closeRows(ctx, rows)
+
+	hooks := []models.Webhook{}
+	for rows.Next() {
+		var hook models.Webhook
+		if err := scanWebhook(rows, &hook); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		hooks = append(hooks, hook)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return hooks, nil
+}
+
+func (s *Storage) CreateWebhook(ctx context.Context, hook models.Webhook) (models.Webhook, error) {
+	const op = "sqlite.webhook.CreateWebhook"
+
+	events, err := jsonArray(hook.Events)
+	if err != nil {
+		return models.Webhook{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var created models.Webhook
+	err = scanWebhook(s.conn(ctx).QueryRowContext(ctx,
+		`INSERT INTO webhooks (url, events, secret, active, created_at) VALUES (?1, ?2, ?3, ?4, ?5) RETURNING `+webhookColumns,
+		hook.URL, events, hook.Secret, hook.Active, time.Now().UTC()), &created)
+	if err != nil {
+		return models.Webhook{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return created, nil
+}
+
+func (s *Storage) GetWebhook(ctx context.Context, id int64) (models.Webhook, error) {
+	const op = "sqlite.webhook.GetWebhook"
+
+	var hook models.Webhook
+	err := scanWebhook(s.conn(ctx).QueryRowContext(ctx, "SELECT "+webhookColumns+" FROM webhooks WHERE id = ?1", id), &hook)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Webhook{}, storage.ErrWebhookNotFound
+		}
+		return models.Webhook{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return hook, nil
+}
+
+func (s *Storage) ListWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	const op = "sqlite.webhook.ListWebhooks"
+
+	rows, err := s.conn(ctx).QueryContext(ctx, "SELECT "+webhookColumns+" FROM webhooks ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return collectWebhooks(ctx, op, rows)
+}
+
+// ActiveWebhooks returns enabled webhooks; filtering by event type is left to the caller.
+func (s *Storage) ActiveWebhooks(ctx context.Context) ([]models.Webhook, error) {
+	const op = "sqlite.webhook.ActiveWebhooks"
+
+	rows, err := s.conn(ctx).QueryContext(ctx, "SELECT "+webhookColumns+" FROM webhooks WHERE active ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return collectWebhooks(ctx, op, rows)
+}
+
+// UpdateWebhook replaces the url, filter, secret and active flag. Re-enabling
+// a webhook resets its failure counter.
+func (s *Storage) UpdateWebhook(ctx context.Context, hook models.Webhook) (models.Webhook, error) {
+	const op = "sqlite.webhook.UpdateWebhook"
+
+	events, err := jsonArray(hook.Events)
+	if err != nil {
+		return models.Webhook{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var updated models.Webhook
+	err = scanWebhook(s.conn(ctx).QueryRowContext(ctx,
+		`UPDATE webhooks SET url = ?2, events = ?3, secret = ?4, active = ?5,
+		failure_count = CASE WHEN ?5 AND NOT active THEN 0 ELSE failure_count END,
+		disabled_at = CASE WHEN ?5 THEN NULL ELSE disabled_at END
+		WHERE id = ?1 RETURNING `+webhookColumns,
+		hook.Id, hook.URL, events, hook.Secret, hook.Active), &updated)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Webhook{}, storage.ErrWebhookNotFound
+		}
+		return models.Webhook{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return updated, nil
+}
+
+func (s *Storage) DeleteWebhook(ctx context.Context, id int64) error {
+	const op = "sqlite.webhook.DeleteWebhook"
+
+	res, err := s.conn(ctx).ExecContext(ctx, "DELETE FROM webhooks WHERE id = ?1", id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if rowsAffected == 0 {
+		return storage.ErrWebhookNotFound
+	}
+
+	return nil
+}
+
+func (s *Storage) SaveDelivery(ctx context.Context, d models.WebhookDelivery) error {
+	const op = "sqlite.webhook.SaveDelivery"
+
+	_, err := s.conn(ctx).ExecContext(ctx,
+		`INSERT INTO webhook_deliveries
+		(webhook_id, event_id, event_type, attempt, status_code, response_body, error, duration_ms, success, created_at)
+		VALUES (?1, ?2, ?3, ?4, NULLIF(?5, 0), ?6, ?7, ?8, ?9, ?10)`,
+		d.WebhookId, d.EventId, d.EventType, d.Attempt, d.StatusCode, d.ResponseBody, d.Error, d.DurationMs, d.Success,
+		time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) ListDeliveries(ctx context.Context, webhookId int64, limit int) ([]models.WebhookDelivery, error) {
+	const op = "sqlite.webhook.ListDeliveries"
+
+	if _, err := s.GetWebhook(ctx, webhookId); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.conn(ctx).QueryContext(ctx,
+		`SELECT id, webhook_id, event_id, event_type, attempt, COALESCE(status_code, 0), response_body, error,
+		duration_ms, success, created_at FROM webhook_deliveries WHERE webhook_id = ?1 ORDER BY id DESC LIMIT ?2`,
+		webhookId, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer closeRows(ctx, rows)
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		var d models.WebhookDelivery
+		if err := rows.Scan(&d.Id, &d.WebhookId, &d.EventId, &d.EventType, &d.Attempt, &d.StatusCode,
+			&d.ResponseBody, &d.Error, &d.DurationMs, &d.Success, &d.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return deliveries, nil
+}
+
+// RecordWebhookResult resets the failure counter on success; on failure it
+// increments it and disables the webhook once maxFailures is reached.
+func (s *Storage) RecordWebhookResult(ctx context.Context, id int64, success bool, maxFailures int) (bool, error) {
+	const op = "sqlite.webhook.RecordWebhookResult"
+
+	if success {
+		if _, err := s.conn(ctx).ExecContext(ctx, "UPDATE webhooks SET failure_count = 0 WHERE id = ?1", id); err != nil {
+			return false, fmt.Errorf("%s: %w", op, err)
+		}
+		return false, nil
+	}
+
+	var active bool
+	err := s.conn(ctx).QueryRowContext(ctx,
+		`UPDATE webhooks SET failure_count = failure_count + 1,
+		active = active AND failure_count + 1 < ?2,
+		disabled_at = CASE WHEN active AND failure_count + 1 >= ?2 THEN ?3 ELSE disabled_at END
+		WHERE id = ?1 RETURNING active`, id, maxFailures, time.Now().UTC()).Scan(&active)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, storage.ErrWebhookNotFound
+		}
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return !active, nil
+}
+
+// EnqueueWebhookJobs stores pending deliveries. Called inside the relay
+// transaction, the jobs become visible together with the published event.
+func (s *Storage) EnqueueWebhookJobs(ctx context.Context, jobs []models.WebhookJob) error {
+	const op = "sqlite.webhook.EnqueueWebhookJobs"
+
+	now := time.Now().UTC()
+	for _, job := range jobs {
+		_, err := s.conn(ctx).ExecContext(ctx,
+			`INSERT INTO webhook_jobs (webhook_id, event_id, event_type, body, next_attempt_at, created_at)
+			VALUES (?1, ?2, ?3, ?4, ?5, ?6)`,
+			job.WebhookId, job.EventId, job.EventType, job.Body, job.NextAttemptAt.UTC(), now)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
+
+// ClaimWebhookJobs takes up to limit jobs that are due at now, counts the
+// attempt and hides them from other workers until leaseUntil. A job whose
+// worker dies becomes due again once the lease runs out. The single UPDATE
+// needs no row locks: SQLite runs one writer at a time.
+func (s *Storage) ClaimWebhookJobs(ctx context.Context, now, leaseUntil time.Time, limit int) ([]models.WebhookJob, error) {
+	const op = "sqlite.webhook.ClaimWebhookJobs"
+
+	rows, err := s.conn(ctx).QueryContext(ctx,
+		`UPDATE webhook_jobs SET attempts = attempts + 1, next_attempt_at = ?2
+		WHERE id IN (SELECT id FROM webhook_jobs WHERE next_attempt_at <= ?1 ORDER BY next_attempt_at, id LIMIT ?3)
+		RETURNING id, webhook_id, event_id, event_type, body, attempts, next_attempt_at, created_at`,
+		now.UTC(), leaseUntil.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer closeRows(ctx, rows)
+
+	jobs := []models.WebhookJob{}
+	for rows.Next() {
+		var job models.WebhookJob
+		if err := rows.Scan(&job.Id, &job.WebhookId, &job.EventId, &job.EventType, &job.Body,
+			&job.Attempts, &job.NextAttemptAt, &job.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		jobs = append(jobs, job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return jobs, nil
+}
+
+func (s *Storage) RescheduleWebhookJob(ctx context.Context, id int64, at time.Time) error {
+	const op = "sqlite.webhook.RescheduleWebhookJob"
+
+	if _, err := s.conn(ctx).ExecContext(ctx, "UPDATE webhook_jobs SET next_attempt_at = ?2 WHERE id = ?1", id, at.UTC()); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) DeleteWebhookJob(ctx context.Context, id int64) error {
+	const op = "sqlite.webhook.DeleteWebhookJob"
+
+	if _, err := s.conn(ctx).ExecContext(ctx, "DELETE FROM webhook_jobs WHERE id = ?1", id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -3,6 +3,16 @@ package storage
 import "errors"
 
 var (
-	ErrSongExists   = errors.New("song already exists")
-	ErrSongNotFound = errors.New("song not found")
+	ErrSongExists         = errors.New("song already exists")
+	ErrSongNotFound       = errors.New("song not found")
+	ErrRevisionNotFound   = errors.New("lyrics revision not found")
+	ErrGroupNotFound      = errors.New("group not found")
+	ErrCacheEntryNotFound = errors.New("cache entry not found")
+	ErrLinkExists         = errors.New("link already exists")
+	ErrLinkNotFound       = errors.New("link not found")
+	ErrWebhookNotFound    = errors.New("webhook not found")
+	ErrAPIKeyNotFound     = errors.New("api key not found")
+	ErrUserExists         = errors.New("user already exists")
+	ErrUserNotFound       = errors.New("user not found")
+	ErrTokenNotFound      = errors.New("refresh token not found")
 )
@@ -0,0 +1,128 @@
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/service"
+	"github.com/2pizzzza/TestTask/internal/storage"
+)
+
+// RunAPIKeys runs the API key part of the suite for backends that keep keys.
+func RunAPIKeys(t *testing.T, open func(t *testing.T) service.APIKeyRepository) {
+	runSuite(t, open, []test[service.APIKeyRepository]{
+		{"CreateAndLookup", testAPIKeyCreateAndLookup},
+		{"Revoke", testAPIKeyRevoke},
+		{"Touch", testAPIKeyTouch},
+	})
+}
+
+func testAPIKeyCreateAndLookup(t *testing.T, repo service.APIKeyRepository) {
+	ctx := context.Background()
+
+	created, err := repo.CreateAPIKey(ctx, models.APIKey{
+		Name:   "importer",
+		Prefix: "slk_abcdefgh",
+		Scopes: []string{models.ScopeSongsRead, models.ScopeSongsWrite},
+	}, "hash-1")
+	if err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+	if created.Id == 0 || created.CreatedAt.IsZero() || created.LastUsedAt != nil || created.RevokedAt != nil {
+		t.Errorf("CreateAPIKey = %+v, want an id, a creation time and no use or revocation", created)
+	}
+
+	got, err := repo.APIKeyByHash(ctx, "hash-1")
+	if err != nil {
+		t.Fatalf("APIKeyByHash: %v", err)
+	}
+	if got.Id != created.Id || got.Name != "importer" || got.Prefix != "slk_abcdefgh" ||
+		!slices.Equal(got.Scopes, []string{models.ScopeSongsRead, models.ScopeSongsWrite}) {
+		t.Errorf("APIKeyByHash = %+v, want %+v", got, created)
+	}
+
+	if _, err := repo.APIKeyByHash(ctx, "hash-2"); !errors.Is(err, storage.ErrAPIKeyNotFound) {
+		t.Errorf("APIKeyByHash(unknown): err = %v, want ErrAPIKeyNotFound", err)
+	}
+
+	if _, err := repo.CreateAPIKey(ctx, models.APIKey{Name: "reader", Prefix: "slk_ijklmnop",
+		Scopes: []string{models.ScopeSongsRead}}, "hash-2"); err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+	keys, err := repo.ListAPIKeys(ctx)
+	if err != nil {
+		t.Fatalf("ListAPIKeys: %v", err)
+	}
+	if len(keys) != 2 || keys[0].Name != "importer" || keys[1].Name != "reader" {
+		t.Errorf("ListAPIKeys = %+v, want importer and reader in creation order", keys)
+	}
+}
+
+func testAPIKeyRevoke(t *testing.T, repo service.APIKeyRepository) {
+	ctx := context.Background()
+
+	created, err := repo.CreateAPIKey(ctx, models.APIKey{Name: "old", Prefix: "slk_abcdefgh",
+		Scopes: []string{models.ScopeAdmin}}, "hash-1")
+	if err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+
+	revoked, err := repo.RevokeAPIKey(ctx, created.Id)
+	if err != nil {
+		t.Fatalf("RevokeAPIKey: %v", err)
+	}
+	if revoked.RevokedAt == nil {
+		t.Fatalf("RevokeAPIKey = %+v, want a revocation time", revoked)
+	}
+
+	again, err := repo.RevokeAPIKey(ctx, created.Id)
+	if err != nil {
+		t.Fatalf("RevokeAPIKey again: %v", err)
+	}
+	if again.RevokedAt == nil || !again.RevokedAt.Equal(*revoked.RevokedAt) {
+		t.Errorf("revoking twice moved the revocation time from %v to %v", revoked.RevokedAt, again.RevokedAt)
+	}
+
+	got, err := repo.APIKeyByHash(ctx, "hash-1")
+	if err != nil {
+		t.Fatalf("APIKeyByHash: %v", err)
+	}
+	if got.RevokedAt == nil {
+		t.Errorf("APIKeyByHash returned the revoked key without its revocation time")
+	}
+
+	if _, err := repo.RevokeAPIKey(ctx, created.Id+1000); !errors.Is(err, storage.ErrAPIKeyNotFound) {
+		t.Errorf("RevokeAPIKey(unknown): err = %v, want ErrAPIKeyNotFound", err)
+	}
+}
+
+func testAPIKeyTouch(t *testing.T, repo service.APIKeyRepository) {
+	ctx := context.Background()
+
+	created, err := repo.CreateAPIKey(ctx, models.APIKey{Name: "busy", Prefix: "slk_abcdefgh",
+		Scopes: []string{models.ScopeSongsRead}}, "hash-1")
+	if err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+
+	used := time.Now().Truncate(time.Second)
+	if err := repo.TouchAPIKey(ctx, created.Id, used); err != nil {
+		t.Fatalf("TouchAPIKey: %v", err)
+	}
+	// An older time, e.g. from a slower concurrent request, is ignored.
+	if err := repo.TouchAPIKey(ctx, created.Id, used.Add(-time.Hour)); err != nil {
+		t.Fatalf("TouchAPIKey: %v", err)
+	}
+
+	got, err := repo.APIKeyByHash(ctx, "hash-1")
+	if err != nil {
+		t.Fatalf("APIKeyByHash: %v", err)
+	}
+	if got.LastUsedAt == nil || !got.LastUsedAt.Equal(used) {
+		t.Errorf("LastUsedAt = %v, want %v", got.LastUsedAt, used)
+	}
+}
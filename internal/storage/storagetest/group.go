@@ -0,0 +1,93 @@
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/storage"
+)
+
+func testMergeGroups(t *testing.T, repo Repository) {
+	ctx := context.Background()
+
+	songs := save(t, repo,
+		seed{"Queen", "Bohemian Rhapsody", models.SongDetails{}},
+		seed{"queen", "Bohemian Rhapsody", models.SongDetails{}},
+		seed{"queen", "Killer Queen", models.SongDetails{}},
+		seed{"QUEEN ", "Radio Ga Ga", models.SongDetails{}},
+	)
+
+	dry, err := repo.MergeGroups(ctx, []string{"queen", "QUEEN "}, "Queen", true)
+	if err != nil {
+		t.Fatalf("MergeGroups dry run: %v", err)
+	}
+	if got := find(t, repo, "queen", "Killer Queen"); got.Id != songs[2].Id {
+		t.Errorf("dry run moved a song")
+	}
+
+	result, err := repo.MergeGroups(ctx, []string{"queen", "QUEEN "}, "Queen", false)
+	if err != nil {
+		t.Fatalf("MergeGroups: %v", err)
+	}
+	want := models.GroupMergeResult{
+		Into:          "Queen",
+		Moved:         []int64{songs[2].Id, songs[3].Id},
+		Conflicts:     []int64{songs[1].Id},
+		RemovedGroups: []string{"QUEEN "},
+	}
+	if !slices.Equal(result.Moved, want.Moved) || !slices.Equal(result.Conflicts, want.Conflicts) ||
+		!slices.Equal(result.RemovedGroups, want.RemovedGroups) || result.Into != want.Into || result.DryRun {
+		t.Errorf("MergeGroups = %+v, want %+v", result, want)
+	}
+	if !dry.DryRun || !slices.Equal(dry.Moved, result.Moved) || !slices.Equal(dry.Conflicts, result.Conflicts) {
+		t.Errorf("dry run = %+v, want the same outcome as the merge %+v", dry, result)
+	}
+
+	find(t, repo, "Queen", "Killer Queen")
+	find(t, repo, "Queen", "Radio Ga Ga")
+	find(t, repo, "queen", "Bohemian Rhapsody")
+
+	_, err = repo.MergeGroups(ctx, []string{"QUEEN "}, "Queen", false)
+	if !errors.Is(err, storage.ErrGroupNotFound) {
+		t.Errorf("merging a removed group: err = %v, want ErrGroupNotFound", err)
+	}
+}
+
+func testMergeIntoNewGroup(t *testing.T, repo Repository) {
+	ctx := context.Background()
+
+	songs := save(t, repo,
+		seed{"The Beatles", "Help!", models.SongDetails{}},
+		seed{"The Beatles", "Yesterday", models.SongDetails{}},
+		seed{"Beatles", "Yesterday", models.SongDetails{}},
+	)
+
+	_, err := repo.MergeGroups(ctx, []string{"The Beatles", "Nobody"}, "Beatles, The", false)
+	if !errors.Is(err, storage.ErrGroupNotFound) {
+		t.Errorf("merging an unknown group: err = %v, want ErrGroupNotFound", err)
+	}
+	if got := find(t, repo, "The Beatles", "Help!"); got.Id != songs[0].Id {
+		t.Errorf("a failed merge moved a song")
+	}
+
+	result, err := repo.MergeGroups(ctx, []string{"The Beatles", "Beatles"}, "Beatles, The", false)
+	if err != nil {
+		t.Fatalf("MergeGroups: %v", err)
+	}
+	// Titles are checked against the target as it is when each group moves,
+	// so the second Yesterday conflicts with the first.
+	if !slices.Equal(result.Moved, []int64{songs[0].Id, songs[1].Id}) ||
+		!slices.Equal(result.Conflicts, []int64{songs[2].Id}) ||
+		!slices.Equal(result.RemovedGroups, []string{"The Beatles"}) {
+		t.Errorf("MergeGroups = %+v", result)
+	}
+
+	moved := find(t, repo, "Beatles, The", "Help!")
+	if moved.Id != songs[0].Id {
+		t.Errorf("Help! has id %d after the merge, want %d", moved.Id, songs[0].Id)
+	}
+	find(t, repo, "Beatles", "Yesterday")
+}
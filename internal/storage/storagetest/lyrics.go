@@ -0,0 +1,79 @@
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/storage"
+)
+
+func testLyricsRevisions(t *testing.T, repo Repository) {
+	ctx := context.Background()
+
+	songs := save(t, repo,
+		seed{"Muse", "Uprising", models.SongDetails{Lyrics: "found", LyricsSource: "provider"}},
+		seed{"Muse", "Resistance", models.SongDetails{}},
+	)
+	id := songs[0].Id
+
+	for _, lyrics := range []string{"first edit", "second edit", "second edit"} {
+		if _, err := repo.SetLyrics(ctx, id, lyrics, models.LyricsSourceManual); err != nil {
+			t.Fatalf("SetLyrics: %v", err)
+		}
+	}
+
+	// Setting the same lyrics again is no change and keeps no revision.
+	revisions, err := repo.LyricsRevisions(ctx, id)
+	if err != nil {
+		t.Fatalf("LyricsRevisions: %v", err)
+	}
+	if len(revisions) != 2 {
+		t.Fatalf("LyricsRevisions = %+v, want 2 revisions", revisions)
+	}
+	newest, oldest := revisions[0], revisions[1]
+	if newest.Lyrics != "first edit" || newest.LyricsSource != models.LyricsSourceManual || newest.SongId != id {
+		t.Errorf("newest revision = %+v, want the first edit", newest)
+	}
+	if oldest.Lyrics != "found" || oldest.LyricsSource != "provider" || oldest.CreatedAt.IsZero() {
+		t.Errorf("oldest revision = %+v, want the enriched lyrics", oldest)
+	}
+
+	got, err := repo.LyricsRevision(ctx, id, oldest.Id)
+	if err != nil {
+		t.Fatalf("LyricsRevision: %v", err)
+	}
+	if got.Id != oldest.Id || got.Lyrics != oldest.Lyrics {
+		t.Errorf("LyricsRevision = %+v, want %+v", got, oldest)
+	}
+
+	// Revisions belong to their song.
+	if _, err := repo.LyricsRevision(ctx, songs[1].Id, oldest.Id); !errors.Is(err, storage.ErrRevisionNotFound) {
+		t.Errorf("LyricsRevision of another song: err = %v, want ErrRevisionNotFound", err)
+	}
+	if other, err := repo.LyricsRevisions(ctx, songs[1].Id); err != nil || len(other) != 0 {
+		t.Errorf("LyricsRevisions of an unchanged song = %+v, %v, want none", other, err)
+	}
+
+	if _, err := repo.Remove(ctx, id); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if _, err := repo.LyricsRevision(ctx, id, oldest.Id); !errors.Is(err, storage.ErrRevisionNotFound) {
+		t.Errorf("LyricsRevision of a removed song: err = %v, want ErrRevisionNotFound", err)
+	}
+}
+
+func testLyricsRevisionsNotFound(t *testing.T, repo Repository) {
+	ctx := context.Background()
+
+	songs := save(t, repo, seed{"Muse", "Uprising", models.SongDetails{}})
+	id := missingID(t, repo)
+
+	if _, err := repo.LyricsRevisions(ctx, id); !errors.Is(err, storage.ErrSongNotFound) {
+		t.Errorf("LyricsRevisions of a missing id: err = %v, want ErrSongNotFound", err)
+	}
+	if _, err := repo.LyricsRevision(ctx, songs[0].Id, 1); !errors.Is(err, storage.ErrRevisionNotFound) {
+		t.Errorf("LyricsRevision of a missing revision: err = %v, want ErrRevisionNotFound", err)
+	}
+}
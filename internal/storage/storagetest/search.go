@@ -0,0 +1,198 @@
+package storagetest
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+)
+
+func testSearch(t *testing.T, repo Repository) {
+	ctx := context.Background()
+
+	save(t, repo,
+		seed{"Queen", "Bohemian Rhapsody", models.SongDetails{ReleaseDate: "1975-10-31"}},
+		seed{"Queen", "Don't Stop Me Now", models.SongDetails{ReleaseDate: "1979-01-26"}},
+		seed{"Queens of the Stone Age", "No One Knows", models.SongDetails{}},
+		seed{"Muse", "Uprising", models.SongDetails{ReleaseDate: "2009-09-07"}},
+		seed{"Кино", "Группа крови", models.SongDetails{ReleaseDate: "1988-01-05"}},
+		seed{"100% Pure", "snake_case", models.SongDetails{}},
+		seed{"100% Pure", "snakeXcase", models.SongDetails{}},
+	)
+
+	tests := []struct {
+		name   string
+		filter models.SongFilter
+		want   []string
+	}{
+		{"no filter", models.SongFilter{},
+			[]string{"snakeXcase", "snake_case", "Группа крови", "Uprising", "No One Knows", "Don't Stop Me Now", "Bohemian Rhapsody"}},
+		{"group substring ignores case", models.SongFilter{GroupName: "queen"},
+			[]string{"No One Knows", "Don't Stop Me Now", "Bohemian Rhapsody"}},
+		{"group and song", models.SongFilter{GroupName: "QUEEN", SongName: "stop"},
+			[]string{"Don't Stop Me Now"}},
+		{"short song filter", models.SongFilter{SongName: "up"},
+			[]string{"Uprising"}},
+		{"cyrillic group", models.SongFilter{GroupName: "кИНо"},
+			[]string{"Группа крови"}},
+		{"cyrillic song", models.SongFilter{SongName: "ГРУППА"},
+			[]string{"Группа крови"}},
+		{"underscore wildcard", models.SongFilter{SongName: "rh_psody"},
+			[]string{"Bohemian Rhapsody"}},
+		{"percent wildcard", models.SongFilter{SongName: "bohemian%ody"},
+			[]string{"Bohemian Rhapsody"}},
+		{"unescaped underscore", models.SongFilter{SongName: "snake_case"},
+			[]string{"snakeXcase", "snake_case"}},
+		{"escaped underscore", models.SongFilter{SongName: `snake\_case`},
+			[]string{"snake_case"}},
+		{"percent in group", models.SongFilter{GroupName: "100%"},
+			[]string{"snakeXcase", "snake_case"}},
+		{"release date", models.SongFilter{ReleaseDate: "2009-09-07"},
+			[]string{"Uprising"}},
+		{"release date is exact", models.SongFilter{ReleaseDate: "2009"},
+			[]string{}},
+		{"all filters", models.SongFilter{GroupName: "que", SongName: "rhap", ReleaseDate: "1975-10-31"},
+			[]string{"Bohemian Rhapsody"}},
+		{"no match", models.SongFilter{SongName: "nothing like this"},
+			[]string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			songs, err := repo.GetAll(ctx, tt.filter, 100, 0)
+			if err != nil {
+				t.Fatalf("GetAll: %v", err)
+			}
+			if got := titles(songs); !slices.Equal(got, tt.want) {
+				t.Errorf("GetAll(%+v) = %q, want %q", tt.filter, got, tt.want)
+			}
+		})
+	}
+}
+
+// testFilterCombinations checks every combination of the three filters
+// against a plain reference implementation.
+func testFilterCombinations(t *testing.T, repo Repository) {
+	ctx := context.Background()
+
+	songs := save(t, repo,
+		seed{"Queen", "Bohemian Rhapsody", models.SongDetails{ReleaseDate: "1975-10-31"}},
+		seed{"Queen", "Love of My Life", models.SongDetails{ReleaseDate: "1975-10-31"}},
+		seed{"Queen", "Radio Ga Ga", models.SongDetails{ReleaseDate: "1984-01-23"}},
+		seed{"Queens of the Stone Age", "Go with the Flow", models.SongDetails{ReleaseDate: "1975-10-31"}},
+		seed{"Muse", "Bohemian Rhapsody", models.SongDetails{}},
+		seed{"Muse", "Uprising", models.SongDetails{ReleaseDate: "2009-09-07"}},
+	)
+
+	values := []models.SongFilter{
+		{GroupName: "QUEEN", SongName: "o", ReleaseDate: "1975-10-31"},
+		{GroupName: "use", SongName: "rhapsody", ReleaseDate: "2009-09-07"},
+		{GroupName: "nobody", SongName: "nothing", ReleaseDate: "1900-01-01"},
+	}
+
+	for _, value := range values {
+		for mask := 0; mask < 8; mask++ {
+			var filter models.SongFilter
+			if mask&1 != 0 {
+				filter.GroupName = value.GroupName
+			}
+			if mask&2 != 0 {
+				filter.SongName = value.SongName
+			}
+			if mask&4 != 0 {
+				filter.ReleaseDate = value.ReleaseDate
+			}
+
+			t.Run(fmt.Sprintf("%q/%q/%q", filter.GroupName, filter.SongName, filter.ReleaseDate), func(t *testing.T) {
+				want := []string{}
+				for i := len(songs) - 1; i >= 0; i-- {
+					if matches(songs[i], filter) {
+						want = append(want, songs[i].GroupName.GroupName+" - "+songs[i].SongName)
+					}
+				}
+
+				got, err := repo.GetAll(ctx, filter, 100, 0)
+				if err != nil {
+					t.Fatalf("GetAll: %v", err)
+				}
+				gotNames := []string{}
+				for _, s := range got {
+					gotNames = append(gotNames, s.GroupName.GroupName+" - "+s.SongName)
+				}
+				if !slices.Equal(gotNames, want) {
+					t.Errorf("GetAll(%+v) = %q, want %q", filter, gotNames, want)
+				}
+			})
+		}
+	}
+}
+
+// matches is the reference filter for values without wildcards.
+func matches(song models.Song, filter models.SongFilter) bool {
+	contains := func(s, substr string) bool {
+		return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+	}
+	return contains(song.GroupName.GroupName, filter.GroupName) &&
+		contains(song.SongName, filter.SongName) &&
+		(filter.ReleaseDate == "" || song.ReleaseDate == filter.ReleaseDate)
+}
+
+func testPagination(t *testing.T, repo Repository) {
+	ctx := context.Background()
+
+	var seeds []seed
+	for i := 1; i <= 5; i++ {
+		seeds = append(seeds, seed{"Muse", fmt.Sprintf("Song %d", i), models.SongDetails{}})
+	}
+	save(t, repo, seeds...)
+
+	tests := []struct {
+		name          string
+		limit, offset int
+		want          []string
+	}{
+		{"exact size", 5, 0, []string{"Song 5", "Song 4", "Song 3", "Song 2", "Song 1"}},
+		{"larger limit", 100, 0, []string{"Song 5", "Song 4", "Song 3", "Song 2", "Song 1"}},
+		{"first page", 2, 0, []string{"Song 5", "Song 4"}},
+		{"middle page", 2, 2, []string{"Song 3", "Song 2"}},
+		{"partial last page", 2, 4, []string{"Song 1"}},
+		{"offset at the end", 2, 5, []string{}},
+		{"offset past the end", 2, 100, []string{}},
+		{"zero limit", 0, 0, []string{}},
+		{"single oldest", 1, 4, []string{"Song 1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.GetAll(ctx, models.SongFilter{}, tt.limit, tt.offset)
+			if err != nil {
+				t.Fatalf("GetAll: %v", err)
+			}
+			if !slices.Equal(titles(got), tt.want) {
+				t.Errorf("GetAll(limit %d, offset %d) = %q, want %q", tt.limit, tt.offset, titles(got), tt.want)
+			}
+		})
+	}
+
+	t.Run("filtered page", func(t *testing.T) {
+		got, err := repo.GetAll(ctx, models.SongFilter{SongName: "song"}, 2, 3)
+		if err != nil {
+			t.Fatalf("GetAll: %v", err)
+		}
+		if want := []string{"Song 2", "Song 1"}; !slices.Equal(titles(got), want) {
+			t.Errorf("GetAll = %q, want %q", titles(got), want)
+		}
+	})
+
+	t.Run("negative", func(t *testing.T) {
+		if _, err := repo.GetAll(ctx, models.SongFilter{}, -1, 0); err == nil {
+			t.Errorf("GetAll with a negative limit succeeded")
+		}
+		if _, err := repo.GetAll(ctx, models.SongFilter{}, 1, -1); err == nil {
+			t.Errorf("GetAll with a negative offset succeeded")
+		}
+	})
+}
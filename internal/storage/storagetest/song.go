@@ -0,0 +1,293 @@
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/storage"
+)
+
+func testSaveAndGet(t *testing.T, repo Repository) {
+	ctx := context.Background()
+
+	song := save(t, repo, seed{"Muse", "Uprising", models.SongDetails{
+		ReleaseDate: "2009-09-07", Link: "https://example.com/uprising", Lyrics: "They will not force us",
+		LyricsSource: "provider",
+	}})[0]
+
+	got, err := repo.GetById(ctx, song.Id)
+	if err != nil {
+		t.Fatalf("GetById: %v", err)
+	}
+	want := models.Song{
+		Id: song.Id, GroupName: got.GroupName, SongName: "Uprising", ReleaseDate: "2009-09-07",
+		Lyrics: "They will not force us", LyricsSource: "provider", Link: "https://example.com/uprising",
+	}
+	if got != want {
+		t.Errorf("GetById = %+v, want %+v", got, want)
+	}
+	if got.GroupName.GroupName != "Muse" || got.GroupName.Id == 0 {
+		t.Errorf("group = %+v, want Muse with an id", got.GroupName)
+	}
+
+	other := save(t, repo, seed{"Muse", "Resistance", models.SongDetails{}})[0]
+	if other.Id <= song.Id {
+		t.Errorf("second song got id %d, want more than %d", other.Id, song.Id)
+	}
+	if other.GroupName != got.GroupName {
+		t.Errorf("second song group = %+v, want the existing %+v", other.GroupName, got.GroupName)
+	}
+}
+
+func testSaveConflicts(t *testing.T, repo Repository) {
+	ctx := context.Background()
+
+	save(t, repo, seed{"Muse", "Uprising", models.SongDetails{}})
+
+	_, err := repo.Save(ctx, "Muse", "Uprising", models.SongDetails{Lyrics: "other"})
+	if !errors.Is(err, storage.ErrSongExists) {
+		t.Fatalf("saving a duplicate: err = %v, want ErrSongExists", err)
+	}
+	if got := all(t, repo); len(got) != 1 || got[0].Lyrics != "" {
+		t.Errorf("after a rejected duplicate the catalog is %+v, want the original song only", got)
+	}
+
+	// Group and title are compared exactly, so these are all new songs.
+	saved := save(t, repo,
+		seed{"Muse", "Resistance", models.SongDetails{}},
+		seed{"Placebo", "Uprising", models.SongDetails{}},
+		seed{"Muse", "uprising", models.SongDetails{}},
+		seed{"muse", "Uprising", models.SongDetails{}},
+	)
+	if saved[0].GroupName != saved[2].GroupName {
+		t.Errorf("songs of Muse are in groups %+v and %+v, want one group", saved[0].GroupName, saved[2].GroupName)
+	}
+	if saved[3].GroupName.Id == saved[0].GroupName.Id {
+		t.Errorf("muse and Muse share group id %d, want separate groups", saved[0].GroupName.Id)
+	}
+	if got := all(t, repo); len(got) != 5 {
+		t.Errorf("catalog has %d songs, want 5", len(got))
+	}
+}
+
+func testGetNotFound(t *testing.T, repo Repository) {
+	ctx := context.Background()
+
+	if _, err := repo.GetById(ctx, 1); !errors.Is(err, storage.ErrSongNotFound) {
+		t.Errorf("GetById in an empty catalog: err = %v, want ErrSongNotFound", err)
+	}
+
+	save(t, repo, seed{"Muse", "Uprising", models.SongDetails{}})
+	if _, err := repo.GetById(ctx, missingID(t, repo)); !errors.Is(err, storage.ErrSongNotFound) {
+		t.Errorf("GetById of a missing id: err = %v, want ErrSongNotFound", err)
+	}
+	if _, err := repo.GetById(ctx, 0); !errors.Is(err, storage.ErrSongNotFound) {
+		t.Errorf("GetById(0): err = %v, want ErrSongNotFound", err)
+	}
+}
+
+func testUpdate(t *testing.T, repo Repository) {
+	ctx := context.Background()
+
+	songs := save(t, repo,
+		seed{"Muse", "Uprisin", models.SongDetails{ReleaseDate: "2009-09-07", Lyrics: "lyrics"}},
+		seed{"Muse", "Resistance", models.SongDetails{}},
+	)
+
+	updated, err := repo.Update(ctx, songs[0].Id, "MUSE", "Uprising")
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	want := songs[0]
+	want.GroupName = updated.GroupName
+	want.SongName = "Uprising"
+	if updated != want || updated.GroupName.GroupName != "MUSE" {
+		t.Errorf("Update = %+v, want MUSE - Uprising with the other fields kept", updated)
+	}
+
+	got, err := repo.GetById(ctx, songs[0].Id)
+	if err != nil {
+		t.Fatalf("GetById: %v", err)
+	}
+	if got != updated {
+		t.Errorf("GetById after Update = %+v, want %+v", got, updated)
+	}
+
+	found, err := repo.GetAll(ctx, models.SongFilter{SongName: "uprising"}, 10, 0)
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	if !slices.Equal(ids(found), []int64{songs[0].Id}) {
+		t.Errorf("searching the new title = %q, want the updated song", titles(found))
+	}
+
+	if other := find(t, repo, "Muse", "Resistance"); other != songs[1] {
+		t.Errorf("other song changed to %+v", other)
+	}
+
+	moved, err := repo.Update(ctx, songs[1].Id, "MUSE", "Resistance")
+	if err != nil {
+		t.Fatalf("Update into an existing group: %v", err)
+	}
+	if moved.GroupName != updated.GroupName {
+		t.Errorf("group = %+v, want the existing %+v", moved.GroupName, updated.GroupName)
+	}
+}
+
+func testUpdateNotFound(t *testing.T, repo Repository) {
+	ctx := context.Background()
+
+	save(t, repo, seed{"Muse", "Uprising", models.SongDetails{}})
+	before := all(t, repo)
+
+	_, err := repo.Update(ctx, missingID(t, repo), "Muse", "Resistance")
+	if !errors.Is(err, storage.ErrSongNotFound) {
+		t.Errorf("Update of a missing id: err = %v, want ErrSongNotFound", err)
+	}
+
+	after := all(t, repo)
+	if len(after) != len(before) || *after[0] != *before[0] {
+		t.Errorf("catalog changed to %+v", after)
+	}
+}
+
+func testRemove(t *testing.T, repo Repository) {
+	ctx := context.Background()
+
+	songs := save(t, repo, seed{"Muse", "Uprising", models.SongDetails{}}, seed{"Muse", "Resistance", models.SongDetails{}})
+
+	if _, err := repo.Remove(ctx, songs[0].Id); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if _, err := repo.GetById(ctx, songs[0].Id); !errors.Is(err, storage.ErrSongNotFound) {
+		t.Errorf("GetById after Remove: err = %v, want ErrSongNotFound", err)
+	}
+	if _, err := repo.GetById(ctx, songs[1].Id); err != nil {
+		t.Errorf("GetById of the other song: %v", err)
+	}
+
+	got, err := repo.GetAll(ctx, models.SongFilter{SongName: "uprising"}, 10, 0)
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("searching a removed song = %q, want nothing", titles(got))
+	}
+
+	// The title is free again.
+	if _, err := repo.Save(ctx, "Muse", "Uprising", models.SongDetails{}); err != nil {
+		t.Errorf("saving a removed song again: %v", err)
+	}
+}
+
+func testRemoveNotFound(t *testing.T, repo Repository) {
+	ctx := context.Background()
+
+	song := save(t, repo, seed{"Muse", "Uprising", models.SongDetails{}})[0]
+
+	if _, err := repo.Remove(ctx, missingID(t, repo)); !errors.Is(err, storage.ErrSongNotFound) {
+		t.Errorf("Remove of a missing id: err = %v, want ErrSongNotFound", err)
+	}
+	if _, err := repo.Remove(ctx, song.Id); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if _, err := repo.Remove(ctx, song.Id); !errors.Is(err, storage.ErrSongNotFound) {
+		t.Errorf("removing twice: err = %v, want ErrSongNotFound", err)
+	}
+}
+
+func testDetails(t *testing.T, repo Repository) {
+	ctx := context.Background()
+
+	songs := save(t, repo,
+		seed{"Muse", "Uprising", models.SongDetails{ReleaseDate: "2009-09-07"}},
+		seed{"Muse", "Resistance", models.SongDetails{ReleaseDate: "2009-09-14", Link: "l", Lyrics: "x"}},
+	)
+
+	err := repo.FillDetails(ctx, songs[0].Id, models.SongDetails{
+		ReleaseDate: "2000-01-01", Link: "https://example.com", Lyrics: "found", LyricsSource: "provider",
+	})
+	if err != nil {
+		t.Fatalf("FillDetails: %v", err)
+	}
+	got, err := repo.GetById(ctx, songs[0].Id)
+	if err != nil {
+		t.Fatalf("GetById: %v", err)
+	}
+	if got.ReleaseDate != "2009-09-07" || got.Link != "https://example.com" || got.Lyrics != "found" ||
+		got.LyricsSource != "provider" {
+		t.Errorf("after FillDetails = %+v, want only the empty fields filled", got)
+	}
+
+	if err := repo.FillDetails(ctx, songs[1].Id, models.SongDetails{Link: "other", Lyrics: "other"}); err != nil {
+		t.Fatalf("FillDetails: %v", err)
+	}
+	if got := find(t, repo, "Muse", "Resistance"); got != songs[1] {
+		t.Errorf("FillDetails overwrote a complete song: %+v", got)
+	}
+
+	got, err = repo.SetLyrics(ctx, songs[0].Id, "typed by hand", models.LyricsSourceManual)
+	if err != nil {
+		t.Fatalf("SetLyrics: %v", err)
+	}
+	if got.Lyrics != "typed by hand" || got.LyricsSource != models.LyricsSourceManual {
+		t.Errorf("SetLyrics = %+v, want the manual lyrics", got)
+	}
+	if read, _ := repo.GetById(ctx, songs[0].Id); read != got {
+		t.Errorf("GetById after SetLyrics = %+v, want %+v", read, got)
+	}
+}
+
+func testDetailsNotFound(t *testing.T, repo Repository) {
+	ctx := context.Background()
+
+	save(t, repo, seed{"Muse", "Uprising", models.SongDetails{}})
+	id := missingID(t, repo)
+
+	if err := repo.FillDetails(ctx, id, models.SongDetails{Lyrics: "x"}); !errors.Is(err, storage.ErrSongNotFound) {
+		t.Errorf("FillDetails of a missing id: err = %v, want ErrSongNotFound", err)
+	}
+	if _, err := repo.SetLyrics(ctx, id, "x", models.LyricsSourceManual); !errors.Is(err, storage.ErrSongNotFound) {
+		t.Errorf("SetLyrics of a missing id: err = %v, want ErrSongNotFound", err)
+	}
+}
+
+func testListIncomplete(t *testing.T, repo Repository) {
+	ctx := context.Background()
+
+	complete := models.SongDetails{ReleaseDate: "2009-09-07", Link: "l", Lyrics: "x"}
+	songs := save(t, repo,
+		seed{"Muse", "No date", models.SongDetails{Link: "l", Lyrics: "x"}},
+		seed{"Muse", "Complete", complete},
+		seed{"Muse", "No link", models.SongDetails{ReleaseDate: "2009-09-07", Lyrics: "x"}},
+		seed{"Muse", "No lyrics", models.SongDetails{ReleaseDate: "2009-09-07", Link: "l"}},
+		seed{"Muse", "Nothing", models.SongDetails{}},
+	)
+
+	tests := []struct {
+		name    string
+		afterId int64
+		limit   int
+		want    []string
+	}{
+		{"all", 0, 10, []string{"No date", "No link", "No lyrics", "Nothing"}},
+		{"limited", 0, 2, []string{"No date", "No link"}},
+		{"after id", songs[2].Id, 10, []string{"No lyrics", "Nothing"}},
+		{"after the last", songs[4].Id, 10, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.ListIncomplete(ctx, tt.afterId, tt.limit)
+			if err != nil {
+				t.Fatalf("ListIncomplete: %v", err)
+			}
+			if !slices.Equal(titles(got), tt.want) {
+				t.Errorf("ListIncomplete(%d, %d) = %q, want %q", tt.afterId, tt.limit, titles(got), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+// Package storagetest is the conformance suite every storage backend has to
+// pass. Backends run it from their own tests:
+//
+//	func TestConformance(t *testing.T) {
+//		storagetest.Run(t, func(t *testing.T) storagetest.Repository {
+//			return memory.New()
+//		})
+//	}
+package storagetest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/service"
+)
+
+// Repository is the part of a backend covered by the suite.
+type Repository interface {
+	service.SongRepository
+	service.GroupRepository
+	service.TxManager
+}
+
+// test is one case of a suite, run on its own empty repository.
+type test[R any] struct {
+	name string
+	run  func(t *testing.T, repo R)
+}
+
+// runSuite runs every test as a subtest on a repository returned by open.
+func runSuite[R any](t *testing.T, open func(t *testing.T) R, tests []test[R]) {
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, open(t))
+		})
+	}
+}
+
+// Run runs the suite. open must return an empty repository; it is called
+// once per test and may register cleanups on t.
+func Run(t *testing.T, open func(t *testing.T) Repository) {
+	runSuite(t, open, []test[Repository]{
+		{"SaveAndGet", testSaveAndGet},
+		{"SaveConflicts", testSaveConflicts},
+		{"GetNotFound", testGetNotFound},
+		{"Update", testUpdate},
+		{"UpdateNotFound", testUpdateNotFound},
+		{"Remove", testRemove},
+		{"RemoveNotFound", testRemoveNotFound},
+		{"Details", testDetails},
+		{"DetailsNotFound", testDetailsNotFound},
+		{"LyricsRevisions", testLyricsRevisions},
+		{"LyricsRevisionsNotFound", testLyricsRevisionsNotFound},
+		{"ListIncomplete", testListIncomplete},
+		{"Search", testSearch},
+		{"FilterCombinations", testFilterCombinations},
+		{"Pagination", testPagination},
+		{"MergeGroups", testMergeGroups},
+		{"MergeIntoNewGroup", testMergeIntoNewGroup},
+		{"TxCommit", testTxCommit},
+		{"TxRollback", testTxRollback},
+		{"TxNested", testTxNested},
+	})
+}
+
+type seed struct {
+	group, song string
+	details     models.SongDetails
+}
+
+// save stores the songs in order and returns them as read back, so ids grow
+// in the same order.
+func save(t *testing.T, repo Repository, songs ...seed) []models.Song {
+	t.Helper()
+
+	saved := make([]models.Song, 0, len(songs))
+	for _, s := range songs {
+		if _, err := repo.Save(context.Background(), s.group, s.song, s.details); err != nil {
+			t.Fatalf("Save(%q, %q): %v", s.group, s.song, err)
+		}
+		saved = append(saved, find(t, repo, s.group, s.song))
+	}
+	return saved
+}
+
+// all returns every song, newest first.
+func all(t *testing.T, repo Repository) []*models.Song {
+	t.Helper()
+
+	songs, err := repo.GetAll(context.Background(), models.SongFilter{}, 1000, 0)
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	return songs
+}
+
+// find returns the song with exactly the given group and title.
+func find(t *testing.T, repo Repository, group, song string) models.Song {
+	t.Helper()
+
+	for _, s := range all(t, repo) {
+		if s.GroupName.GroupName == group && s.SongName == song {
+			return *s
+		}
+	}
+	t.Fatalf("song %q by %q not found", song, group)
+	return models.Song{}
+}
+
+func titles(songs []*models.Song) []string {
+	result := []string{}
+	for _, s := range songs {
+		result = append(result, s.SongName)
+	}
+	return result
+}
+
+func ids(songs []*models.Song) []int64 {
+	result := []int64{}
+	for _, s := range songs {
+		result = append(result, s.Id)
+	}
+	return result
+}
+
+// missingID returns an id no song has.
+func missingID(t *testing.T, repo Repository) int64 {
+	t.Helper()
+
+	id := int64(1000)
+	for _, s := range all(t, repo) {
+		id = max(id, s.Id+1000)
+	}
+	return id
+}
@@ -0,0 +1,93 @@
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/storage"
+)
+
+var errAbort = errors.New("abort")
+
+func testTxCommit(t *testing.T, repo Repository) {
+	songs := save(t, repo, seed{"Muse", "Uprising", models.SongDetails{}})
+
+	err := repo.WithinTx(context.Background(), func(ctx context.Context) error {
+		if _, err := repo.Save(ctx, "Muse", "Starlight", models.SongDetails{}); err != nil {
+			return err
+		}
+		if _, err := repo.Update(ctx, songs[0].Id, "Muse", "Uprising (Live)"); err != nil {
+			return err
+		}
+		// Reads inside the unit of work see its own writes.
+		got, err := repo.GetById(ctx, songs[0].Id)
+		if err != nil {
+			return err
+		}
+		if got.SongName != "Uprising (Live)" {
+			t.Errorf("GetById inside the unit of work = %q, want the updated title", got.SongName)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithinTx: %v", err)
+	}
+
+	find(t, repo, "Muse", "Starlight")
+	find(t, repo, "Muse", "Uprising (Live)")
+}
+
+func testTxRollback(t *testing.T, repo Repository) {
+	songs := save(t, repo, seed{"Muse", "Uprising", models.SongDetails{}})
+
+	err := repo.WithinTx(context.Background(), func(ctx context.Context) error {
+		if _, err := repo.Save(ctx, "Radiohead", "Creep", models.SongDetails{}); err != nil {
+			return err
+		}
+		if _, err := repo.Update(ctx, songs[0].Id, "Muse", "Resistance"); err != nil {
+			return err
+		}
+		if _, err := repo.MergeGroups(ctx, []string{"Radiohead"}, "Muse", false); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("WithinTx: err = %v, want the error returned by fn", err)
+	}
+
+	got := all(t, repo)
+	if len(got) != 1 || got[0].SongName != "Uprising" || got[0].GroupName.GroupName != "Muse" {
+		t.Errorf("after rollback songs = %v, want only the original Uprising", titles(got))
+	}
+
+	_, err = repo.MergeGroups(context.Background(), []string{"Radiohead"}, "Muse", true)
+	if !errors.Is(err, storage.ErrGroupNotFound) {
+		t.Errorf("group created in a rolled back unit of work: err = %v, want ErrGroupNotFound", err)
+	}
+}
+
+func testTxNested(t *testing.T, repo Repository) {
+	err := repo.WithinTx(context.Background(), func(ctx context.Context) error {
+		if _, err := repo.Save(ctx, "Muse", "Uprising", models.SongDetails{}); err != nil {
+			return err
+		}
+		// The inner call joins the outer unit of work, so its error only
+		// reaches the caller and the outer rollback undoes both saves.
+		return repo.WithinTx(ctx, func(ctx context.Context) error {
+			if _, err := repo.Save(ctx, "Muse", "Starlight", models.SongDetails{}); err != nil {
+				return err
+			}
+			return errAbort
+		})
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("WithinTx: err = %v, want the error returned by the inner fn", err)
+	}
+
+	if got := all(t, repo); len(got) != 0 {
+		t.Errorf("after rollback songs = %v, want none", titles(got))
+	}
+}
@@ -0,0 +1,137 @@
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/service"
+	"github.com/2pizzzza/TestTask/internal/storage"
+)
+
+// RunUsers runs the user account part of the suite for backends that keep
+// users.
+func RunUsers(t *testing.T, open func(t *testing.T) service.UserRepository) {
+	runSuite(t, open, []test[service.UserRepository]{
+		{"CreateAndLookup", testUserCreateAndLookup},
+		{"SetRole", testUserSetRole},
+		{"RefreshToken", testUserRefreshToken},
+	})
+}
+
+func testUserCreateAndLookup(t *testing.T, repo service.UserRepository) {
+	ctx := context.Background()
+
+	created, err := repo.CreateUser(ctx, models.User{Username: "alice", Role: models.RoleEditor}, "hash-1")
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if created.Id == 0 || created.CreatedAt.IsZero() || created.Role != models.RoleEditor {
+		t.Errorf("CreateUser = %+v, want an id, a creation time and the editor role", created)
+	}
+
+	if _, err := repo.CreateUser(ctx, models.User{Username: "alice", Role: models.RoleViewer}, "hash-2"); !errors.Is(err, storage.ErrUserExists) {
+		t.Errorf("CreateUser(duplicate): err = %v, want ErrUserExists", err)
+	}
+
+	got, hash, err := repo.UserByName(ctx, "alice")
+	if err != nil {
+		t.Fatalf("UserByName: %v", err)
+	}
+	if got.Id != created.Id || got.Role != models.RoleEditor || hash != "hash-1" {
+		t.Errorf("UserByName = %+v, %q, want %+v, %q", got, hash, created, "hash-1")
+	}
+
+	if _, _, err := repo.UserByName(ctx, "bob"); !errors.Is(err, storage.ErrUserNotFound) {
+		t.Errorf("UserByName(unknown): err = %v, want ErrUserNotFound", err)
+	}
+
+	byId, err := repo.GetUser(ctx, created.Id)
+	if err != nil {
+		t.Fatalf("GetUser: %v", err)
+	}
+	if byId.Username != "alice" {
+		t.Errorf("GetUser = %+v, want alice", byId)
+	}
+	if _, err := repo.GetUser(ctx, created.Id+1000); !errors.Is(err, storage.ErrUserNotFound) {
+		t.Errorf("GetUser(unknown): err = %v, want ErrUserNotFound", err)
+	}
+
+	if _, err := repo.CreateUser(ctx, models.User{Username: "bob", Role: models.RoleViewer}, "hash-2"); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	users, err := repo.ListUsers(ctx)
+	if err != nil {
+		t.Fatalf("ListUsers: %v", err)
+	}
+	if len(users) != 2 || users[0].Username != "alice" || users[1].Username != "bob" {
+		t.Errorf("ListUsers = %+v, want alice and bob in creation order", users)
+	}
+}
+
+func testUserSetRole(t *testing.T, repo service.UserRepository) {
+	ctx := context.Background()
+
+	created, err := repo.CreateUser(ctx, models.User{Username: "carol", Role: models.RoleViewer}, "hash-1")
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	updated, err := repo.SetUserRole(ctx, created.Id, models.RoleModerator)
+	if err != nil {
+		t.Fatalf("SetUserRole: %v", err)
+	}
+	if updated.Role != models.RoleModerator || updated.Username != "carol" {
+		t.Errorf("SetUserRole = %+v, want carol as moderator", updated)
+	}
+
+	got, err := repo.GetUser(ctx, created.Id)
+	if err != nil {
+		t.Fatalf("GetUser: %v", err)
+	}
+	if got.Role != models.RoleModerator {
+		t.Errorf("role after SetUserRole = %q, want %q", got.Role, models.RoleModerator)
+	}
+
+	if _, err := repo.SetUserRole(ctx, created.Id+1000, models.RoleAdmin); !errors.Is(err, storage.ErrUserNotFound) {
+		t.Errorf("SetUserRole(unknown): err = %v, want ErrUserNotFound", err)
+	}
+}
+
+func testUserRefreshToken(t *testing.T, repo service.UserRepository) {
+	ctx := context.Background()
+
+	user, err := repo.CreateUser(ctx, models.User{Username: "dave", Role: models.RoleViewer}, "hash-1")
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	now := time.Now()
+	if err := repo.SaveRefreshToken(ctx, user.Id, "token-1", now.Add(time.Hour)); err != nil {
+		t.Fatalf("SaveRefreshToken: %v", err)
+	}
+	if err := repo.SaveRefreshToken(ctx, user.Id, "token-2", now.Add(-time.Minute)); err != nil {
+		t.Fatalf("SaveRefreshToken: %v", err)
+	}
+
+	userId, err := repo.UseRefreshToken(ctx, "token-1", now)
+	if err != nil {
+		t.Fatalf("UseRefreshToken: %v", err)
+	}
+	if userId != user.Id {
+		t.Errorf("UseRefreshToken = %d, want %d", userId, user.Id)
+	}
+
+	// A refresh token works once.
+	if _, err := repo.UseRefreshToken(ctx, "token-1", now); !errors.Is(err, storage.ErrTokenNotFound) {
+		t.Errorf("UseRefreshToken(spent): err = %v, want ErrTokenNotFound", err)
+	}
+	if _, err := repo.UseRefreshToken(ctx, "token-2", now); !errors.Is(err, storage.ErrTokenNotFound) {
+		t.Errorf("UseRefreshToken(expired): err = %v, want ErrTokenNotFound", err)
+	}
+	if _, err := repo.UseRefreshToken(ctx, "token-3", now); !errors.Is(err, storage.ErrTokenNotFound) {
+		t.Errorf("UseRefreshToken(unknown): err = %v, want ErrTokenNotFound", err)
+	}
+}
@@ -0,0 +1,133 @@
+package storagetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/service"
+	"github.com/2pizzzza/TestTask/internal/webhook"
+)
+
+// WebhookRepository is the part of a backend the webhook job suite needs.
+type WebhookRepository interface {
+	service.WebhookRepository
+	webhook.Store
+}
+
+// RunWebhookJobs runs the webhook delivery queue part of the suite.
+func RunWebhookJobs(t *testing.T, open func(t *testing.T) WebhookRepository) {
+	runSuite(t, open, []test[WebhookRepository]{
+		{"ClaimAndLease", testWebhookJobClaimAndLease},
+		{"Reschedule", testWebhookJobReschedule},
+		{"DeletedWithWebhook", testWebhookJobDeletedWithWebhook},
+	})
+}
+
+// enqueue creates a webhook and stores one job per event id, all due at due.
+func enqueue(t *testing.T, repo WebhookRepository, due time.Time, eventIds ...int64) models.Webhook {
+	t.Helper()
+	ctx := context.Background()
+
+	hook, err := repo.CreateWebhook(ctx, models.Webhook{URL: "http://example.com/hook", Secret: "s", Active: true})
+	if err != nil {
+		t.Fatalf("CreateWebhook: %v", err)
+	}
+
+	jobs := make([]models.WebhookJob, 0, len(eventIds))
+	for _, id := range eventIds {
+		jobs = append(jobs, models.WebhookJob{
+			WebhookId: hook.Id, EventId: id, EventType: "song.created", Body: []byte(`{"id":1}`), NextAttemptAt: due,
+		})
+	}
+	if err := repo.EnqueueWebhookJobs(ctx, jobs); err != nil {
+		t.Fatalf("EnqueueWebhookJobs: %v", err)
+	}
+
+	return hook
+}
+
+func claim(t *testing.T, repo WebhookRepository, now time.Time, limit int) []models.WebhookJob {
+	t.Helper()
+
+	jobs, err := repo.ClaimWebhookJobs(context.Background(), now, now.Add(time.Minute), limit)
+	if err != nil {
+		t.Fatalf("ClaimWebhookJobs: %v", err)
+	}
+	return jobs
+}
+
+func testWebhookJobClaimAndLease(t *testing.T, repo WebhookRepository) {
+	now := time.Now()
+	hook := enqueue(t, repo, now.Add(-time.Second), 1, 2, 3)
+
+	jobs := claim(t, repo, now, 2)
+	if len(jobs) != 2 {
+		t.Fatalf("claimed %d jobs, want the limit of 2", len(jobs))
+	}
+	for _, job := range jobs {
+		if job.WebhookId != hook.Id || job.Attempts != 1 || string(job.Body) != `{"id":1}` || job.EventType != "song.created" {
+			t.Errorf("claimed job = %+v, want the stored job on its first attempt", job)
+		}
+	}
+
+	// Claimed jobs are leased; only the third one is left.
+	if jobs := claim(t, repo, now, 10); len(jobs) != 1 || jobs[0].EventId != 3 {
+		t.Fatalf("second claim = %+v, want only event 3", jobs)
+	}
+	if jobs := claim(t, repo, now, 10); len(jobs) != 0 {
+		t.Fatalf("third claim = %+v, want every job leased", jobs)
+	}
+
+	// Once the lease expires the job is claimed again, counting the attempt.
+	jobs = claim(t, repo, now.Add(2*time.Minute), 10)
+	if len(jobs) != 3 {
+		t.Fatalf("claim after the lease = %d jobs, want 3", len(jobs))
+	}
+	for _, job := range jobs {
+		if job.Attempts != 2 {
+			t.Errorf("job %d: attempts = %d, want 2", job.Id, job.Attempts)
+		}
+	}
+}
+
+func testWebhookJobReschedule(t *testing.T, repo WebhookRepository) {
+	ctx := context.Background()
+	now := time.Now()
+	enqueue(t, repo, now, 1)
+
+	jobs := claim(t, repo, now, 1)
+	if len(jobs) != 1 {
+		t.Fatalf("claimed %d jobs, want 1", len(jobs))
+	}
+	if err := repo.RescheduleWebhookJob(ctx, jobs[0].Id, now.Add(time.Hour)); err != nil {
+		t.Fatalf("RescheduleWebhookJob: %v", err)
+	}
+
+	if jobs := claim(t, repo, now.Add(30*time.Minute), 1); len(jobs) != 0 {
+		t.Errorf("claimed %+v before the retry time", jobs)
+	}
+	if jobs := claim(t, repo, now.Add(2*time.Hour), 1); len(jobs) != 1 || jobs[0].Attempts != 2 {
+		t.Errorf("claim after the retry time = %+v, want the job on its second attempt", jobs)
+	}
+
+	if err := repo.DeleteWebhookJob(ctx, jobs[0].Id); err != nil {
+		t.Fatalf("DeleteWebhookJob: %v", err)
+	}
+	if jobs := claim(t, repo, now.Add(24*time.Hour), 1); len(jobs) != 0 {
+		t.Errorf("claimed %+v after DeleteWebhookJob", jobs)
+	}
+}
+
+func testWebhookJobDeletedWithWebhook(t *testing.T, repo WebhookRepository) {
+	now := time.Now()
+	hook := enqueue(t, repo, now, 1)
+
+	if err := repo.DeleteWebhook(context.Background(), hook.Id); err != nil {
+		t.Fatalf("DeleteWebhook: %v", err)
+	}
+	if jobs := claim(t, repo, now, 10); len(jobs) != 0 {
+		t.Errorf("claimed %+v after the webhook was deleted", jobs)
+	}
+}
@@ -0,0 +1,12 @@
+package utils
+
+import "net/url"
+
+// IsHTTPURL reports whether raw is an absolute http or https URL.
+func IsHTTPURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -0,0 +1,307 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/2pizzzza/TestTask/internal/config"
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/events"
+	"github.com/2pizzzza/TestTask/internal/lib/logger/sl"
+	"github.com/2pizzzza/TestTask/internal/storage"
+)
+
+const (
+	HeaderSignature = "X-Webhook-Signature-256"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+
+	// maxResponseBody is how much of the receiver's answer a delivery
+	// keeps: enough to debug a rejection, too little to read documents
+	// through a webhook.
+	maxResponseBody = 256
+)
+
+// leaseMargin is added to the request timeout to get the time a claimed job
+// stays hidden from other workers; it must outlast one delivery attempt.
+const leaseMargin = 30 * time.Second
+
+type Store interface {
+	GetWebhook(ctx context.Context, id int64) (models.Webhook, error)
+	ActiveWebhooks(ctx context.Context) ([]models.Webhook, error)
+	EnqueueWebhookJobs(ctx context.Context, jobs []models.WebhookJob) error
+	ClaimWebhookJobs(ctx context.Context, now, leaseUntil time.Time, limit int) ([]models.WebhookJob, error)
+	RescheduleWebhookJob(ctx context.Context, id int64, at time.Time) error
+	DeleteWebhookJob(ctx context.Context, id int64) error
+	SaveDelivery(ctx context.Context, d models.WebhookDelivery) error
+	RecordWebhookResult(ctx context.Context, id int64, success bool, maxFailures int) (bool, error)
+}
+
+// Dispatcher is an events.Publisher that stores a delivery job for every
+// subscribed webhook. Background workers drain the jobs from storage,
+// retrying failed deliveries with backoff, so pending deliveries survive
+// restarts.
+type Dispatcher struct {
+	log    *slog.Logger
+	store  Store
+	cfg    config.WebhookConfig
+	client *http.Client
+	wg     sync.WaitGroup
+}
+
+func New(log *slog.Logger, store Store, cfg config.WebhookConfig) *Dispatcher {
+	return &Dispatcher{
+		log:    log,
+		store:  store,
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout, Transport: newTransport(cfg.AllowPrivate)},
+	}
+}
+
+// Start launches the delivery workers; they stop when ctx is cancelled.
+func (d *Dispatcher) Start(ctx context.Context) {
+	for i := 0; i < d.cfg.Workers; i++ {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			d.work(ctx)
+		}()
+	}
+}
+
+// Wait blocks until all workers have stopped.
+func (d *Dispatcher) Wait() {
+	d.wg.Wait()
+}
+
+func (d *Dispatcher) work(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for ctx.Err() == nil {
+		found, err := d.DeliverOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			d.log.Error("failed to claim webhook jobs", slog.String("op", "webhook.Dispatcher.work"), sl.Err(err))
+		}
+		if found {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-ticker.C:
+		}
+	}
+}
+
+// Publish stores a delivery job for every active webhook subscribed to the
+// event type. The relay calls it inside the transaction that marks the event
+// published, so either both are written or neither is.
+func (d *Dispatcher) Publish(ctx context.Context, event events.Event) error {
+	const op = "webhook.Dispatcher.Publish"
+
+	hooks, err := d.store.ActiveWebhooks(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	now := time.Now()
+	jobs := []models.WebhookJob{}
+	for _, hook := range hooks {
+		if hook.Accepts(event.Type) {
+			jobs = append(jobs, models.WebhookJob{
+				WebhookId:     hook.Id,
+				EventId:       event.Id,
+				EventType:     event.Type,
+				Body:          body,
+				NextAttemptAt: now,
+			})
+		}
+	}
+	if len(jobs) == 0 {
+		return nil
+	}
+
+	if err := d.store.EnqueueWebhookJobs(ctx, jobs); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// DeliverOnce claims one due job and makes a delivery attempt. It reports
+// whether there was a job to work on.
+func (d *Dispatcher) DeliverOnce(ctx context.Context) (bool, error) {
+	const op = "webhook.Dispatcher.DeliverOnce"
+
+	now := time.Now()
+	jobs, err := d.store.ClaimWebhookJobs(ctx, now, now.Add(d.cfg.Timeout+leaseMargin), 1)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(jobs) == 0 {
+		return false, nil
+	}
+
+	d.process(ctx, jobs[0])
+	return true, nil
+}
+
+func (d *Dispatcher) process(ctx context.Context, job models.WebhookJob) {
+	log := d.log.With(
+		slog.String("op", "webhook.Dispatcher.process"),
+		slog.Int64("webhook_id", job.WebhookId),
+		slog.Int64("event_id", job.EventId),
+	)
+
+	hook, err := d.store.GetWebhook(ctx, job.WebhookId)
+	if err != nil && !errors.Is(err, storage.ErrWebhookNotFound) {
+		// The lease runs out and the job is claimed again later.
+		log.Error("failed to load webhook", sl.Err(err))
+		return
+	}
+	if err != nil || !hook.Active {
+		// Deleted or disabled webhooks drop their pending deliveries.
+		d.deleteJob(ctx, log, job.Id)
+		return
+	}
+
+	delivery := d.deliver(ctx, hook, job.EventId, job.EventType, job.Body, job.Attempts)
+	if ctx.Err() != nil {
+		// Shutting down: the job is retried after its lease expires.
+		return
+	}
+
+	if !delivery.Success && job.Attempts < d.cfg.MaxAttempts {
+		if err := d.store.RescheduleWebhookJob(ctx, job.Id, time.Now().Add(d.backoff(job.Attempts))); err != nil {
+			log.Error("failed to reschedule webhook job", sl.Err(err))
+		}
+		return
+	}
+
+	d.deleteJob(ctx, log, job.Id)
+
+	disabled, err := d.store.RecordWebhookResult(ctx, hook.Id, delivery.Success, d.cfg.MaxFailures)
+	if err != nil {
+		log.Error("failed to record webhook result", sl.Err(err))
+		return
+	}
+	if disabled {
+		log.Warn("webhook disabled after repeated failures")
+	}
+}
+
+func (d *Dispatcher) deleteJob(ctx context.Context, log *slog.Logger, id int64) {
+	if err := d.store.DeleteWebhookJob(ctx, id); err != nil {
+		log.Error("failed to delete webhook job", sl.Err(err))
+	}
+}
+
+// backoff is the wait after the given failed attempt: BackoffBase doubled
+// for every earlier attempt, capped at BackoffMax.
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	wait := d.cfg.BackoffBase
+	for i := 1; i < attempt && wait < d.cfg.BackoffMax; i++ {
+		wait *= 2
+	}
+	return min(wait, d.cfg.BackoffMax)
+}
+
+// Deliver makes a single signed delivery attempt and records it.
+func (d *Dispatcher) Deliver(ctx context.Context, hook models.Webhook, event events.Event, attempt int) models.WebhookDelivery {
+	body, err := json.Marshal(event)
+	if err != nil {
+		delivery := models.WebhookDelivery{
+			WebhookId: hook.Id,
+			EventId:   event.Id,
+			EventType: event.Type,
+			Attempt:   attempt,
+			Error:     err.Error(),
+		}
+		return d.save(ctx, d.log, delivery)
+	}
+
+	return d.deliver(ctx, hook, event.Id, event.Type, body, attempt)
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, hook models.Webhook, eventId int64, eventType string, body []byte, attempt int) models.WebhookDelivery {
+	log := d.log.With(
+		slog.String("op", "webhook.Dispatcher.deliver"),
+		slog.Int64("webhook_id", hook.Id),
+	)
+
+	delivery := models.WebhookDelivery{
+		WebhookId: hook.Id,
+		EventId:   eventId,
+		EventType: eventType,
+		Attempt:   attempt,
+		CreatedAt: time.Now(),
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		delivery.Error = err.Error()
+		return d.save(ctx, log, delivery)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "SongLibraries-Webhooks/1.0")
+	req.Header.Set(HeaderEvent, eventType)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(eventId, 10))
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, "sha256="+Sign(hook.Secret, timestamp, body))
+
+	start := time.Now()
+	resp, err := d.client.Do(req)
+	delivery.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		delivery.Error = err.Error()
+		return d.save(ctx, log, delivery)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	delivery.StatusCode = resp.StatusCode
+	// The cut may split a character, which postgres would refuse to store.
+	delivery.ResponseBody = strings.ToValidUTF8(string(respBody), "")
+	delivery.Success = resp.StatusCode >= 200 && resp.StatusCode < 300
+
+	return d.save(ctx, log, delivery)
+}
+
+func (d *Dispatcher) save(ctx context.Context, log *slog.Logger, delivery models.WebhookDelivery) models.WebhookDelivery {
+	if err := d.store.SaveDelivery(ctx, delivery); err != nil {
+		log.Error("failed to save delivery", sl.Err(err))
+	}
+	return delivery
+}
+
+// Sign computes the hex HMAC-SHA256 of "timestamp.body" with the webhook secret.
+// Receivers recompute it to verify the X-Webhook-Signature-256 header.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
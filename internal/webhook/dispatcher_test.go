@@ -0,0 +1,274 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/2pizzzza/TestTask/internal/config"
+	"github.com/2pizzzza/TestTask/internal/domain/models"
+	"github.com/2pizzzza/TestTask/internal/events"
+	"github.com/2pizzzza/TestTask/internal/storage/memory"
+)
+
+func TestSign(t *testing.T) {
+	const want = "3dd1b9aef568d75f6790a84bd2e5dfa1f44409eef3cbdbd3f10b837376100c11"
+	if got := Sign("secret", "1700000000", []byte(`{"id":1}`)); got != want {
+		t.Errorf("Sign = %s, want %s", got, want)
+	}
+	if Sign("secret", "1700000001", []byte(`{"id":1}`)) == want {
+		t.Error("Sign ignores the timestamp")
+	}
+	if Sign("other", "1700000000", []byte(`{"id":1}`)) == want {
+		t.Error("Sign ignores the secret")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	d := New(slog.New(slog.NewTextHandler(io.Discard, nil)), nil, config.WebhookConfig{
+		BackoffBase: time.Second,
+		BackoffMax:  5 * time.Second,
+	})
+
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, w := range want {
+		if got := d.backoff(i + 1); got != w {
+			t.Errorf("backoff(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+}
+
+// receiver answers with the queued status codes, then with 200, and checks
+// the signature of every request.
+type receiver struct {
+	t      *testing.T
+	mu     sync.Mutex
+	codes  []int
+	calls  int
+	secret string
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	want := "sha256=" + Sign(rc.secret, r.Header.Get(HeaderTimestamp), body)
+	if got := r.Header.Get(HeaderSignature); got != want {
+		rc.t.Errorf("signature = %s, want %s", got, want)
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.calls++
+	code := http.StatusOK
+	if len(rc.codes) > 0 {
+		code, rc.codes = rc.codes[0], rc.codes[1:]
+	}
+	w.WriteHeader(code)
+}
+
+func (rc *receiver) count() int {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.calls
+}
+
+func setup(t *testing.T, codes ...int) (*Dispatcher, *memory.Storage, *receiver, models.Webhook) {
+	t.Helper()
+
+	rc := &receiver{t: t, codes: codes, secret: "s3cret"}
+	srv := httptest.NewServer(rc)
+	t.Cleanup(srv.Close)
+
+	db := memory.New()
+	hook, err := db.CreateWebhook(context.Background(), models.Webhook{
+		URL: srv.URL, Events: []string{"*"}, Secret: rc.secret, Active: true,
+	})
+	if err != nil {
+		t.Fatalf("CreateWebhook: %v", err)
+	}
+
+	d := New(slog.New(slog.NewTextHandler(io.Discard, nil)), db, config.WebhookConfig{
+		Workers:      1,
+		PollInterval: time.Second,
+		MaxAttempts:  3,
+		BackoffBase:  time.Hour,
+		BackoffMax:   time.Hour,
+		MaxFailures:  10,
+		Timeout:      time.Second,
+		AllowPrivate: true, // the receiver listens on loopback
+	})
+	if err := d.Publish(context.Background(), events.Event{Id: 7, Type: events.SongCreated}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	return d, db, rc, hook
+}
+
+func deliverOnce(t *testing.T, d *Dispatcher) bool {
+	t.Helper()
+	found, err := d.DeliverOnce(context.Background())
+	if err != nil {
+		t.Fatalf("DeliverOnce: %v", err)
+	}
+	return found
+}
+
+// expire makes the stored job due now, as if its backoff had passed.
+func expire(t *testing.T, db *memory.Storage) {
+	t.Helper()
+	if err := db.RescheduleWebhookJob(context.Background(), 1, time.Now()); err != nil {
+		t.Fatalf("RescheduleWebhookJob: %v", err)
+	}
+}
+
+func TestDeliverOnceRetriesWithBackoff(t *testing.T) {
+	d, db, rc, hook := setup(t, http.StatusInternalServerError)
+	ctx := context.Background()
+
+	if !deliverOnce(t, d) {
+		t.Fatal("DeliverOnce found no job after Publish")
+	}
+	// The failed job waits out its backoff.
+	if deliverOnce(t, d) {
+		t.Fatal("DeliverOnce retried before the backoff elapsed")
+	}
+
+	// A new dispatcher, as after a restart, picks the stored job up.
+	expire(t, db)
+	d = New(d.log, db, d.cfg)
+	if !deliverOnce(t, d) {
+		t.Fatal("DeliverOnce lost the job")
+	}
+	if deliverOnce(t, d) {
+		t.Fatal("job left after a successful delivery")
+	}
+
+	deliveries, err := db.ListDeliveries(ctx, hook.Id, 10)
+	if err != nil {
+		t.Fatalf("ListDeliveries: %v", err)
+	}
+	if len(deliveries) != 2 || deliveries[0].Attempt != 2 || !deliveries[0].Success ||
+		deliveries[1].Attempt != 1 || deliveries[1].Success {
+		t.Errorf("deliveries = %+v, want a failed first attempt and a successful second", deliveries)
+	}
+	if rc.count() != 2 {
+		t.Errorf("receiver got %d requests, want 2", rc.count())
+	}
+}
+
+func TestDeliverOnceGivesUpAfterMaxAttempts(t *testing.T) {
+	d, db, rc, hook := setup(t, http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if i > 0 {
+			expire(t, db)
+		}
+		if !deliverOnce(t, d) {
+			t.Fatalf("attempt %d: no job", i+1)
+		}
+	}
+	expire(t, db)
+	if deliverOnce(t, d) {
+		t.Fatal("job retried past MaxAttempts")
+	}
+	if rc.count() != 3 {
+		t.Errorf("receiver got %d requests, want 3", rc.count())
+	}
+
+	stored, err := db.GetWebhook(ctx, hook.Id)
+	if err != nil {
+		t.Fatalf("GetWebhook: %v", err)
+	}
+	if stored.FailureCount != 1 {
+		t.Errorf("FailureCount = %d, want the exhausted delivery counted once", stored.FailureCount)
+	}
+}
+
+func TestDeliverOnceDropsJobsOfDisabledWebhooks(t *testing.T) {
+	d, db, rc, hook := setup(t)
+	ctx := context.Background()
+
+	hook.Active = false
+	if _, err := db.UpdateWebhook(ctx, hook); err != nil {
+		t.Fatalf("UpdateWebhook: %v", err)
+	}
+
+	if !deliverOnce(t, d) {
+		t.Fatal("DeliverOnce found no job after Publish")
+	}
+	if rc.count() != 0 {
+		t.Errorf("receiver got %d requests for a disabled webhook", rc.count())
+	}
+	expire(t, db)
+	if deliverOnce(t, d) {
+		t.Fatal("job of a disabled webhook kept")
+	}
+}
+
+func TestPublishSkipsUnsubscribedWebhooks(t *testing.T) {
+	d, db, _, hook := setup(t)
+	ctx := context.Background()
+
+	hook.Events = []string{events.SongDeleted}
+	if _, err := db.UpdateWebhook(ctx, hook); err != nil {
+		t.Fatalf("UpdateWebhook: %v", err)
+	}
+	if err := d.Publish(ctx, events.Event{Id: 8, Type: events.SongUpdated}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	jobs, err := db.ClaimWebhookJobs(ctx, time.Now(), time.Now().Add(time.Minute), 10)
+	if err != nil {
+		t.Fatalf("ClaimWebhookJobs: %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].EventId != 7 || !strings.Contains(string(jobs[0].Body), `"id":7`) {
+		t.Errorf("jobs = %+v, want only the job for event 7", jobs)
+	}
+}
+
+func TestCheckAddress(t *testing.T) {
+	for address, allowed := range map[string]bool{
+		"93.184.216.34:443":       true,
+		"[2606:4700::1111]:443":   true,
+		"127.0.0.1:80":            false,
+		"10.1.2.3:80":             false,
+		"172.16.0.1:80":           false,
+		"192.168.1.1:80":          false,
+		"169.254.169.254:80":      false,
+		"100.64.0.1:80":           false,
+		"0.0.0.0:80":              false,
+		"[::1]:80":                false,
+		"[fd00::1]:80":            false,
+		"[fe80::1]:80":            false,
+		"[::ffff:127.0.0.1]:80":   false,
+		"[::ffff:169.254.1.1]:80": false,
+	} {
+		err := checkAddress("tcp", address, nil)
+		if allowed && err != nil {
+			t.Errorf("checkAddress(%s) = %v, want allowed", address, err)
+		}
+		if !allowed && !errors.Is(err, ErrForbiddenAddress) {
+			t.Errorf("checkAddress(%s) = %v, want ErrForbiddenAddress", address, err)
+		}
+	}
+}
+
+func TestDeliverRefusesPrivateAddresses(t *testing.T) {
+	d, db, rc, hook := setup(t)
+	d = New(d.log, db, config.WebhookConfig{Timeout: time.Second, MaxFailures: 10})
+
+	delivery := d.Deliver(context.Background(), hook, events.Event{Id: 1, Type: events.SongCreated}, 1)
+	if delivery.Success || !strings.Contains(delivery.Error, ErrForbiddenAddress.Error()) {
+		t.Errorf("delivery = %+v, want it refused", delivery)
+	}
+	if rc.count() != 0 {
+		t.Errorf("receiver got %d requests, want none", rc.count())
+	}
+}
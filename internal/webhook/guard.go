@@ -0,0 +1,72 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress is returned when a webhook resolves to an address
+// inside the network the service runs in.
+var ErrForbiddenAddress = errors.New("webhook address is not allowed")
+
+// Ranges that are not public but that netip.Addr has no predicate for.
+var nonPublic = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // "this network", reaches the host itself
+	netip.MustParsePrefix("100.64.0.0/10"), // carrier-grade NAT
+}
+
+// newTransport returns the transport for webhook requests. Unless
+// allowPrivate is set, connections to loopback, private, link-local (cloud
+// metadata included) and other non-public addresses are refused. The check
+// runs on the resolved address of every connection, redirects included, so
+// a DNS name that later resolves elsewhere cannot get around it.
+func newTransport(allowPrivate bool) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if allowPrivate {
+		return transport
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   checkAddress,
+	}
+	transport.DialContext = dialer.DialContext
+	// A proxy would make the dial go to the proxy instead of the webhook.
+	transport.Proxy = nil
+
+	return transport
+}
+
+// checkAddress is a net.Dialer Control hook; address is the resolved ip:port.
+func checkAddress(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+	}
+
+	if !publicAddr(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, addrPort.Addr())
+	}
+
+	return nil
+}
+
+func publicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() || addr.IsLoopback() || addr.IsLinkLocalUnicast() {
+		return false
+	}
+	for _, prefix := range nonPublic {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}